@@ -0,0 +1,338 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/client"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type command struct {
+	usage       string
+	description string
+	run         func(ctx context.Context, env *environment, args []string) error
+}
+
+// environment is shared by all commands
+type environment struct {
+	client *client.Client
+	out    *output
+	stdin  io.Reader
+	stderr io.Writer
+}
+
+var commands = []struct {
+	name string
+	command
+}{
+	{"list", command{"list [flags]", "list devices", listCommand}},
+	{"search", command{"search [flags] <query>", "list devices matching the search query, e.g. 'name:sensor* AND attr.room:kitchen'", searchCommand}},
+	{"show", command{"show <local_id>", "show a device with its attributes", showCommand}},
+	{"use", command{"use <local_id>...", "create the devices in the device-manager and remove them from the waiting room", idsCommand("used", (*client.Client).UseDevices)}},
+	{"hide", command{"hide <local_id>...", "hide the devices from the default device list", idsCommand("hidden", (*client.Client).HideDevices)}},
+	{"unhide", command{"unhide <local_id>...", "show hidden devices in the default device list again", idsCommand("shown", (*client.Client).ShowDevices)}},
+	{"delete", command{"delete <local_id>...", "move the devices to the trash", idsCommand("deleted", (*client.Client).DeleteDevices)}},
+	{"export", command{"export [flags]", "export devices as ndjson or csv; accepts the filters of list", exportCommand}},
+	{"import", command{"import [flags] <file>", "import devices from a csv or ndjson file (- for stdin)", importCommand}},
+	{"tail", command{"tail", "print live events until interrupted", tailCommand}},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c.command, true
+		}
+	}
+	return command{}, false
+}
+
+// stringList is a flag that may be repeated
+type stringList []string
+
+func (this *stringList) String() string {
+	return strings.Join(*this, ",")
+}
+
+func (this *stringList) Set(value string) error {
+	*this = append(*this, value)
+	return nil
+}
+
+// flagError is returned for invalid command flags, which have already been reported by the flag set
+type flagError struct {
+	error
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return flagError{err}
+	}
+	return err
+}
+
+// listFlags adds the filter flags of list, search and export; the returned function builds the options after parsing
+func listFlags(flags *flag.FlagSet) func() (options.List, error) {
+	limit := flags.Int("limit", 100, "page size")
+	offset := flags.Int("offset", 0, "offset of the first device")
+	sort := flags.String("sort", "local_id", "sort field, optionally with .asc or .desc, e.g. updated_at.desc")
+	showHidden := flags.Bool("show-hidden", false, "include hidden devices")
+	search := flags.String("search", "", "search query")
+	attrs := &stringList{}
+	flags.Var(attrs, "attr", "attribute filter <key>=<value>; may be repeated")
+	attrExists := &stringList{}
+	flags.Var(attrExists, "attr-exists", "filter devices with an attribute of the key; may be repeated")
+	deviceTypes := &stringList{}
+	flags.Var(deviceTypes, "device-type", "filter by device type id; may be repeated")
+	updatedAfter := flags.String("updated-after", "", "filter devices updated at or after the time (RFC 3339)")
+	return func() (o options.List, err error) {
+		o = options.List{Limit: *limit, Offset: *offset, Sort: *sort, ShowHidden: *showHidden, Search: *search, DeviceTypeIds: *deviceTypes}
+		for _, attr := range *attrs {
+			key, value, found := strings.Cut(attr, "=")
+			if !found || key == "" {
+				return o, errors.New("expect -attr in the form <key>=<value>")
+			}
+			o.AttributeFilter = append(o.AttributeFilter, options.AttributeFilter{Key: key, Value: value, Operation: options.AttributeEquals})
+		}
+		for _, key := range *attrExists {
+			o.AttributeFilter = append(o.AttributeFilter, options.AttributeFilter{Key: key, Operation: options.AttributeExists})
+		}
+		if *updatedAfter != "" {
+			o.UpdatedAfter, err = time.Parse(time.RFC3339, *updatedAfter)
+			if err != nil {
+				return o, fmt.Errorf("invalid -updated-after: %w", err)
+			}
+		}
+		return o, nil
+	}
+}
+
+func listCommand(ctx context.Context, env *environment, args []string) error {
+	return list(ctx, env, "list", args, false)
+}
+
+func searchCommand(ctx context.Context, env *environment, args []string) error {
+	return list(ctx, env, "search", args, true)
+}
+
+func list(ctx context.Context, env *environment, name string, args []string, withQuery bool) error {
+	flags := newFlagSet(name, env)
+	getOptions := listFlags(flags)
+	all := flags.Bool("all", false, "page through all devices instead of listing a single page")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	o, err := getOptions()
+	if err != nil {
+		return usageError{err}
+	}
+	switch {
+	case withQuery && flags.NArg() == 1:
+		o.Search = flags.Arg(0)
+	case flags.NArg() != 0:
+		return usageError{fmt.Errorf("unexpected arguments %v", flags.Args())}
+	}
+	if *all {
+		devices, err := env.client.IterateDevices(o).All(ctx)
+		if err != nil {
+			return err
+		}
+		if env.out.isJson() {
+			return env.out.json(devices)
+		}
+		err = env.out.devices(devices)
+		env.out.line("%v devices", len(devices))
+		return err
+	}
+	result, err := env.client.ListDevices(ctx, o)
+	if err != nil {
+		return err
+	}
+	if env.out.isJson() {
+		return env.out.json(result)
+	}
+	err = env.out.devices(result.Result)
+	env.out.line("%v-%v of %v devices", min(int64(result.Offset+1), result.Total), int64(result.Offset)+int64(len(result.Result)), result.Total)
+	return err
+}
+
+func showCommand(ctx context.Context, env *environment, args []string) error {
+	if len(args) != 1 {
+		return usageError{errors.New("expect exactly one local_id")}
+	}
+	device, err := env.client.ReadDevice(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if env.out.isJson() {
+		return env.out.json(device)
+	}
+	return env.out.device(device)
+}
+
+// idsCommand calls the bulk endpoint with all arguments as local ids
+func idsCommand(done string, f func(c *client.Client, ctx context.Context, localIds []string) error) func(ctx context.Context, env *environment, args []string) error {
+	return func(ctx context.Context, env *environment, args []string) error {
+		if len(args) == 0 {
+			return usageError{errors.New("expect at least one local_id")}
+		}
+		err := f(env.client, ctx, args)
+		if err != nil {
+			return err
+		}
+		if env.out.isJson() {
+			return env.out.json(map[string][]string{done: args})
+		}
+		env.out.line("%v %v", done, strings.Join(args, ", "))
+		return nil
+	}
+}
+
+func exportCommand(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet("export", env)
+	getOptions := listFlags(flags)
+	format := flags.String("format", client.FormatNdjson, "ndjson or csv")
+	file := flags.String("file", "-", "output file; - for stdout")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	o, err := getOptions()
+	if err != nil {
+		return usageError{err}
+	}
+	if flags.NArg() != 0 {
+		return usageError{fmt.Errorf("unexpected arguments %v", flags.Args())}
+	}
+	if *file == "-" {
+		return env.client.WriteExport(ctx, o, *format, env.out.w)
+	}
+	f, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+	err = env.client.WriteExport(ctx, o, *format, f)
+	return errors.Join(err, f.Close())
+}
+
+func importCommand(ctx context.Context, env *environment, args []string) error {
+	flags := newFlagSet("import", env)
+	format := flags.String("format", "", "csv or ndjson; defaults to the file extension, else ndjson")
+	mappings := &stringList{}
+	flags.Var(mappings, "mapping", "<column>=<field> maps a column to local_id, name, device_type_id, attributes or attr.<key>; may be repeated")
+	delimiter := flags.String("delimiter", "", "csv delimiter; defaults to ','")
+	dryRun := flags.Bool("dry-run", false, "only validate the rows")
+	err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usageError{errors.New("expect exactly one file")}
+	}
+	o := client.ImportOptions{Format: *format, Delimiter: *delimiter, DryRun: *dryRun, Mapping: map[string]string{}}
+	for _, mapping := range *mappings {
+		column, field, found := strings.Cut(mapping, "=")
+		if !found {
+			return usageError{errors.New("expect -mapping in the form <column>=<field>")}
+		}
+		o.Mapping[column] = field
+	}
+	if o.Format == "" && strings.HasSuffix(strings.ToLower(flags.Arg(0)), ".csv") {
+		o.Format = client.FormatCsv
+	}
+	var body io.Reader = env.stdin
+	if flags.Arg(0) != "-" {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		body = file
+	}
+	result, err := env.client.ImportDevices(ctx, body, o)
+	if err != nil {
+		return err
+	}
+	if env.out.isJson() {
+		err = env.out.json(result)
+	} else {
+		err = printImportResult(env.out, result)
+	}
+	if err == nil && result.Failed > 0 {
+		err = fmt.Errorf("%v of %v rows failed", result.Failed, result.Total)
+	}
+	return err
+}
+
+func printImportResult(out *output, result model.ImportResult) error {
+	dryRun := ""
+	if result.DryRun {
+		dryRun = " (dry run)"
+	}
+	out.line("%v rows: %v created, %v updated, %v failed%v", result.Total, result.Created, result.Updated, result.Failed, dryRun)
+	if result.Failed == 0 {
+		return nil
+	}
+	rows := [][]string{}
+	for _, row := range result.Rows {
+		if row.Status == model.ImportStatusFailed {
+			rows = append(rows, []string{strconv.Itoa(row.Row), row.LocalId, row.Error})
+		}
+	}
+	out.line("")
+	return out.table([]string{"ROW", "LOCAL_ID", "ERROR"}, rows)
+}
+
+func tailCommand(ctx context.Context, env *environment, args []string) error {
+	if len(args) != 0 {
+		return usageError{fmt.Errorf("unexpected arguments %v", args)}
+	}
+	subscriber := env.client.NewSubscriber(func(eventType string, localId string) {
+		now := time.Now()
+		if env.out.isJson() {
+			_ = env.out.jsonLine(struct {
+				Time    time.Time `json:"time"`
+				Type    string    `json:"type"`
+				LocalId string    `json:"local_id"`
+			}{Time: now, Type: eventType, LocalId: localId})
+			return
+		}
+		env.out.line("%v  %-15v  %v", now.Format(time.RFC3339), eventType, localId)
+	})
+	subscriber.OnConnected = func() {
+		fmt.Fprintln(env.stderr, "connected, waiting for events")
+	}
+	subscriber.OnError = func(err error) {
+		fmt.Fprintln(env.stderr, "connection lost, reconnecting:", err)
+	}
+	err := subscriber.Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/client"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentials are read from a json config file and overwritten by the environment variables in credentialsEnv
+type credentials struct {
+	Url          string `json:"url"`           //base url of the api, e.g. https://api.example.com/device-waiting-room
+	Token        string `json:"token"`         //static token; if empty, tokens are requested from token_url
+	TokenUrl     string `json:"token_url"`     //openid token endpoint, e.g. https://auth.example.com/realms/master/protocol/openid-connect/token
+	ClientId     string `json:"client_id"`     //used with token_url
+	ClientSecret string `json:"client_secret"` //used with token_url
+	Username     string `json:"username"`      //uses the password grant if set, else the client_credentials grant
+	Password     string `json:"password"`
+}
+
+var credentialsEnv = map[string]func(c *credentials) *string{
+	"DWR_URL":           func(c *credentials) *string { return &c.Url },
+	"DWR_TOKEN":         func(c *credentials) *string { return &c.Token },
+	"DWR_TOKEN_URL":     func(c *credentials) *string { return &c.TokenUrl },
+	"DWR_CLIENT_ID":     func(c *credentials) *string { return &c.ClientId },
+	"DWR_CLIENT_SECRET": func(c *credentials) *string { return &c.ClientSecret },
+	"DWR_USERNAME":      func(c *credentials) *string { return &c.Username },
+	"DWR_PASSWORD":      func(c *credentials) *string { return &c.Password },
+}
+
+// defaultConfigLocation is <user config dir>/device-waiting-room/cli.json, e.g. ~/.config/device-waiting-room/cli.json
+func defaultConfigLocation() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "device-waiting-room", "cli.json")
+}
+
+// loadCredentials reads the config file at location, if it exists, and applies the environment;
+// a missing file is only an error if required is set
+func loadCredentials(location string, required bool, getenv func(string) string) (result credentials, err error) {
+	if location != "" {
+		file, err := os.Open(location)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && !required:
+		case err != nil:
+			return result, err
+		default:
+			defer file.Close()
+			err = json.NewDecoder(file).Decode(&result)
+			if err != nil {
+				return result, fmt.Errorf("invalid config %v: %w", location, err)
+			}
+		}
+	}
+	for name, field := range credentialsEnv {
+		if value := getenv(name); value != "" {
+			*field(&result) = value
+		}
+	}
+	return result, nil
+}
+
+func (this credentials) tokenProvider() (client.TokenProvider, error) {
+	switch {
+	case this.Token != "":
+		return client.StaticToken(this.Token), nil
+	case this.TokenUrl != "":
+		return (&openIdToken{credentials: this}).get, nil
+	default:
+		return nil, errors.New("missing credentials: set DWR_TOKEN or DWR_TOKEN_URL with client and user credentials, or use a config file")
+	}
+}
+
+// openIdToken requests tokens from the token endpoint and reuses them until shortly before they expire
+type openIdToken struct {
+	credentials credentials
+	mux         sync.Mutex
+	token       string
+	expiration  time.Time
+}
+
+// tokenRefreshMargin is the time before the expiration of a token, at which a new token is requested
+const tokenRefreshMargin = 10 * time.Second
+
+func (this *openIdToken) get(ctx context.Context) (string, error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	if this.token != "" && time.Now().Add(tokenRefreshMargin).Before(this.expiration) {
+		return this.token, nil
+	}
+	form := url.Values{"client_id": {this.credentials.ClientId}}
+	if this.credentials.ClientSecret != "" {
+		form.Set("client_secret", this.credentials.ClientSecret)
+	}
+	if this.credentials.Username != "" {
+		form.Set("grant_type", "password")
+		form.Set("username", this.credentials.Username)
+		form.Set("password", this.credentials.Password)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, this.credentials.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request failed: %v", resp.Status)
+	}
+	result := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if result.AccessToken == "" {
+		return "", errors.New("invalid token response: missing access_token")
+	}
+	this.token = result.AccessToken
+	this.expiration = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	return this.token, nil
+}
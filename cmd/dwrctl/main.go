@@ -0,0 +1,151 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// dwrctl is a command-line client of the device-waiting-room api for operators.
+// The api url and credentials are read from a config file (see credentials; -config or DWR_CONFIG) and the environment variables
+// DWR_URL, DWR_TOKEN, DWR_TOKEN_URL, DWR_CLIENT_ID, DWR_CLIENT_SECRET, DWR_USERNAME and DWR_PASSWORD, which take precedence over the file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/client"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Getenv, os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// usageError is printed together with the usage of the command
+type usageError struct {
+	err error
+}
+
+func (this usageError) Error() string {
+	return this.err.Error()
+}
+
+// run executes the command of args and returns the exit code: 0 on success, 1 on errors and 2 on invalid usage
+func run(ctx context.Context, args []string, getenv func(string) string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("dwrctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configLocation := flags.String("config", "", "credentials config file; overrides DWR_CONFIG (default "+defaultConfigLocation()+")")
+	apiUrl := flags.String("url", "", "api url; overrides DWR_URL and the config file")
+	outputFormat := flags.String("o", outputTable, "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: dwrctl [flags] <command> [command flags] [args]\n\nCommands:\n")
+		for _, c := range commands {
+			fmt.Fprintf(stderr, "  %-26v %v\n", c.usage, c.description)
+		}
+		fmt.Fprintf(stderr, "\nFlags:\n")
+		flags.PrintDefaults()
+		fmt.Fprintf(stderr, "\nEnvironment:\n  DWR_CONFIG, DWR_URL, DWR_TOKEN, DWR_TOKEN_URL, DWR_CLIENT_ID, DWR_CLIENT_SECRET, DWR_USERNAME, DWR_PASSWORD\n")
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	name := flags.Arg(0)
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %v\n", name)
+		flags.Usage()
+		return 2
+	}
+	if *outputFormat != outputTable && *outputFormat != outputJson {
+		fmt.Fprintf(stderr, "unknown output format %v, expected %v or %v\n", *outputFormat, outputTable, outputJson)
+		return 2
+	}
+
+	location, required := *configLocation, true
+	if location == "" {
+		location = getenv("DWR_CONFIG")
+	}
+	if location == "" {
+		location, required = defaultConfigLocation(), false
+	}
+	creds, err := loadCredentials(location, required, getenv)
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	if *apiUrl != "" {
+		creds.Url = *apiUrl
+	}
+	if creds.Url == "" {
+		fmt.Fprintln(stderr, "error: missing api url: set -url, DWR_URL or url in the config file")
+		return 1
+	}
+	token, err := creds.tokenProvider()
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+
+	env := &environment{
+		client: client.New(creds.Url, token),
+		out:    &output{w: stdout, format: *outputFormat},
+		stdin:  stdin,
+		stderr: stderr,
+	}
+	err = cmd.run(ctx, env, flags.Args()[1:])
+	var usageErr usageError
+	var flagErr flagError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &flagErr):
+		return 2
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "%v\nUsage: dwrctl %v\n", err, cmd.usage)
+		return 2
+	default:
+		printError(stderr, err)
+		return 1
+	}
+}
+
+// printError prints api errors with their code, e.g. "error: device not found (not_found, 404)"
+func printError(stderr io.Writer, err error) {
+	var apiErr *model.Error
+	if errors.As(err, &apiErr) {
+		fmt.Fprintf(stderr, "error: %v (%v, %v)\n", apiErr.Error(), apiErr.Code, model.ErrorStatus(apiErr))
+		return
+	}
+	fmt.Fprintln(stderr, "error:", err)
+}
+
+func newFlagSet(name string, env *environment) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+	return flags
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadCredentials(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "cli.json")
+	err := os.WriteFile(location, []byte(`{"url": "http://file", "token": "file-token", "client_id": "cli"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"DWR_TOKEN": "env-token"}
+	creds, err := loadCredentials(location, true, func(name string) string {
+		return env[name]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := credentials{Url: "http://file", Token: "env-token", ClientId: "cli"}
+	if creds != expected {
+		t.Errorf("%#v", creds)
+	}
+
+	_, err = loadCredentials(filepath.Join(t.TempDir(), "missing.json"), true, os.Getenv)
+	if err == nil {
+		t.Error("expect error for missing required config")
+	}
+	creds, err = loadCredentials(filepath.Join(t.TempDir(), "missing.json"), false, func(name string) string {
+		return env[name]
+	})
+	if err != nil || creds.Token != "env-token" {
+		t.Error(creds, err)
+	}
+}
+
+func TestOpenIdToken(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		if request.FormValue("grant_type") != "password" || request.FormValue("username") != "operator" || request.FormValue("client_id") != "cli" {
+			http.Error(writer, "invalid grant", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(writer).Encode(map[string]any{"access_token": "token", "expires_in": 300})
+	}))
+	defer server.Close()
+
+	provider, err := credentials{TokenUrl: server.URL, ClientId: "cli", Username: "operator", Password: "secret"}.tokenProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		token, err := provider(context.Background())
+		if err != nil || token != "token" {
+			t.Error(token, err)
+		}
+	}
+	if requests != 1 {
+		t.Error("expect cached token", requests)
+	}
+
+	_, err = credentials{}.tokenProvider()
+	if err == nil {
+		t.Error("expect error for missing credentials")
+	}
+}
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Authorization") != "Bearer test-token" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case request.Method == http.MethodGet && request.URL.Path == "/devices":
+			if request.URL.Query().Get("search") != "sensor" || request.URL.Query().Get("attr") != "room=kitchen" {
+				t.Error(request.URL.RawQuery)
+			}
+			json.NewEncoder(writer).Encode(model.DeviceList{Total: 1, Limit: 100, Result: []model.Device{
+				{Device: models.Device{LocalId: "lid1", Name: "sensor 1", DeviceTypeId: "dt1"}},
+			}})
+		case request.Method == http.MethodPut && request.URL.Path == "/hidden/devices":
+			ids := []string{}
+			json.NewDecoder(request.Body).Decode(&ids)
+			if strings.Join(ids, ",") != "lid1,lid2" {
+				t.Error(ids)
+			}
+		default:
+			problem := model.NewError(model.ErrorCodeNotFound, nil).WithLocalId("unknown").Problem()
+			problem.Detail = "device not found"
+			writer.Header().Set("Content-Type", model.ProblemContentType)
+			writer.WriteHeader(problem.Status)
+			json.NewEncoder(writer).Encode(problem)
+		}
+	}))
+	defer server.Close()
+
+	config := filepath.Join(t.TempDir(), "cli.json")
+	err := os.WriteFile(config, []byte(`{"url": "http://unused"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"DWR_CONFIG": config, "DWR_URL": server.URL, "DWR_TOKEN": "test-token"}
+	getenv := func(name string) string {
+		return env[name]
+	}
+	exec := func(args ...string) (code int, stdout string, stderr string) {
+		out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+		code = run(context.Background(), args, getenv, strings.NewReader(""), out, errOut)
+		return code, out.String(), errOut.String()
+	}
+
+	t.Run("search table", func(t *testing.T) {
+		code, stdout, stderr := exec("search", "-attr", "room=kitchen", "sensor")
+		if code != 0 || !strings.Contains(stdout, "LOCAL_ID") || !strings.Contains(stdout, "sensor 1") || !strings.Contains(stdout, "1-1 of 1 devices") {
+			t.Error(code, stdout, stderr)
+		}
+	})
+
+	t.Run("search json", func(t *testing.T) {
+		code, stdout, stderr := exec("-o", "json", "list", "-search", "sensor", "-attr", "room=kitchen")
+		list := model.DeviceList{}
+		if code != 0 || json.Unmarshal([]byte(stdout), &list) != nil || list.Total != 1 || list.Result[0].LocalId != "lid1" {
+			t.Error(code, stdout, stderr)
+		}
+	})
+
+	t.Run("hide", func(t *testing.T) {
+		code, stdout, stderr := exec("hide", "lid1", "lid2")
+		if code != 0 || stdout != "hidden lid1, lid2\n" {
+			t.Error(code, stdout, stderr)
+		}
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		code, _, stderr := exec("show", "unknown")
+		if code != 1 || stderr != "error: device not found (not_found, 404)\n" {
+			t.Error(code, stderr)
+		}
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		if code, _, _ := exec("unknown"); code != 2 {
+			t.Error(code)
+		}
+		if code, _, _ := exec("show"); code != 2 {
+			t.Error(code)
+		}
+		if code, _, _ := exec("list", "-unknown-flag"); code != 2 {
+			t.Error(code)
+		}
+		if code, _, _ := exec("-config", filepath.Join(t.TempDir(), "missing.json"), "list"); code != 1 {
+			t.Error(code)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const outputTable = "table"
+const outputJson = "json"
+
+// output writes results either as aligned table or as indented json
+type output struct {
+	w      io.Writer
+	format string
+}
+
+func (this *output) isJson() bool {
+	return this.format == outputJson
+}
+
+// json writes value as indented json
+func (this *output) json(value any) error {
+	encoder := json.NewEncoder(this.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// jsonLine writes value as json in a single line, e.g. for streams of values
+func (this *output) jsonLine(value any) error {
+	return json.NewEncoder(this.w).Encode(value)
+}
+
+// table writes the rows aligned below the header
+func (this *output) table(header []string, rows [][]string) error {
+	writer := tabwriter.NewWriter(this.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+func (this *output) line(format string, args ...any) {
+	fmt.Fprintf(this.w, format+"\n", args...)
+}
+
+func (this *output) devices(devices []model.Device) error {
+	rows := [][]string{}
+	for _, device := range devices {
+		rows = append(rows, []string{device.LocalId, device.Name, device.DeviceTypeId, strconv.FormatBool(device.Hidden), formatTime(device.LastUpdate)})
+	}
+	return this.table([]string{"LOCAL_ID", "NAME", "DEVICE_TYPE_ID", "HIDDEN", "UPDATED_AT"}, rows)
+}
+
+func (this *output) device(device model.Device) error {
+	err := this.table([]string{"FIELD", "VALUE"}, [][]string{
+		{"local_id", device.LocalId},
+		{"id", device.Id},
+		{"name", device.Name},
+		{"device_type_id", device.DeviceTypeId},
+		{"user_id", device.UserId},
+		{"hidden", strconv.FormatBool(device.Hidden)},
+		{"created_at", formatTime(device.CreatedAt)},
+		{"updated_at", formatTime(device.LastUpdate)},
+		{"overrides", strings.Join(device.Overrides, ", ")},
+	})
+	if err != nil || len(device.Attributes) == 0 {
+		return err
+	}
+	this.line("")
+	rows := [][]string{}
+	for _, attr := range device.Attributes {
+		rows = append(rows, []string{attr.Key, attr.Value, attr.Origin})
+	}
+	return this.table([]string{"ATTRIBUTE", "VALUE", "ORIGIN"}, rows)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.RFC3339)
+}
@@ -18,46 +18,193 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/backup"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/migration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tracing"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
+	"text/tabwriter"
+	"time"
 )
 
 func main() {
-	configLocation := flag.String("config", "config.json", "configuration file")
+	configLocation := flag.String("config", "config.json", "configuration file; reloadable settings are applied on SIGHUP and on changes of the file")
 	migrate := flag.String("migrate", "", "migrate to given implementation and stop program (source and target database implementations must be configured)")
+	migrateBatchSize := flag.Int("migrate-batch-size", migration.DefaultBatchSize, "number of devices copied per batch by -migrate")
+	migrateCheckpoint := flag.String("migrate-checkpoint", "", "file to store the progress of -migrate in; an existing checkpoint is resumed")
+	migrateDryRun := flag.Bool("migrate-dry-run", false, "only read the source of -migrate without writing anything")
+	migrateDeleteSource := flag.Bool("migrate-delete-source", false, "delete all devices from the source after -migrate has been verified")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %v [flags] [command]\n\nCommands:\n"+
+			"  config check                          validate the configuration, print it with redacted credentials and stop program\n"+
+			"  schema status                         show the schema migrations of the configured database and stop program\n"+
+			"  backup <file>                         write a backup archive of all devices to file (- for stdout) and stop program\n"+
+			"  backup verify <file>                  check the integrity of a backup archive and stop program\n"+
+			"  restore [-mode merge|replace] <file>  verify and restore a backup archive and stop program;\n"+
+			"                                        merge keeps devices missing in the archive, replace removes them\n"+
+			"\nFlags:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	args := flag.Args()
 	conf, err := configuration.Load(*configLocation)
+	if strings.Join(args, " ") == "config check" {
+		os.Exit(checkConfig(conf, err))
+	}
 	if err != nil {
-		log.Fatal("ERROR: unable to load config", err)
+		log.Fatalf("ERROR: unable to load config:\n%v", err)
+	}
+
+	switch {
+	case len(args) == 0:
+	case strings.Join(args, " ") == "schema status":
+		err = printSchemaStatus(conf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	case len(args) == 3 && args[0] == "backup" && args[1] == "verify":
+		header, trailer, err := pkg.VerifyBackup(args[2])
+		if err != nil {
+			log.Fatal(err)
+		}
+		history := "without device history and trash"
+		if trailer.History != nil && trailer.Trash != nil {
+			history = fmt.Sprintf("with %v versions and %v trashed devices", trailer.History.Count, trailer.Trash.Count)
+		}
+		fmt.Printf("valid backup of %v devices %v from %v created at %v (checksum %v)\n", trailer.Count, history, header.Source, header.CreatedAt.Format(time.RFC3339), trailer.Checksum)
+		return
+	case len(args) == 2 && args[0] == "backup":
+		trailer, err := pkg.Backup(conf, args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("backed up %v devices, %v versions and %v trashed devices (checksum %v)\n", trailer.Count, trailer.History.Count, trailer.Trash.Count, trailer.Checksum)
+		return
+	case len(args) > 0 && args[0] == "restore":
+		err = restore(conf, args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
 	}
 
 	if migrate != nil && *migrate != "" {
-		err = pkg.Migrate(conf, *migrate)
+		err = pkg.Migrate(conf, *migrate, migration.Options{
+			BatchSize:      *migrateBatchSize,
+			CheckpointFile: *migrateCheckpoint,
+			DryRun:         *migrateDryRun,
+			DeleteSource:   *migrateDeleteSource,
+		})
 		if err != nil {
 			log.Fatal(err)
 		}
 		return
 	}
 
+	err = logging.Setup(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.Info("effective config", "config", conf.Redacted())
+
 	ctx, cancel := context.WithCancel(context.Background())
 	wg := &sync.WaitGroup{}
 
-	err = pkg.Start(ctx, wg, conf)
+	shutdownTracing, err := tracing.Setup(ctx, conf)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("unable to setup tracing", "error", err)
+		os.Exit(1)
+	}
+
+	service, err := pkg.StartService(ctx, wg, conf)
+	if err != nil {
+		slog.Error("unable to start", "error", err)
+		os.Exit(1)
 	}
+	service.WatchConfig(ctx, wg, *configLocation)
 
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL)
 	sig := <-shutdown
-	log.Println("received shutdown signal", sig)
+	slog.Info("received shutdown signal", "signal", sig.String())
 	cancel()
 	wg.Wait() //wait for clean disconnects
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelTimeout()
+	err = shutdownTracing(timeout)
+	if err != nil {
+		slog.Error("unable to flush traces", "error", err)
+	}
+}
+
+// checkConfig prints the effective config with redacted credentials as json to stdout and all problems to stderr
+func checkConfig(conf configuration.Config, loadErr error) (exitCode int) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "    ")
+	err := encoder.Encode(conf.Redacted())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if loadErr != nil {
+		fmt.Fprintf(os.Stderr, "config is invalid:\n%v\n", loadErr)
+		return 1
+	}
+	fmt.Fprintln(os.Stderr, "config is valid")
+	return 0
+}
+
+func restore(conf configuration.Config, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	mode := flags.String("mode", string(backup.ModeMerge), "merge: keep devices, versions and trashed devices missing in the archive; replace: remove devices, versions and trashed devices missing in the archive")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	result, err := pkg.Restore(conf, flags.Arg(0), backup.Mode(*mode))
+	if err != nil {
+		return err
+	}
+	log.Printf("restored %v devices, %v versions and %v trashed devices from backup of %v created at %v, removed %v devices, %v versions and %v trashed devices\n", result.Restored, result.RestoredVersions, result.RestoredTrash, result.Header.Source, result.Header.CreatedAt.Format(time.RFC3339), result.Removed, result.RemovedVersions, result.RemovedTrash)
+	return nil
+}
+
+func printSchemaStatus(conf configuration.Config) error {
+	status, err := persistence.SchemaStatus(conf)
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "%v schema\n", conf.DbImpl)
+	fmt.Fprintln(writer, "VERSION\tDESCRIPTION\tAPPLIED AT")
+	pending := 0
+	for _, migration := range status {
+		appliedAt := "pending"
+		if migration.Applied() {
+			appliedAt = migration.AppliedAt.Format(time.RFC3339)
+		} else {
+			pending++
+		}
+		fmt.Fprintf(writer, "%v\t%v\t%v\n", migration.Version, migration.Description, appliedAt)
+	}
+	fmt.Fprintf(writer, "%v pending migrations, applied on next start\n", pending)
+	return writer.Flush()
 }
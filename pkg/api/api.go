@@ -21,63 +21,115 @@ import (
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/api/util"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/metrics"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
 	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var endpoints = []func(config configuration.Config, control Controller, router *httprouter.Router){}
 
-func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config, control Controller) {
-	log.Println("start api")
-	router := httprouter.New()
-	for _, e := range endpoints {
-		log.Println("add endpoints: " + runtime.FuncForPC(reflect.ValueOf(e).Pointer()).Name())
-		e(config, control, router)
+// Api allows to change the reloadable settings of a started api
+type Api struct {
+	cors *util.CorsMiddleware
+}
+
+// UpdateConfig applies the reloadable settings of config used by the api (cors_allowed_origins)
+func (this *Api) UpdateConfig(config configuration.Config) {
+	this.cors.SetAllowedOrigins(config.CorsAllowedOrigins)
+}
+
+func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config, control Controller, metrics *metrics.Metrics) *Api {
+	slog.Info("start api")
+	shuttingDown := &atomic.Bool{}
+	router := NewRouter(config, control, metrics, shuttingDown)
+	spec, err := OpenApiSpec()
+	if err != nil {
+		slog.Error("invalid openapi document", "error", err)
+		os.Exit(1)
 	}
-	log.Println("add logging and cors")
-	corsHandler := util.NewCors(router)
-	logger := util.NewLogger(corsHandler)
-	log.Println("listen on port", config.ApiPort)
-	server := &http.Server{Addr: ":" + config.ApiPort, Handler: logger, WriteTimeout: 10 * time.Second, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second}
-	wg.Add(1)
+	slog.Debug("add tracing, logging, metrics, cors and validation")
+	validationHandler, err := util.NewValidation(router, spec)
+	if err != nil {
+		slog.Error("unable to create request validation", "error", err)
+		os.Exit(1)
+	}
+	corsHandler := util.NewCors(validationHandler)
+	corsHandler.SetAllowedOrigins(config.CorsAllowedOrigins)
+	metricsHandler := util.NewMetrics(corsHandler, router, metrics)
+	logger := util.NewLogger(metricsHandler)
+	tracingHandler := util.NewTracing(logger, router)
+	server := &http.Server{Addr: ":" + config.ApiPort, Handler: tracingHandler, WriteTimeout: 10 * time.Second, ReadTimeout: 2 * time.Second, ReadHeaderTimeout: 2 * time.Second}
 	go func() {
-		log.Println("Listening on ", server.Addr)
+		slog.Info("listening", "addr", server.Addr)
 		if err := server.ListenAndServe(); err != nil {
 			if err != http.ErrServerClosed {
-				log.Println("ERROR: api server error", err)
-				log.Fatal(err)
+				slog.Error("api server error", "error", err)
+				os.Exit(1)
 			} else {
-				log.Println("closing api server")
+				slog.Info("closing api server")
 			}
-			wg.Done()
 		}
 	}()
 
+	//wg is done after the running requests are drained, not when ListenAndServe returns
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		<-ctx.Done()
-		log.Println("DEBUG: api shutdown", server.Shutdown(context.Background()))
+		//readiness fails during the delay, so that no new requests are routed to this instance before it stops
+		shuttingDown.Store(true)
+		time.Sleep(config.ShutdownDelay.Duration())
+		err := server.Shutdown(context.Background())
+		if err != nil {
+			slog.Error("unable to shutdown api server", "error", err)
+		}
 	}()
+	return &Api{cors: corsHandler}
+}
+
+// NewRouter registers all endpoints; every route has to be described in openapi.yaml
+func NewRouter(config configuration.Config, control Controller, metrics *metrics.Metrics, shuttingDown *atomic.Bool) *httprouter.Router {
+	router := httprouter.New()
+	for _, e := range endpoints {
+		slog.Debug("add endpoints", "endpoints", runtime.FuncForPC(reflect.ValueOf(e).Pointer()).Name())
+		e(config, control, router)
+	}
+	slog.Debug("add metrics and health endpoints")
+	router.Handler(http.MethodGet, "/metrics", metrics.Handler())
+	HealthEndpoints(control, router, shuttingDown)
+	return router
 }
 
 type Controller interface {
-	ListDevices(token auth.Token, options options.List) (result model.DeviceList, err error, errCode int)
-	ReadDevice(token auth.Token, localId string) (result model.Device, err error, errCode int)
-	SetDevice(token auth.Token, device model.Device) (result model.Device, err error, errCode int)
-	UseDevice(token auth.Token, localId string) (err error, errCode int)
-	DeleteDevice(token auth.Token, id string) (err error, errCode int)
-	UseMultipleDevices(token auth.Token, ids []string) (err error, errCode int)
-	DeleteMultipleDevices(token auth.Token, ids []string) (err error, errCode int)
-	HideDevice(token auth.Token, id string) (err error, errCode int)
-	HideMultipleDevices(token auth.Token, ids []string) (err error, errCode int)
-	ShowDevice(token auth.Token, id string) (err error, errCode int)
-	ShowMultipleDevices(token auth.Token, ids []string) (err error, errCode int)
-	HandleWs(conn *websocket.Conn)
+	ListDevices(ctx context.Context, token auth.Token, options options.List) (result model.DeviceList, err error)
+	ExportDevices(ctx context.Context, token auth.Token, options options.List, handler func(device model.Device) error) (err error)
+	ReadDevice(ctx context.Context, token auth.Token, localId string) (result model.Device, err error)
+	SetDevice(ctx context.Context, token auth.Token, device model.Device, overwrite bool) (result model.Device, err error)
+	PatchDevice(ctx context.Context, token auth.Token, localId string, contentType string, patch []byte) (result model.Device, err error)
+	ImportDevice(ctx context.Context, token auth.Token, device model.Device, dryRun bool) (created bool, err error)
+	UseDevice(ctx context.Context, token auth.Token, localId string) (err error)
+	DeleteDevice(ctx context.Context, token auth.Token, id string) (err error)
+	UseMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error)
+	DeleteMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error)
+	HideDevice(ctx context.Context, token auth.Token, id string) (err error)
+	HideMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error)
+	ShowDevice(ctx context.Context, token auth.Token, id string) (err error)
+	ShowMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error)
+	ListDeviceHistory(ctx context.Context, token auth.Token, localId string) (result model.DeviceHistory, err error)
+	RestoreDeviceVersion(ctx context.Context, token auth.Token, localId string, version int64) (result model.Device, err error)
+	ListDeletedDevices(ctx context.Context, token auth.Token, limit int, offset int) (result model.DeviceList, err error)
+	RestoreDevice(ctx context.Context, token auth.Token, localId string) (err error)
+	RestoreMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error)
+	HandleWs(ctx context.Context, conn *websocket.Conn)
+	CheckReadiness(ctx context.Context) model.Health
 }
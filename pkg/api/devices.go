@@ -18,14 +18,21 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api/util"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	options "github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
 	"github.com/julienschmidt/httprouter"
-	"log"
+	"io"
+	"log/slog"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func init() {
@@ -38,54 +45,24 @@ func DevicesEndpoints(config configuration.Config, control Controller, router *h
 	router.GET(resource, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
-		o := options.List{}
-		limitStr := request.URL.Query().Get("limit")
-		if limitStr == "" {
-			limitStr = "100"
-		}
-		o.Limit, err = strconv.Atoi(limitStr)
-		if err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
-			return
-		}
-		offsetStr := request.URL.Query().Get("offset")
-		if offsetStr == "" {
-			offsetStr = "0"
-		}
-		o.Offset, err = strconv.Atoi(offsetStr)
-		if err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
-			return
-		}
-		o.Sort = request.URL.Query().Get("sort")
-		if o.Sort == "" {
-			o.Sort = "local_id"
-		}
-
-		showHiddenStr := request.URL.Query().Get("show_hidden")
-		if showHiddenStr == "" {
-			showHiddenStr = "false"
-		}
-		o.ShowHidden, err = strconv.ParseBool(showHiddenStr)
+		o, err := getListOptions(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
 			return
 		}
 
-		o.Search = request.URL.Query().Get("search")
-
-		result, err, errCode := control.ListDevices(token, o)
+		result, err := control.ListDevices(request.Context(), token, o)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 		err = json.NewEncoder(writer).Encode(result)
 		if err != nil {
-			log.Println("ERROR: unable to encode response", err)
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
 		}
 		return
 	})
@@ -94,12 +71,12 @@ func DevicesEndpoints(config configuration.Config, control Controller, router *h
 		localId := params.ByName("local_id")
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			writer.WriteHeader(http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
-		_, err, errCode := control.ReadDevice(token, localId)
+		_, err = control.ReadDevice(request.Context(), token, localId)
 		if err != nil {
-			writer.WriteHeader(errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -110,18 +87,18 @@ func DevicesEndpoints(config configuration.Config, control Controller, router *h
 		localId := params.ByName("local_id")
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
-		result, err, errCode := control.ReadDevice(token, localId)
+		result, err := control.ReadDevice(request.Context(), token, localId)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 		err = json.NewEncoder(writer).Encode(result)
 		if err != nil {
-			log.Println("ERROR: unable to encode response", err)
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
 		}
 		return
 	})
@@ -130,12 +107,12 @@ func DevicesEndpoints(config configuration.Config, control Controller, router *h
 		localId := params.ByName("local_id")
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
-		err, errCode := control.DeleteDevice(token, localId)
+		err = control.DeleteDevice(request.Context(), token, localId)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -145,18 +122,18 @@ func DevicesEndpoints(config configuration.Config, control Controller, router *h
 	router.DELETE(resource, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
 		ids := []string{}
 		err = json.NewDecoder(request.Body).Decode(&ids)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
 			return
 		}
-		err, errCode := control.DeleteMultipleDevices(token, ids)
+		err = control.DeleteMultipleDevices(request.Context(), token, ids)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -168,27 +145,64 @@ func DevicesEndpoints(config configuration.Config, control Controller, router *h
 		device := model.Device{}
 		err := json.NewDecoder(request.Body).Decode(&device)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
 			return
 		}
 		if device.LocalId != id {
-			http.Error(writer, "expect path local_id == body.local_id", http.StatusBadRequest)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, errors.New("expect path local_id == body.local_id")).WithLocalId(id))
 			return
 		}
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
-		result, err, errCode := control.SetDevice(token, device)
+		overwrite, err := getOverwrite(request)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+			return
+		}
+		result, err := control.SetDevice(request.Context(), token, device, overwrite)
+		if err != nil {
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 		err = json.NewEncoder(writer).Encode(result)
 		if err != nil {
-			log.Println("ERROR: unable to encode response", err)
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
+		}
+		return
+	})
+
+	//applies a json merge patch (application/merge-patch+json or application/json) or json patch (application/json-patch+json);
+	//attributes are patched by key, e.g. {"attributes": {"gateway": {"value": "gw-2"}}}
+	router.PATCH(resource+"/:local_id", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		localId := params.ByName("local_id")
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
+			return
+		}
+		contentType, err := getPatchContentType(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnsupportedMediaType, err))
+			return
+		}
+		patch, err := io.ReadAll(request.Body)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+			return
+		}
+		result, err := control.PatchDevice(request.Context(), token, localId, contentType, patch)
+		if err != nil {
+			util.WriteError(writer, request, err)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
 		}
 		return
 	})
@@ -197,23 +211,28 @@ func DevicesEndpoints(config configuration.Config, control Controller, router *h
 		devices := []model.Device{}
 		err := json.NewDecoder(request.Body).Decode(&devices)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
 			return
 		}
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
+			return
+		}
+		overwrite, err := getOverwrite(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
 			return
 		}
 		result := []model.Device{}
 		for _, device := range devices {
 			if device.LocalId == "" {
-				http.Error(writer, "empty local_id in device", http.StatusBadRequest)
+				util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, errors.New("empty local_id in device")))
 				return
 			}
-			temp, err, errCode := control.SetDevice(token, device)
+			temp, err := control.SetDevice(request.Context(), token, device, overwrite)
 			if err != nil {
-				http.Error(writer, err.Error(), errCode)
+				util.WriteError(writer, request, err)
 				return
 			}
 			result = append(result, temp)
@@ -221,9 +240,126 @@ func DevicesEndpoints(config configuration.Config, control Controller, router *h
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 		err = json.NewEncoder(writer).Encode(result)
 		if err != nil {
-			log.Println("ERROR: unable to encode response", err)
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
 		}
 		return
 	})
 
 }
+
+// getOverwrite reads the overwrite query parameter of upserts, which discards the overrides of the user
+func getOverwrite(request *http.Request) (overwrite bool, err error) {
+	overwriteStr := request.URL.Query().Get("overwrite")
+	if overwriteStr == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(overwriteStr)
+}
+
+// getPatchContentType returns model.MergePatchContentType or model.JsonPatchContentType; plain json is read as merge patch
+func getPatchContentType(request *http.Request) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil {
+		return "", fmt.Errorf("expected Content-Type %v or %v: %w", model.MergePatchContentType, model.JsonPatchContentType, err)
+	}
+	switch mediaType {
+	case model.MergePatchContentType, "application/json":
+		return model.MergePatchContentType, nil
+	case model.JsonPatchContentType:
+		return model.JsonPatchContentType, nil
+	default:
+		return "", fmt.Errorf("unsupported content type %v, expected %v or %v", mediaType, model.MergePatchContentType, model.JsonPatchContentType)
+	}
+}
+
+func getListOptions(request *http.Request) (o options.List, err error) {
+	query := request.URL.Query()
+	limitStr := query.Get("limit")
+	if limitStr == "" {
+		limitStr = "100"
+	}
+	o.Limit, err = strconv.Atoi(limitStr)
+	if err != nil {
+		return o, err
+	}
+	offsetStr := query.Get("offset")
+	if offsetStr == "" {
+		offsetStr = "0"
+	}
+	o.Offset, err = strconv.Atoi(offsetStr)
+	if err != nil {
+		return o, err
+	}
+	o.Sort = query.Get("sort")
+	if o.Sort == "" {
+		o.Sort = "local_id"
+	}
+
+	showHiddenStr := query.Get("show_hidden")
+	if showHiddenStr == "" {
+		showHiddenStr = "false"
+	}
+	o.ShowHidden, err = strconv.ParseBool(showHiddenStr)
+	if err != nil {
+		return o, err
+	}
+
+	o.Search = query.Get("search")
+	_, err = o.SearchQuery()
+	if err != nil {
+		return o, err
+	}
+
+	//attr=<key>=<value> filters devices with an attribute of the given key and value
+	for _, attr := range query["attr"] {
+		key, value, found := strings.Cut(attr, "=")
+		if !found || key == "" {
+			return o, errors.New("expect attr filter in the form <key>=<value>")
+		}
+		o.AttributeFilter = append(o.AttributeFilter, options.AttributeFilter{Key: key, Value: value, Operation: options.AttributeEquals})
+	}
+	//attr_exists=<key> filters devices with an attribute of the given key
+	for _, key := range query["attr_exists"] {
+		if key == "" {
+			return o, errors.New("expect non empty attr_exists filter")
+		}
+		o.AttributeFilter = append(o.AttributeFilter, options.AttributeFilter{Key: key, Operation: options.AttributeExists})
+	}
+
+	for _, deviceTypeIds := range query["device_type_id"] {
+		for _, deviceTypeId := range strings.Split(deviceTypeIds, ",") {
+			if deviceTypeId = strings.TrimSpace(deviceTypeId); deviceTypeId != "" {
+				o.DeviceTypeIds = append(o.DeviceTypeIds, deviceTypeId)
+			}
+		}
+	}
+
+	//facets=device_type_id,hidden,attr.<key> adds device counts grouped by the given fields to the result
+	for _, facets := range query["facets"] {
+		for _, facet := range strings.Split(facets, ",") {
+			err = o.Facets.Add(strings.TrimSpace(facet))
+			if err != nil {
+				return o, err
+			}
+		}
+	}
+
+	timeParams := []struct {
+		name   string
+		target *time.Time
+	}{
+		{name: "created_after", target: &o.CreatedAfter},
+		{name: "created_before", target: &o.CreatedBefore},
+		{name: "updated_after", target: &o.UpdatedAfter},
+		{name: "updated_before", target: &o.UpdatedBefore},
+	}
+	for _, param := range timeParams {
+		if value := query.Get(param.name); value != "" {
+			*param.target, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				return o, fmt.Errorf("invalid %v: %w", param.name, err)
+			}
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api/util"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/julienschmidt/httprouter"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	endpoints = append(endpoints, ExportEndpoints)
+}
+
+const FormatNdjson = "ndjson"
+const FormatCsv = "csv"
+
+const ndjsonContentType = "application/x-ndjson"
+const csvContentType = "text/csv"
+
+// flush the response every exportFlushInterval devices, so that clients receive the export continuously
+const exportFlushInterval = 100
+
+// CsvColumns are the columns of csv exports; attributes are encoded as json list
+var CsvColumns = []string{"local_id", "id", "name", "device_type_id", "attributes", "hidden", "created_at", "updated_at"}
+
+func ExportEndpoints(config configuration.Config, control Controller, router *httprouter.Router) {
+	resource := "/export/devices"
+
+	//accepts the same filter, search and sort parameters as GET /devices; limit and offset are ignored
+	//the format is chosen by the format parameter (ndjson or csv) or the Accept header (application/x-ndjson or text/csv), default is ndjson
+	router.GET(resource, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
+			return
+		}
+		o, err := getListOptions(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+			return
+		}
+		format, err := getExportFormat(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+			return
+		}
+
+		//exports may take longer than the server wide write timeout
+		err = http.NewResponseController(writer).SetWriteDeadline(time.Time{})
+		if err != nil {
+			slog.WarnContext(request.Context(), "unable to remove write deadline for export", "error", err)
+		}
+
+		encoder := newExportEncoder(writer, format)
+		started := false
+		begin := func() error {
+			started = true
+			setExportHeaders(writer, format)
+			return encoder.Begin()
+		}
+		count := 0
+		err = control.ExportDevices(request.Context(), token, o, func(device model.Device) error {
+			if !started {
+				err := begin()
+				if err != nil {
+					return err
+				}
+			}
+			err := encoder.Encode(device)
+			if err != nil {
+				return err
+			}
+			count++
+			if count%exportFlushInterval == 0 {
+				err = encoder.Flush()
+				if err != nil {
+					return err
+				}
+				return http.NewResponseController(writer).Flush()
+			}
+			return nil
+		})
+		if err != nil {
+			if !started {
+				util.WriteError(writer, request, err)
+				return
+			}
+			//the status code has already been sent; aborting the connection lets the client notice the incomplete export
+			slog.ErrorContext(request.Context(), "unable to complete export", "error", err)
+			panic(http.ErrAbortHandler)
+		}
+		if !started {
+			err = begin()
+		}
+		if err == nil {
+			err = encoder.Flush()
+		}
+		if err != nil {
+			slog.ErrorContext(request.Context(), "unable to complete export", "error", err)
+		}
+		return
+	})
+}
+
+type exportEncoder interface {
+	Begin() error
+	Encode(device model.Device) error
+	Flush() error
+}
+
+func newExportEncoder(writer http.ResponseWriter, format string) exportEncoder {
+	switch format {
+	case FormatCsv:
+		return &csvExportEncoder{writer: csv.NewWriter(writer)}
+	default:
+		return &ndjsonExportEncoder{encoder: json.NewEncoder(writer)}
+	}
+}
+
+type ndjsonExportEncoder struct {
+	encoder *json.Encoder
+}
+
+func (this *ndjsonExportEncoder) Begin() error {
+	return nil
+}
+
+func (this *ndjsonExportEncoder) Encode(device model.Device) error {
+	return this.encoder.Encode(device)
+}
+
+func (this *ndjsonExportEncoder) Flush() error {
+	return nil
+}
+
+type csvExportEncoder struct {
+	writer *csv.Writer
+}
+
+func (this *csvExportEncoder) Begin() error {
+	return this.writer.Write(CsvColumns)
+}
+
+func (this *csvExportEncoder) Encode(device model.Device) error {
+	record, err := deviceToCsvRecord(device)
+	if err != nil {
+		return err
+	}
+	return this.writer.Write(record)
+}
+
+func (this *csvExportEncoder) Flush() error {
+	this.writer.Flush()
+	return this.writer.Error()
+}
+
+func getExportFormat(request *http.Request) (string, error) {
+	switch format := request.URL.Query().Get("format"); format {
+	case FormatNdjson, FormatCsv:
+		return format, nil
+	case "":
+	default:
+		return "", errors.New("unknown format " + format + ", expected " + FormatNdjson + " or " + FormatCsv)
+	}
+	for _, accept := range request.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, _, _ := strings.Cut(mediaRange, ";")
+			switch strings.TrimSpace(mediaType) {
+			case csvContentType:
+				return FormatCsv, nil
+			case ndjsonContentType:
+				return FormatNdjson, nil
+			}
+		}
+	}
+	return FormatNdjson, nil
+}
+
+func setExportHeaders(writer http.ResponseWriter, format string) {
+	switch format {
+	case FormatCsv:
+		writer.Header().Set("Content-Type", csvContentType+"; charset=utf-8")
+		writer.Header().Set("Content-Disposition", `attachment; filename="devices.csv"`)
+	default:
+		writer.Header().Set("Content-Type", ndjsonContentType+"; charset=utf-8")
+		writer.Header().Set("Content-Disposition", `attachment; filename="devices.ndjson"`)
+	}
+	writer.WriteHeader(http.StatusOK)
+}
+
+func deviceToCsvRecord(device model.Device) ([]string, error) {
+	attributes := []byte("[]")
+	if len(device.Attributes) > 0 {
+		var err error
+		attributes, err = json.Marshal(device.Attributes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return []string{
+		device.LocalId,
+		device.Id,
+		device.Name,
+		device.DeviceTypeId,
+		string(attributes),
+		strconv.FormatBool(device.Hidden),
+		device.CreatedAt.Format(time.RFC3339Nano),
+		device.LastUpdate.Format(time.RFC3339Nano),
+	}, nil
+}
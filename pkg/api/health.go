@@ -0,0 +1,55 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/julienschmidt/httprouter"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+)
+
+// HealthEndpoints adds /health/live, which succeeds while the api is serving, and /health/ready,
+// which additionally checks the dependencies of the controller and fails as soon as shuttingDown is set
+func HealthEndpoints(control Controller, router *httprouter.Router, shuttingDown *atomic.Bool) {
+	router.GET("/health/live", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		writeHealth(writer, model.Health{Status: model.HealthOk})
+	})
+
+	router.GET("/health/ready", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		result := control.CheckReadiness(request.Context())
+		if shuttingDown.Load() {
+			result.AddCheck("shutdown", errors.New("shutting down"))
+		}
+		writeHealth(writer, result)
+	})
+}
+
+func writeHealth(writer http.ResponseWriter, health model.Health) {
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.Header().Set("Cache-Control", "no-store")
+	if health.Status != model.HealthOk {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(writer).Encode(health)
+	if err != nil {
+		slog.Error("unable to encode response", "error", err)
+	}
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api/util"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 )
@@ -20,12 +22,12 @@ func HideDevicesEndpoints(config configuration.Config, control Controller, route
 		localId := params.ByName("local_id")
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
-		err, errCode := control.HideDevice(token, localId)
+		err = control.HideDevice(request.Context(), token, localId)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -35,18 +37,18 @@ func HideDevicesEndpoints(config configuration.Config, control Controller, route
 	router.PUT(resource, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
 		ids := []string{}
 		err = json.NewDecoder(request.Body).Decode(&ids)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
 			return
 		}
-		err, errCode := control.HideMultipleDevices(token, ids)
+		err = control.HideMultipleDevices(request.Context(), token, ids)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -62,12 +64,12 @@ func ShowDevicesEndpoints(config configuration.Config, control Controller, route
 		localId := params.ByName("local_id")
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
-		err, errCode := control.ShowDevice(token, localId)
+		err = control.ShowDevice(request.Context(), token, localId)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -77,18 +79,18 @@ func ShowDevicesEndpoints(config configuration.Config, control Controller, route
 	router.PUT(resource, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
 		ids := []string{}
 		err = json.NewDecoder(request.Body).Decode(&ids)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
 			return
 		}
-		err, errCode := control.ShowMultipleDevices(token, ids)
+		err = control.ShowMultipleDevices(request.Context(), token, ids)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
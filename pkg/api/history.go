@@ -0,0 +1,83 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api/util"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/julienschmidt/httprouter"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+func init() {
+	endpoints = append(endpoints, HistoryEndpoints)
+}
+
+func HistoryEndpoints(config configuration.Config, control Controller, router *httprouter.Router) {
+	resource := "/devices"
+
+	//lists the versions of the device, newest first, with their changes to the previous version
+	router.GET(resource+"/:local_id/history", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		localId := params.ByName("local_id")
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
+			return
+		}
+		result, err := control.ListDeviceHistory(request.Context(), token, localId)
+		if err != nil {
+			util.WriteError(writer, request, err)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
+		}
+		return
+	})
+
+	router.POST(resource+"/:local_id/history/:version/restore", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		localId := params.ByName("local_id")
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
+			return
+		}
+		version, err := strconv.ParseInt(params.ByName("version"), 10, 64)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err).WithLocalId(localId))
+			return
+		}
+		result, err := control.RestoreDeviceVersion(request.Context(), token, localId, version)
+		if err != nil {
+			util.WriteError(writer, request, err)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
+		}
+		return
+	})
+}
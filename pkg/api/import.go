@@ -0,0 +1,430 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api/util"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/julienschmidt/httprouter"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func init() {
+	endpoints = append(endpoints, ImportEndpoints)
+}
+
+// MaxImportRows limits the number of rows of a single import; larger imports are rejected before any device is stored
+const MaxImportRows = 10000
+
+const importFieldLocalId = "local_id"
+const importFieldName = "name"
+const importFieldDeviceTypeId = "device_type_id"
+const importFieldAttributes = "attributes" //json list of attributes, as written by the csv export
+const importFieldAttributePrefix = "attr." //attr.<key> sets the value of the attribute with the given key
+
+var importFields = []string{importFieldLocalId, importFieldName, importFieldDeviceTypeId, importFieldAttributes}
+
+func ImportEndpoints(config configuration.Config, control Controller, router *httprouter.Router) {
+	resource := "/import/devices"
+
+	//the body is read as csv (with header) or ndjson (one flat json object per line), chosen by the format parameter or the Content-Type header (text/csv or application/x-ndjson)
+	//mapping=<column>=<field> maps a column (csv) or key (ndjson) to local_id, name, device_type_id, attributes or attr.<key>; may be repeated
+	//without mapping, columns named like a field are used and all other columns are ignored
+	//delimiter=<char> sets the csv delimiter (default ',')
+	//dry_run=true validates every row and reports if the device would be created or updated, without storing anything
+	router.POST(resource, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
+			return
+		}
+		query := request.URL.Query()
+		format, err := getImportFormat(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnsupportedMediaType, err))
+			return
+		}
+		mapping, err := getImportMapping(query)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+			return
+		}
+		dryRun := false
+		if dryRunStr := query.Get("dry_run"); dryRunStr != "" {
+			dryRun, err = strconv.ParseBool(dryRunStr)
+			if err != nil {
+				util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+				return
+			}
+		}
+
+		//imports may take longer than the server wide read and write timeouts
+		controller := http.NewResponseController(writer)
+		err = errors.Join(controller.SetReadDeadline(time.Time{}), controller.SetWriteDeadline(time.Time{}))
+		if err != nil {
+			slog.WarnContext(request.Context(), "unable to remove deadlines for import", "error", err)
+		}
+
+		var reader importReader
+		switch format {
+		case FormatCsv:
+			reader, err = newCsvImportReader(request.Body, query.Get("delimiter"), mapping)
+			if err != nil {
+				util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+				return
+			}
+		default:
+			reader = newNdjsonImportReader(request.Body)
+		}
+
+		//all rows are read and validated before the first device is stored, to reject malformed or oversized imports as a whole
+		result := model.ImportResult{DryRun: dryRun, Rows: []model.ImportRowResult{}}
+		devices := map[int]model.Device{}
+		localIds := map[string]int{}
+		for {
+			fields, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			var rowErr *importRowError
+			if err != nil && !errors.As(err, &rowErr) {
+				util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+				return
+			}
+			if len(result.Rows) >= MaxImportRows {
+				util.WriteError(writer, request, model.NewError(model.ErrorCodeTooLarge, fmt.Errorf("import exceeds the maximum of %v rows", MaxImportRows)))
+				return
+			}
+			row := model.ImportRowResult{Row: len(result.Rows) + 1}
+			if err == nil {
+				var device model.Device
+				device, err = mapping.toDevice(fields)
+				row.LocalId = device.LocalId
+				if err == nil {
+					if firstRow, ok := localIds[device.LocalId]; ok {
+						err = fmt.Errorf("duplicate local_id, already used in row %v", firstRow)
+					} else {
+						localIds[device.LocalId] = row.Row
+						devices[row.Row] = device
+					}
+				}
+			}
+			if err != nil {
+				row.Status = model.ImportStatusFailed
+				row.Error = err.Error()
+			}
+			result.Rows = append(result.Rows, row)
+		}
+
+		for i, row := range result.Rows {
+			if device, ok := devices[row.Row]; ok {
+				created, err := control.ImportDevice(request.Context(), token, device, dryRun)
+				switch {
+				case err != nil:
+					result.Rows[i].Status = model.ImportStatusFailed
+					result.Rows[i].Error = err.Error()
+				case created:
+					result.Rows[i].Status = model.ImportStatusCreated
+				default:
+					result.Rows[i].Status = model.ImportStatusUpdated
+				}
+			}
+			switch result.Rows[i].Status {
+			case model.ImportStatusCreated:
+				result.Created++
+			case model.ImportStatusUpdated:
+				result.Updated++
+			default:
+				result.Failed++
+			}
+		}
+		result.Total = len(result.Rows)
+
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
+		}
+		return
+	})
+}
+
+func getImportFormat(request *http.Request) (string, error) {
+	switch format := request.URL.Query().Get("format"); format {
+	case FormatNdjson, FormatCsv:
+		return format, nil
+	case "":
+	default:
+		return "", errors.New("unknown format " + format + ", expected " + FormatNdjson + " or " + FormatCsv)
+	}
+	contentType := request.Header.Get("Content-Type")
+	if contentType == "" {
+		return FormatNdjson, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", err
+	}
+	switch mediaType {
+	case csvContentType:
+		return FormatCsv, nil
+	case ndjsonContentType, "application/json":
+		return FormatNdjson, nil
+	default:
+		return "", errors.New("unsupported content type " + mediaType + ", expected " + csvContentType + " or " + ndjsonContentType)
+	}
+}
+
+// importMapping maps columns to device fields; a nil mapping uses every column named like a field
+type importMapping map[string]string
+
+func getImportMapping(query url.Values) (result importMapping, err error) {
+	for _, entry := range query["mapping"] {
+		//the column name may contain '=', field names do not
+		index := strings.LastIndex(entry, "=")
+		if index < 1 {
+			return nil, errors.New("expect mapping in the form <column>=<field>")
+		}
+		column, field := entry[:index], entry[index+1:]
+		if !isImportField(field) {
+			return nil, fmt.Errorf("unknown import field %v, expected one of %v or %v<key>", field, strings.Join(importFields, ", "), importFieldAttributePrefix)
+		}
+		if result == nil {
+			result = importMapping{}
+		}
+		for otherColumn, otherField := range result {
+			if otherField == field {
+				return nil, fmt.Errorf("field %v is mapped to %v and %v", field, otherColumn, column)
+			}
+		}
+		result[column] = field
+	}
+	return result, nil
+}
+
+func isImportField(field string) bool {
+	return slices.Contains(importFields, field) || (strings.HasPrefix(field, importFieldAttributePrefix) && len(field) > len(importFieldAttributePrefix))
+}
+
+// columns returns the used columns in a stable order: local_id first, so that it is known for rows with invalid values,
+// and attributes before attr.<key>, so that single attributes override the list
+func (this importMapping) columns(fields map[string]string) (result []string) {
+	if this == nil {
+		for column := range fields {
+			if isImportField(column) {
+				result = append(result, column)
+			}
+		}
+	} else {
+		for column := range this {
+			result = append(result, column)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		iIsLocalId, jIsLocalId := this.field(result[i]) == importFieldLocalId, this.field(result[j]) == importFieldLocalId
+		if iIsLocalId != jIsLocalId {
+			return iIsLocalId
+		}
+		iIsList, jIsList := this.field(result[i]) == importFieldAttributes, this.field(result[j]) == importFieldAttributes
+		if iIsList != jIsList {
+			return iIsList
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
+
+func (this importMapping) field(column string) string {
+	if this == nil {
+		return column
+	}
+	return this[column]
+}
+
+func (this importMapping) toDevice(fields map[string]string) (device model.Device, err error) {
+	for _, column := range this.columns(fields) {
+		value := strings.TrimSpace(fields[column])
+		switch field := this.field(column); field {
+		case importFieldLocalId:
+			device.LocalId = value
+		case importFieldName:
+			device.Name = value
+		case importFieldDeviceTypeId:
+			device.DeviceTypeId = value
+		case importFieldAttributes:
+			if value == "" {
+				continue
+			}
+			attributes := []models.Attribute{}
+			err = json.Unmarshal([]byte(value), &attributes)
+			if err != nil {
+				return device, fmt.Errorf("invalid attributes in column %v: %w", column, err)
+			}
+			for _, attribute := range attributes {
+				if attribute.Key == "" {
+					return device, fmt.Errorf("invalid attributes in column %v: empty key", column)
+				}
+				device.Attributes = setAttribute(device.Attributes, attribute)
+			}
+		default:
+			//empty cells do not set an attribute, to allow sparse spreadsheets
+			if value == "" {
+				continue
+			}
+			device.Attributes = setAttribute(device.Attributes, models.Attribute{Key: strings.TrimPrefix(field, importFieldAttributePrefix), Value: value})
+		}
+	}
+	if device.LocalId == "" {
+		return device, errors.New("missing local_id")
+	}
+	return device, nil
+}
+
+func setAttribute(attributes []models.Attribute, attribute models.Attribute) []models.Attribute {
+	for i, existing := range attributes {
+		if existing.Key == attribute.Key {
+			attributes[i] = attribute
+			return attributes
+		}
+	}
+	return append(attributes, attribute)
+}
+
+// importRowError marks errors of a single row, after which reading can continue with the next row
+type importRowError struct {
+	err error
+}
+
+func (this *importRowError) Error() string {
+	return this.err.Error()
+}
+
+func (this *importRowError) Unwrap() error {
+	return this.err
+}
+
+type importReader interface {
+	// Read returns the values of the next row by column, io.EOF after the last row or an *importRowError for invalid rows
+	Read() (fields map[string]string, err error)
+}
+
+type csvImportReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCsvImportReader(body io.Reader, delimiter string, mapping importMapping) (*csvImportReader, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	if delimiter != "" {
+		r, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) {
+			return nil, errors.New("expect single character delimiter")
+		}
+		reader.Comma = r
+	}
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing csv header")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+	//spreadsheet applications may prefix utf-8 files with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+	for column := range mapping {
+		if !slices.Contains(header, column) {
+			return nil, fmt.Errorf("mapped column %v not found in csv header", column)
+		}
+	}
+	return &csvImportReader{reader: reader, header: header}, nil
+}
+
+func (this *csvImportReader) Read() (fields map[string]string, err error) {
+	record, err := this.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &importRowError{err: err}
+		}
+		return nil, err
+	}
+	if len(record) > len(this.header) {
+		return nil, &importRowError{err: fmt.Errorf("row has %v columns, header has %v", len(record), len(this.header))}
+	}
+	fields = map[string]string{}
+	for i, value := range record {
+		fields[this.header[i]] = value
+	}
+	return fields, nil
+}
+
+type ndjsonImportReader struct {
+	reader *bufio.Reader
+}
+
+func newNdjsonImportReader(body io.Reader) *ndjsonImportReader {
+	return &ndjsonImportReader{reader: bufio.NewReader(body)}
+}
+
+func (this *ndjsonImportReader) Read() (fields map[string]string, err error) {
+	var line []byte
+	for len(line) == 0 {
+		line, err = this.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(bytes.TrimSpace(line)) == 0) {
+			return nil, err
+		}
+		line = bytes.TrimSpace(line)
+	}
+	values := map[string]json.RawMessage{}
+	err = json.Unmarshal(line, &values)
+	if err != nil {
+		return nil, &importRowError{err: err}
+	}
+	fields = map[string]string{}
+	for key, value := range values {
+		if string(value) == "null" {
+			continue
+		}
+		//strings are used as they are, other values like the attributes list as json
+		str := ""
+		if json.Unmarshal(value, &str) != nil {
+			str = string(value)
+		}
+		fields[key] = str
+	}
+	return fields, nil
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api/util"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/julienschmidt/httprouter"
+	"log/slog"
+	"net/http"
+)
+
+func init() {
+	endpoints = append(endpoints, OpenApiEndpoints)
+}
+
+//go:embed openapi.yaml
+var openApiYaml []byte
+
+// OpenApiSpec loads and validates the embedded openapi document
+func OpenApiSpec() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openApiYaml)
+	if err != nil {
+		return nil, err
+	}
+	err = doc.Validate(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// OpenApiEndpoints serves the openapi document as /openapi.yaml and /openapi.json
+func OpenApiEndpoints(config configuration.Config, control Controller, router *httprouter.Router) {
+	router.GET("/openapi.yaml", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		writer.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		_, err := writer.Write(openApiYaml)
+		if err != nil {
+			slog.ErrorContext(request.Context(), "unable to write response", "error", err)
+		}
+	})
+
+	router.GET("/openapi.json", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		doc, err := OpenApiSpec()
+		if err != nil {
+			util.WriteError(writer, request, err)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(doc)
+		if err != nil {
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/metrics"
+	"github.com/julienschmidt/httprouter"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync/atomic"
+	"testing"
+)
+
+// TestOpenApiRoutes fails if a registered route is missing in openapi.yaml or if the document describes a route that is not registered
+func TestOpenApiRoutes(t *testing.T) {
+	doc, err := OpenApiSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	documented := map[string]bool{}
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+normalizeRoute(path)] = true
+		}
+	}
+
+	router := NewRouter(configuration.Config{}, nil, metrics.New(), &atomic.Bool{})
+	registered := map[string]bool{}
+	for _, r := range registeredRoutes(router) {
+		registered[normalizeRoute(r)] = true
+	}
+
+	for _, r := range sortedKeys(registered) {
+		if !documented[r] {
+			t.Error("route is not documented in openapi.yaml:", r)
+		}
+	}
+	for _, r := range sortedKeys(documented) {
+		if !registered[r] {
+			t.Error("documented route is not registered:", r)
+		}
+	}
+}
+
+var routeParam = regexp.MustCompile(`:[^/]+|\{[^/]+\}`)
+
+// normalizeRoute replaces path parameters of both notations with {}, because the parameter names are not part of the route
+func normalizeRoute(route string) string {
+	return routeParam.ReplaceAllString(route, "{}")
+}
+
+// registeredRoutes returns "METHOD path" for every handle of the router;
+// httprouter does not expose its routes, so the unexported trees are read by reflection
+func registeredRoutes(router *httprouter.Router) (result []string) {
+	trees := reflect.ValueOf(router).Elem().FieldByName("trees")
+	for _, method := range trees.MapKeys() {
+		walkRouteTree(trees.MapIndex(method), method.String(), "", &result)
+	}
+	return result
+}
+
+func walkRouteTree(node reflect.Value, method string, prefix string, result *[]string) {
+	if node.IsNil() {
+		return
+	}
+	node = node.Elem()
+	path := prefix + node.FieldByName("path").String()
+	if !node.FieldByName("handle").IsNil() {
+		*result = append(*result, method+" "+path)
+	}
+	children := node.FieldByName("children")
+	for i := 0; i < children.Len(); i++ {
+		walkRouteTree(children.Index(i), method, path, result)
+	}
+}
+
+func sortedKeys(m map[string]bool) (result []string) {
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
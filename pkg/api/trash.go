@@ -0,0 +1,98 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api/util"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/julienschmidt/httprouter"
+	"log/slog"
+	"net/http"
+)
+
+func init() {
+	endpoints = append(endpoints, TrashEndpoints)
+}
+
+func TrashEndpoints(config configuration.Config, control Controller, router *httprouter.Router) {
+	//lists the devices in the trash, most recently deleted first; only limit and offset of the list options are used
+	router.GET("/deleted/devices", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
+			return
+		}
+		o, err := getListOptions(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+			return
+		}
+		result, err := control.ListDeletedDevices(request.Context(), token, o.Limit, o.Offset)
+		if err != nil {
+			util.WriteError(writer, request, err)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		err = json.NewEncoder(writer).Encode(result)
+		if err != nil {
+			slog.ErrorContext(request.Context(), "unable to encode response", "error", err)
+		}
+		return
+	})
+
+	resource := "/restored/devices"
+
+	router.POST(resource+"/:local_id", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		localId := params.ByName("local_id")
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
+			return
+		}
+		err = control.RestoreDevice(request.Context(), token, localId)
+		if err != nil {
+			util.WriteError(writer, request, err)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+		return
+	})
+
+	router.POST(resource, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
+			return
+		}
+		ids := []string{}
+		err = json.NewDecoder(request.Body).Decode(&ids)
+		if err != nil {
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
+			return
+		}
+		err = control.RestoreMultipleDevices(request.Context(), token, ids)
+		if err != nil {
+			util.WriteError(writer, request, err)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+		return
+	})
+}
@@ -18,8 +18,10 @@ package api
 
 import (
 	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api/util"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 )
@@ -35,12 +37,12 @@ func UseDevicesEndpoints(config configuration.Config, control Controller, router
 		localId := params.ByName("local_id")
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
-		err, errCode := control.UseDevice(token, localId)
+		err = control.UseDevice(request.Context(), token, localId)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -50,18 +52,18 @@ func UseDevicesEndpoints(config configuration.Config, control Controller, router
 	router.POST(resource, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		token, err := auth.GetParsedToken(request)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusUnauthorized)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeUnauthorized, err))
 			return
 		}
 		ids := []string{}
 		err = json.NewDecoder(request.Body).Decode(&ids)
 		if err != nil {
-			http.Error(writer, err.Error(), http.StatusBadRequest)
+			util.WriteError(writer, request, model.NewError(model.ErrorCodeInvalidRequest, err))
 			return
 		}
-		err, errCode := control.UseMultipleDevices(token, ids)
+		err = control.UseMultipleDevices(request.Context(), token, ids)
 		if err != nil {
-			http.Error(writer, err.Error(), errCode)
+			util.WriteError(writer, request, err)
 			return
 		}
 		writer.WriteHeader(http.StatusOK)
@@ -16,14 +16,32 @@
 
 package util
 
-import "net/http"
+import (
+	"net/http"
+	"slices"
+	"sync/atomic"
+)
 
 func NewCors(handler http.Handler) *CorsMiddleware {
 	return &CorsMiddleware{handler: handler}
 }
 
 type CorsMiddleware struct {
-	handler http.Handler
+	handler        http.Handler
+	allowedOrigins atomic.Pointer[[]string]
+}
+
+// SetAllowedOrigins replaces the origins allowed for cross-origin requests; an empty list or "*" allows every origin
+func (this *CorsMiddleware) SetAllowedOrigins(origins []string) {
+	this.allowedOrigins.Store(&origins)
+}
+
+func (this *CorsMiddleware) isAllowed(origin string) bool {
+	origins := this.allowedOrigins.Load()
+	if origins == nil || len(*origins) == 0 || slices.Contains(*origins, "*") {
+		return true
+	}
+	return slices.Contains(*origins, origin)
 }
 
 func (this *CorsMiddleware) ServeHTTP(res http.ResponseWriter, req *http.Request) {
@@ -31,10 +49,13 @@ func (this *CorsMiddleware) ServeHTTP(res http.ResponseWriter, req *http.Request
 	if origin == "" {
 		origin = "*"
 	}
-	res.Header().Set("Access-Control-Allow-Origin", origin)
-	res.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, authorization, Authorization")
-	res.Header().Set("Access-Control-Allow-Credentials", "true")
-	res.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+	res.Header().Add("Vary", "Origin")
+	if origin == "*" || this.isAllowed(origin) {
+		res.Header().Set("Access-Control-Allow-Origin", origin)
+		res.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, authorization, Authorization")
+		res.Header().Set("Access-Control-Allow-Credentials", "true")
+		res.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
+	}
 
 	if req.Method == "OPTIONS" {
 		res.WriteHeader(http.StatusOK)
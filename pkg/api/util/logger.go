@@ -17,10 +17,16 @@
 package util
 
 import (
-	"log"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
+// NewLogger assigns every request an id, which is taken from the X-Request-ID header or generated,
+// adds it to the request context and the response headers, and logs the request after it has been handled
 func NewLogger(handler http.Handler) *LoggerMiddleWare {
 	return &LoggerMiddleWare{handler: handler}
 }
@@ -30,16 +36,32 @@ type LoggerMiddleWare struct {
 }
 
 func (this *LoggerMiddleWare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	this.log(r)
+	requestId := r.Header.Get(logging.RequestIdHeader)
+	if !logging.ValidRequestId(requestId) {
+		requestId = logging.NewRequestId()
+	}
+	w.Header().Set(logging.RequestIdHeader, requestId)
+	trace.SpanFromContext(r.Context()).SetAttributes(attribute.String(logging.RequestIdKey, requestId))
+	r = r.WithContext(logging.WithRequestId(r.Context(), requestId))
+	start := time.Now()
+	recorder := &statusRecorder{ResponseWriter: w}
 	if this.handler != nil {
-		this.handler.ServeHTTP(w, r)
+		this.handler.ServeHTTP(recorder, r)
 	} else {
-		http.Error(w, "Forbidden", 403)
+		http.Error(recorder, "Forbidden", 403)
 	}
+	this.log(r, recorder.getStatus(), start)
 }
 
-func (this *LoggerMiddleWare) log(request *http.Request) {
-	method := request.Method
-	path := request.URL
-	log.Printf("%v [%v] %v \n", request.RemoteAddr, method, path)
+func (this *LoggerMiddleWare) log(request *http.Request, status int, start time.Time) {
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(request.Context(), level, "request",
+		"method", request.Method,
+		"path", request.URL.Path,
+		"status", status,
+		"duration_ms", float64(time.Since(start).Microseconds())/1000,
+		"remote_addr", request.RemoteAddr)
 }
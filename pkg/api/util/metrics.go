@@ -0,0 +1,121 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"bufio"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/metrics"
+	"github.com/julienschmidt/httprouter"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewMetrics counts requests and their duration by route; router is used to find the route pattern of a request,
+// so that e.g. /devices/foo and /devices/bar are both counted as /devices/:local_id
+func NewMetrics(handler http.Handler, router *httprouter.Router, metrics *metrics.Metrics) *MetricsMiddleware {
+	return &MetricsMiddleware{handler: handler, router: router, metrics: metrics}
+}
+
+type MetricsMiddleware struct {
+	handler http.Handler
+	router  *httprouter.Router
+	metrics *metrics.Metrics
+}
+
+func (this *MetricsMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	recorder := &statusRecorder{ResponseWriter: w}
+	this.handler.ServeHTTP(recorder, r)
+	labels := []string{route(this.router, r), r.Method, strconv.Itoa(recorder.getStatus())}
+	this.metrics.HttpRequests.WithLabelValues(labels...).Inc()
+	if !recorder.hijacked {
+		this.metrics.HttpRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	}
+}
+
+// route replaces the values of path parameters by their names; unknown paths are reported as "unmatched",
+// to limit the number of label values
+func route(router *httprouter.Router, r *http.Request) string {
+	handle, params, _ := router.Lookup(r.Method, r.URL.Path)
+	if handle == nil {
+		return "unmatched"
+	}
+	segments := strings.Split(r.URL.Path, "/")
+	next := 0
+	for i, segment := range segments {
+		if next < len(params) && segment == params[next].Value {
+			segments[i] = ":" + params[next].Key
+			next++
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status   int
+	hijacked bool
+}
+
+func (this *statusRecorder) WriteHeader(status int) {
+	if this.status == 0 {
+		this.status = status
+	}
+	this.ResponseWriter.WriteHeader(status)
+}
+
+func (this *statusRecorder) Write(b []byte) (int, error) {
+	if this.status == 0 {
+		this.status = http.StatusOK
+	}
+	return this.ResponseWriter.Write(b)
+}
+
+func (this *statusRecorder) getStatus() int {
+	switch {
+	case this.hijacked:
+		return http.StatusSwitchingProtocols
+	case this.status == 0:
+		return http.StatusOK
+	default:
+		return this.status
+	}
+}
+
+// Unwrap allows http.NewResponseController to reach the original writer (e.g. to flush or set deadlines)
+func (this *statusRecorder) Unwrap() http.ResponseWriter {
+	return this.ResponseWriter
+}
+
+func (this *statusRecorder) Flush() {
+	if this.status == 0 {
+		this.status = http.StatusOK
+	}
+	http.NewResponseController(this.ResponseWriter).Flush()
+}
+
+// Hijack is used by websocket upgrades, which check the http.Hijacker interface directly
+func (this *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(this.ResponseWriter).Hijack()
+	if err == nil {
+		this.hijacked = true
+	}
+	return conn, rw, err
+}
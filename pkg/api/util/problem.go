@@ -0,0 +1,39 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"log/slog"
+	"net/http"
+)
+
+// WriteError responds with err as application/problem+json; errors that are not *model.Error are internal errors
+func WriteError(writer http.ResponseWriter, request *http.Request, err error) {
+	problem := model.AsError(err).Problem()
+	problem.Instance = request.URL.Path
+	problem.RequestId = logging.RequestId(request.Context())
+	writer.Header().Set("Content-Type", model.ProblemContentType)
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writer.WriteHeader(problem.Status)
+	err = json.NewEncoder(writer).Encode(problem)
+	if err != nil {
+		slog.ErrorContext(request.Context(), "unable to encode problem", "error", err)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tracing"
+	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+)
+
+// NewTracing creates a server span for every request, continuing the trace context of the request headers.
+// Health checks and metrics are not traced.
+func NewTracing(handler http.Handler, router *httprouter.Router) *TracingMiddleware {
+	return &TracingMiddleware{handler: handler, router: router}
+}
+
+type TracingMiddleware struct {
+	handler http.Handler
+	router  *httprouter.Router
+}
+
+func (this *TracingMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/metrics" || strings.HasPrefix(r.URL.Path, "/health/") {
+		this.handler.ServeHTTP(w, r)
+		return
+	}
+	route := route(this.router, r)
+	ctx := tracing.Extract(r.Context(), r.Header)
+	ctx, span := tracing.Start(ctx, r.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		semconv.HTTPRequestMethodKey.String(r.Method),
+		semconv.HTTPRoute(route),
+		semconv.URLPath(r.URL.Path),
+	))
+	defer span.End()
+	recorder := &statusRecorder{ResponseWriter: w}
+	this.handler.ServeHTTP(recorder, r.WithContext(ctx))
+	status := recorder.getStatus()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
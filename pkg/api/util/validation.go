@@ -0,0 +1,81 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"mime"
+	"net/http"
+)
+
+// NewValidation validates requests against the openapi document before they are passed to handler.
+// Requests to paths or methods that are not part of the document are passed on unchanged, so that the handler responds to them.
+// Only json bodies are validated; other content types are checked by the handlers.
+// Bodies without Content-Type are validated as json, if the operation accepts json, because the handlers decode them as json.
+// Authentication is left to the handlers.
+func NewValidation(handler http.Handler, doc *openapi3.T) (*ValidationMiddleware, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &ValidationMiddleware{handler: handler, router: router}, nil
+}
+
+type ValidationMiddleware struct {
+	handler http.Handler
+	router  routers.Router
+}
+
+func (this *ValidationMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, pathParams, err := this.router.FindRoute(r)
+	if err != nil {
+		this.handler.ServeHTTP(w, r)
+		return
+	}
+	if r.Header.Get("Content-Type") == "" && acceptsJson(route.Operation) {
+		r.Header.Set("Content-Type", jsonContentType)
+	}
+	err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			ExcludeRequestBody: !isJson(r),
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	})
+	if err != nil {
+		WriteError(w, r, model.NewError(model.ErrorCodeInvalidRequest, err))
+		return
+	}
+	this.handler.ServeHTTP(w, r)
+}
+
+const jsonContentType = "application/json"
+
+func isJson(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == jsonContentType
+}
+
+func acceptsJson(operation *openapi3.Operation) bool {
+	return operation.RequestBody != nil && operation.RequestBody.Value != nil && operation.RequestBody.Value.Content.Get(jsonContentType) != nil
+}
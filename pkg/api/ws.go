@@ -4,7 +4,7 @@ import (
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
 	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
@@ -24,11 +24,11 @@ func WsEndpoints(config configuration.Config, control Controller, router *httpro
 	router.GET(resource, func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		c, err := upgrader.Upgrade(writer, request, nil)
 		if err != nil {
-			log.Print("ERROR:", err)
+			slog.WarnContext(request.Context(), "unable to upgrade ws connection", "error", err)
 			return
 		}
 		defer c.Close()
-		control.HandleWs(c)
+		control.HandleWs(request.Context(), c)
 	})
 
 }
@@ -84,13 +84,7 @@ func ParseAndValidateToken(token string, pubRsaKey string) (claims Token, err er
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		//decode key base64 string to []byte
-		b, err := base64.StdEncoding.DecodeString(pubRsaKey)
-		if err != nil {
-			return nil, err
-		}
-		//parse []byte key to go struct key (use most common encoding)
-		return x509.ParsePKIXPublicKey(b)
+		return ParsePublicKey(pubRsaKey)
 	})
 	if err == nil {
 		claims.Token = orig
@@ -98,6 +92,20 @@ func ParseAndValidateToken(token string, pubRsaKey string) (claims Token, err er
 	return
 }
 
+// ParsePublicKey parses the base64 encoded key used to validate tokens (without -----BEGIN PUBLIC KEY-----)
+func ParsePublicKey(pubRsaKey string) (interface{}, error) {
+	if pubRsaKey == "" {
+		return nil, errors.New("missing public key")
+	}
+	//decode key base64 string to []byte
+	b, err := base64.StdEncoding.DecodeString(pubRsaKey)
+	if err != nil {
+		return nil, err
+	}
+	//parse []byte key to go struct key (use most common encoding)
+	return x509.ParsePKIXPublicKey(b)
+}
+
 func (this *Token) IsAdmin() bool {
 	return contains(this.RealmAccess["roles"], "admin")
 }
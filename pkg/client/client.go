@@ -0,0 +1,164 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package client is a Go client of the device-waiting-room REST api and its /events websocket.
+// Errors of the api are returned as *model.Error, so that they can be checked with model.IsErrorCode.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TokenProvider returns the token used for a request, with or without "Bearer " prefix.
+// It is called for every request and every websocket (re-)authentication, so that it may refresh expired tokens.
+type TokenProvider func(ctx context.Context) (string, error)
+
+// StaticToken always returns the given token
+func StaticToken(token string) TokenProvider {
+	return func(ctx context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+type Client struct {
+	baseUrl    string
+	token      TokenProvider
+	HttpClient *http.Client //defaults to http.DefaultClient
+}
+
+// New creates a client of the api at baseUrl, e.g. http://localhost:8080
+func New(baseUrl string, token TokenProvider) *Client {
+	return &Client{
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		token:      token,
+		HttpClient: http.DefaultClient,
+	}
+}
+
+// do sends the request and decodes the json response into result, if result is not nil;
+// responses with a status >= 300 are returned as *model.Error
+func (this *Client) do(ctx context.Context, method string, path string, query url.Values, body any, result any) error {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(buf)
+		contentType = "application/json"
+	}
+	resp, err := this.send(ctx, method, path, query, reader, contentType, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if result == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return fmt.Errorf("unable to decode response of %v %v: %w", method, path, err)
+	}
+	return nil
+}
+
+// send sends an authenticated request; the caller has to close the body of the response, which is only returned for status codes < 300
+func (this *Client) send(ctx context.Context, method string, path string, query url.Values, body io.Reader, contentType string, accept string) (*http.Response, error) {
+	endpoint := this.baseUrl + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if this.token != nil {
+		token, err := this.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get token: %w", err)
+		}
+		if !strings.HasPrefix(strings.ToLower(token), "bearer ") {
+			token = "Bearer " + token
+		}
+		req.Header.Set("Authorization", token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := this.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, readError(resp)
+	}
+	return resp, nil
+}
+
+// readError converts a problem+json response with model.Problem.ToError;
+// other error responses, e.g. of a gateway, are converted by their status code
+func readError(resp *http.Response) *model.Error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == model.ProblemContentType {
+		problem := model.Problem{}
+		if err := json.Unmarshal(body, &problem); err == nil && problem.Code != "" {
+			return problem.ToError()
+		}
+	}
+	detail := strings.TrimSpace(string(body))
+	if detail == "" {
+		detail = resp.Status
+	}
+	return &model.Error{Code: statusErrorCode(resp.StatusCode), Status: resp.StatusCode, Detail: detail, Err: errors.New(resp.Status)}
+}
+
+func statusErrorCode(status int) model.ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return model.ErrorCodeInvalidRequest
+	case http.StatusUnauthorized:
+		return model.ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return model.ErrorCodeAccessDenied
+	case http.StatusNotFound:
+		return model.ErrorCodeNotFound
+	case http.StatusConflict:
+		return model.ErrorCodeConflict
+	case http.StatusUnsupportedMediaType:
+		return model.ErrorCodeUnsupportedMediaType
+	case http.StatusRequestEntityTooLarge:
+		return model.ErrorCodeTooLarge
+	default:
+		return model.ErrorCodeInternal
+	}
+}
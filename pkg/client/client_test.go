@@ -0,0 +1,348 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/controller"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/metrics"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/golang-jwt/jwt"
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testApi starts the api with the in-process controller and a sqlite database;
+// tokens have to be signed with the returned key
+func testApi(t *testing.T) (config configuration.Config, key *rsa.PrivateKey) {
+	t.Helper()
+	wg := &sync.WaitGroup{}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.JwtPubRsaKey = base64.StdEncoding.EncodeToString(pubKey)
+	config.DbImpl = configuration.Sqlite
+	config.SqlitePath = filepath.Join(t.TempDir(), "devices.db")
+	config.DeleteAfterUseWaitDuration = 0
+	config.WsPingPeriod = configuration.Duration(200 * time.Millisecond)
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.ApiPort = strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+
+	db, err := persistence.New(ctx, wg, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := metrics.New()
+	api.Start(ctx, wg, config, controller.New(config, db, m), m)
+
+	c := New("http://localhost:"+config.ApiPort, nil)
+	for i := 0; c.Live(ctx) != nil; i++ {
+		if i > 50 {
+			t.Fatal("api not started")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return config, key
+}
+
+func testToken(t *testing.T, key *rsa.PrivateKey, userId string, expiresIn time.Duration) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.StandardClaims{
+		Subject:   userId,
+		ExpiresAt: time.Now().Add(expiresIn).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestClient(t *testing.T) {
+	config, key := testApi(t)
+	ctx := context.Background()
+	c := New("http://localhost:"+config.ApiPort, StaticToken(testToken(t, key, "user1", time.Hour)))
+
+	t.Run("set devices", func(t *testing.T) {
+		device, err := c.SetDevice(ctx, model.Device{Device: models.Device{LocalId: "lid0", Name: "d0", Attributes: []models.Attribute{{Key: "a", Value: "1"}}}}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if device.UserId != "user1" || device.Name != "d0" {
+			t.Errorf("%#v", device)
+		}
+		devices := []model.Device{}
+		for i := 1; i < 5; i++ {
+			devices = append(devices, model.Device{Device: models.Device{LocalId: "lid" + strconv.Itoa(i), Name: "d" + strconv.Itoa(i), Attributes: []models.Attribute{}}})
+		}
+		result, err := c.SetDevices(ctx, devices, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result) != 4 {
+			t.Errorf("%#v", result)
+		}
+	})
+
+	t.Run("read device", func(t *testing.T) {
+		device, err := c.ReadDevice(ctx, "lid0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if device.Name != "d0" || len(device.Attributes) != 1 {
+			t.Errorf("%#v", device)
+		}
+		exists, err := c.DeviceExists(ctx, "lid0")
+		if err != nil || !exists {
+			t.Error(exists, err)
+		}
+		exists, err = c.DeviceExists(ctx, "unknown")
+		if err != nil || exists {
+			t.Error(exists, err)
+		}
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		_, err := c.ReadDevice(ctx, "unknown")
+		if !model.IsErrorCode(err, model.ErrorCodeNotFound) {
+			t.Fatal(err)
+		}
+		if e := model.AsError(err); e.LocalId != "unknown" || e.Status != 404 {
+			t.Errorf("%#v", e)
+		}
+		_, err = New(c.baseUrl, nil).ListDevices(ctx, options.List{})
+		if !model.IsErrorCode(err, model.ErrorCodeUnauthorized) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("list devices", func(t *testing.T) {
+		list, err := c.ListDevices(ctx, options.List{
+			Limit:           10,
+			AttributeFilter: []options.AttributeFilter{{Key: "a", Value: "1", Operation: options.AttributeEquals}},
+			Facets:          options.Facets{AttributeKeys: []string{"a"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.Total != 1 || len(list.Result) != 1 || list.Result[0].LocalId != "lid0" || list.Facets == nil || len(list.Facets.Attributes["a"]) != 1 {
+			t.Errorf("%#v", list)
+		}
+	})
+
+	t.Run("iterate devices", func(t *testing.T) {
+		it := c.IterateDevices(options.List{Limit: 2, Sort: "local_id.desc"})
+		localIds := []string{}
+		for it.Next(ctx) {
+			localIds = append(localIds, it.Device().LocalId)
+		}
+		if it.Err() != nil {
+			t.Fatal(it.Err())
+		}
+		expected := []string{"lid4", "lid3", "lid2", "lid1", "lid0"}
+		if !slices.Equal(localIds, expected) || it.Total() != 5 {
+			t.Error(localIds, it.Total())
+		}
+		devices, err := c.IterateDevices(options.List{Limit: 2, Offset: 3}).All(ctx)
+		if err != nil || len(devices) != 2 {
+			t.Error(len(devices), err)
+		}
+	})
+
+	t.Run("patch device", func(t *testing.T) {
+		device, err := c.PatchDevice(ctx, "lid0", model.MergePatchContentType, []byte(`{"name": "patched"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if device.Name != "patched" {
+			t.Errorf("%#v", device)
+		}
+		_, err = c.PatchDevice(ctx, "lid0", "text/plain", []byte(`{}`))
+		if !model.IsErrorCode(err, model.ErrorCodeUnsupportedMediaType) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("history", func(t *testing.T) {
+		history, err := c.ListDeviceHistory(ctx, "lid0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history.Versions) != 2 || history.Versions[0].Change != model.ChangePatch {
+			t.Fatalf("%#v", history)
+		}
+		device, err := c.RestoreDeviceVersion(ctx, "lid0", history.Versions[1].Version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if device.Name != "d0" {
+			t.Errorf("%#v", device)
+		}
+	})
+
+	t.Run("hide and show", func(t *testing.T) {
+		err := c.HideDevice(ctx, "lid0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.HideDevices(ctx, []string{"lid1", "lid2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := c.ListDevices(ctx, options.List{})
+		if err != nil || list.Total != 2 {
+			t.Error(list.Total, err)
+		}
+		err = c.ShowDevice(ctx, "lid0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.ShowDevices(ctx, []string{"lid1", "lid2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err = c.ListDevices(ctx, options.List{})
+		if err != nil || list.Total != 5 {
+			t.Error(list.Total, err)
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		count := 0
+		err := c.ExportDevices(ctx, options.List{}, func(device model.Device) error {
+			count++
+			return nil
+		})
+		if err != nil || count != 5 {
+			t.Error(count, err)
+		}
+		buf := &bytes.Buffer{}
+		err = c.WriteExport(ctx, options.List{}, FormatCsv, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 6 || !strings.HasPrefix(lines[0], "local_id,") {
+			t.Error(buf.String())
+		}
+	})
+
+	t.Run("import", func(t *testing.T) {
+		result, err := c.ImportDevices(ctx, bytes.NewBufferString("id;title\nlid5;d5\nlid0;d0\n"), ImportOptions{
+			Format:    FormatCsv,
+			Delimiter: ";",
+			Mapping:   map[string]string{"id": "local_id", "title": "name"},
+			DryRun:    true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.DryRun || result.Created != 1 || result.Updated != 1 {
+			t.Errorf("%#v", result)
+		}
+	})
+
+	t.Run("delete and restore", func(t *testing.T) {
+		err := c.DeleteDevice(ctx, "lid0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.DeleteDevices(ctx, []string{"lid1", "lid2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		deleted, err := c.IterateDeletedDevices(2).All(ctx)
+		if err != nil || len(deleted) != 3 {
+			t.Fatal(len(deleted), err)
+		}
+		err = c.RestoreDevice(ctx, "lid0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.RestoreDevices(ctx, []string{"lid1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := c.ListDeletedDevices(ctx, 0, 0)
+		if err != nil || list.Total != 1 || list.Result[0].LocalId != "lid2" {
+			t.Errorf("%#v %v", list, err)
+		}
+		err = c.RestoreDevice(ctx, "lid0")
+		if !model.IsErrorCode(err, model.ErrorCodeNotFound) {
+			t.Error(err)
+		}
+	})
+
+	t.Run("use", func(t *testing.T) {
+		err := c.UseDevice(ctx, "lid0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = c.UseDevices(ctx, []string{"lid1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := c.ListDevices(ctx, options.List{})
+		if err != nil || list.Total != 2 {
+			t.Error(list.Total, err)
+		}
+	})
+
+	t.Run("health", func(t *testing.T) {
+		health, err := c.Ready(ctx)
+		if err != nil || health.Status != model.HealthOk {
+			t.Error(health, err)
+		}
+	})
+}
@@ -0,0 +1,321 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListDevices requests a single page of devices; zero values of o are left to the defaults of the api (limit 100, sort by local_id)
+func (this *Client) ListDevices(ctx context.Context, o options.List) (result model.DeviceList, err error) {
+	err = this.do(ctx, http.MethodGet, "/devices", listQuery(o), nil, &result)
+	return
+}
+
+// IterateDevices pages through all devices matching o, starting at o.Offset with pages of o.Limit devices
+func (this *Client) IterateDevices(o options.List) *DeviceIterator {
+	return newDeviceIterator(o.Limit, o.Offset, func(ctx context.Context, limit int, offset int) (model.DeviceList, error) {
+		page := o
+		page.Limit, page.Offset = limit, offset
+		return this.ListDevices(ctx, page)
+	})
+}
+
+func (this *Client) ReadDevice(ctx context.Context, localId string) (result model.Device, err error) {
+	err = this.do(ctx, http.MethodGet, "/devices/"+url.PathEscape(localId), nil, nil, &result)
+	return
+}
+
+// DeviceExists checks with a HEAD request if the device exists and belongs to the user
+func (this *Client) DeviceExists(ctx context.Context, localId string) (bool, error) {
+	err := this.do(ctx, http.MethodHead, "/devices/"+url.PathEscape(localId), nil, nil, nil)
+	if model.IsErrorCode(err, model.ErrorCodeNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SetDevice creates or updates the device; overrides of the user are kept, unless overwrite is set
+func (this *Client) SetDevice(ctx context.Context, device model.Device, overwrite bool) (result model.Device, err error) {
+	err = this.do(ctx, http.MethodPut, "/devices/"+url.PathEscape(device.LocalId), overwriteQuery(overwrite), device, &result)
+	return
+}
+
+// SetDevices creates or updates multiple devices; the api stops at the first failing device
+func (this *Client) SetDevices(ctx context.Context, devices []model.Device, overwrite bool) (result []model.Device, err error) {
+	err = this.do(ctx, http.MethodPut, "/devices", overwriteQuery(overwrite), devices, &result)
+	return
+}
+
+// PatchDevice applies a patch with the content type model.MergePatchContentType or model.JsonPatchContentType
+func (this *Client) PatchDevice(ctx context.Context, localId string, contentType string, patch []byte) (result model.Device, err error) {
+	resp, err := this.send(ctx, http.MethodPatch, "/devices/"+url.PathEscape(localId), nil, bytes.NewReader(patch), contentType, "application/json")
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
+// DeleteDevice moves the device to the trash
+func (this *Client) DeleteDevice(ctx context.Context, localId string) error {
+	return this.do(ctx, http.MethodDelete, "/devices/"+url.PathEscape(localId), nil, nil, nil)
+}
+
+func (this *Client) DeleteDevices(ctx context.Context, localIds []string) error {
+	return this.do(ctx, http.MethodDelete, "/devices", nil, localIds, nil)
+}
+
+// UseDevice creates the device in the device-manager and removes it from the waiting room
+func (this *Client) UseDevice(ctx context.Context, localId string) error {
+	return this.do(ctx, http.MethodPost, "/used/devices/"+url.PathEscape(localId), nil, nil, nil)
+}
+
+func (this *Client) UseDevices(ctx context.Context, localIds []string) error {
+	return this.do(ctx, http.MethodPost, "/used/devices", nil, localIds, nil)
+}
+
+func (this *Client) HideDevice(ctx context.Context, localId string) error {
+	return this.do(ctx, http.MethodPut, "/hidden/devices/"+url.PathEscape(localId), nil, nil, nil)
+}
+
+func (this *Client) HideDevices(ctx context.Context, localIds []string) error {
+	return this.do(ctx, http.MethodPut, "/hidden/devices", nil, localIds, nil)
+}
+
+func (this *Client) ShowDevice(ctx context.Context, localId string) error {
+	return this.do(ctx, http.MethodPut, "/shown/devices/"+url.PathEscape(localId), nil, nil, nil)
+}
+
+func (this *Client) ShowDevices(ctx context.Context, localIds []string) error {
+	return this.do(ctx, http.MethodPut, "/shown/devices", nil, localIds, nil)
+}
+
+// ListDeviceHistory lists the versions of the device, newest first
+func (this *Client) ListDeviceHistory(ctx context.Context, localId string) (result model.DeviceHistory, err error) {
+	err = this.do(ctx, http.MethodGet, "/devices/"+url.PathEscape(localId)+"/history", nil, nil, &result)
+	return
+}
+
+func (this *Client) RestoreDeviceVersion(ctx context.Context, localId string, version int64) (result model.Device, err error) {
+	err = this.do(ctx, http.MethodPost, "/devices/"+url.PathEscape(localId)+"/history/"+strconv.FormatInt(version, 10)+"/restore", nil, nil, &result)
+	return
+}
+
+// ListDeletedDevices requests a single page of the devices in the trash, most recently deleted first; a limit of 0 uses the default of the api
+func (this *Client) ListDeletedDevices(ctx context.Context, limit int, offset int) (result model.DeviceList, err error) {
+	query := url.Values{}
+	if limit != 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset != 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	err = this.do(ctx, http.MethodGet, "/deleted/devices", query, nil, &result)
+	return
+}
+
+// IterateDeletedDevices pages through the trash with pages of pageSize devices
+func (this *Client) IterateDeletedDevices(pageSize int) *DeviceIterator {
+	return newDeviceIterator(pageSize, 0, this.ListDeletedDevices)
+}
+
+// RestoreDevice moves the device from the trash back to the waiting room
+func (this *Client) RestoreDevice(ctx context.Context, localId string) error {
+	return this.do(ctx, http.MethodPost, "/restored/devices/"+url.PathEscape(localId), nil, nil, nil)
+}
+
+func (this *Client) RestoreDevices(ctx context.Context, localIds []string) error {
+	return this.do(ctx, http.MethodPost, "/restored/devices", nil, localIds, nil)
+}
+
+// ExportDevices streams all devices matching o as ndjson and calls handler for every device; limit and offset are ignored by the api.
+// An export that is aborted by the api returns an error after the devices received so far.
+func (this *Client) ExportDevices(ctx context.Context, o options.List, handler func(device model.Device) error) error {
+	query := listQuery(o)
+	query.Set("format", FormatNdjson)
+	resp, err := this.send(ctx, http.MethodGet, "/export/devices", query, nil, "", ndjsonContentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		device := model.Device{}
+		err = decoder.Decode(&device)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = handler(device)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// WriteExport copies the export of all devices matching o in the given format (FormatNdjson or FormatCsv) to w, e.g. a file
+func (this *Client) WriteExport(ctx context.Context, o options.List, format string, w io.Writer) error {
+	query := listQuery(o)
+	query.Set("format", format)
+	resp, err := this.send(ctx, http.MethodGet, "/export/devices", query, nil, "", "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// export and import formats of the api
+const FormatNdjson = "ndjson"
+const FormatCsv = "csv"
+
+const ndjsonContentType = "application/x-ndjson"
+const csvContentType = "text/csv"
+
+type ImportOptions struct {
+	Format    string            //FormatNdjson (default) or FormatCsv
+	Mapping   map[string]string //column (csv) or key (ndjson) to local_id, name, device_type_id, attributes or attr.<key>
+	Delimiter string            //csv delimiter; defaults to ','
+	DryRun    bool              //validates the rows without storing devices
+}
+
+// ImportDevices sends the csv or ndjson body to the import; failed rows are reported in the result, not as error
+func (this *Client) ImportDevices(ctx context.Context, body io.Reader, o ImportOptions) (result model.ImportResult, err error) {
+	query := url.Values{}
+	contentType := ndjsonContentType
+	if o.Format == FormatCsv {
+		contentType = csvContentType
+	}
+	for column, field := range o.Mapping {
+		query.Add("mapping", column+"="+field)
+	}
+	if o.Delimiter != "" {
+		query.Set("delimiter", o.Delimiter)
+	}
+	if o.DryRun {
+		query.Set("dry_run", "true")
+	}
+	resp, err := this.send(ctx, http.MethodPost, "/import/devices", query, body, contentType, "application/json")
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
+// Live checks the liveness of the api
+func (this *Client) Live(ctx context.Context) error {
+	return this.do(ctx, http.MethodGet, "/health/live", nil, nil, nil)
+}
+
+// Ready returns the readiness checks; an unready api returns the checks together with an error
+func (this *Client) Ready(ctx context.Context) (result model.Health, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, this.baseUrl+"/health/ready", nil)
+	if err != nil {
+		return result, err
+	}
+	resp, err := this.HttpClient.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return result, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return result, &model.Error{Code: model.ErrorCodeInternal, Status: resp.StatusCode, Detail: "not ready"}
+	}
+	return result, nil
+}
+
+// listQuery encodes o as query parameters of GET /devices and GET /export/devices
+func listQuery(o options.List) url.Values {
+	query := url.Values{}
+	if o.Limit != 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset != 0 {
+		query.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if o.Sort != "" {
+		query.Set("sort", o.Sort)
+	}
+	if o.ShowHidden {
+		query.Set("show_hidden", "true")
+	}
+	if o.Search != "" {
+		query.Set("search", o.Search)
+	}
+	for _, filter := range o.AttributeFilter {
+		if filter.Operation == options.AttributeExists {
+			query.Add("attr_exists", filter.Key)
+		} else {
+			query.Add("attr", filter.Key+"="+filter.Value)
+		}
+	}
+	for _, deviceTypeId := range o.DeviceTypeIds {
+		query.Add("device_type_id", deviceTypeId)
+	}
+	if o.Facets.DeviceTypeId {
+		query.Add("facets", "device_type_id")
+	}
+	if o.Facets.Hidden {
+		query.Add("facets", "hidden")
+	}
+	for _, key := range o.Facets.AttributeKeys {
+		query.Add("facets", "attr."+key)
+	}
+	timeParams := []struct {
+		name  string
+		value time.Time
+	}{
+		{name: "created_after", value: o.CreatedAfter},
+		{name: "created_before", value: o.CreatedBefore},
+		{name: "updated_after", value: o.UpdatedAfter},
+		{name: "updated_before", value: o.UpdatedBefore},
+	}
+	for _, param := range timeParams {
+		if !param.value.IsZero() {
+			query.Set(param.name, param.value.Format(time.RFC3339Nano))
+		}
+	}
+	return query
+}
+
+func overwriteQuery(overwrite bool) url.Values {
+	if !overwrite {
+		return nil
+	}
+	return url.Values{"overwrite": {"true"}}
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/gorilla/websocket"
+	"strings"
+	"time"
+)
+
+// EventHandler is called with the type (e.g. model.WsUpdateSetType) and the local id of every event
+type EventHandler func(eventType string, localId string)
+
+// Subscriber receives the events of the /events websocket. It authenticates with the token of the client,
+// answers auth_request messages of the api with a fresh token and reconnects with exponential backoff after connection losses.
+// Events that occur while disconnected are lost; OnConnected may be used to resync.
+type Subscriber struct {
+	client            *Client
+	handler           EventHandler
+	MinReconnectDelay time.Duration   //defaults to 1s
+	MaxReconnectDelay time.Duration   //defaults to 1m
+	ReadTimeout       time.Duration   //connections without messages or pings of the api for this duration are reconnected; defaults to 1m
+	OnConnected       func()          //called after every successful authentication of a new connection
+	OnError           func(err error) //called with connection and auth errors, before reconnecting
+}
+
+// NewSubscriber creates a Subscriber, which calls handler for every event after Run has been called
+func (this *Client) NewSubscriber(handler EventHandler) *Subscriber {
+	return &Subscriber{
+		client:            this,
+		handler:           handler,
+		MinReconnectDelay: time.Second,
+		MaxReconnectDelay: time.Minute,
+		ReadTimeout:       time.Minute,
+	}
+}
+
+// Run connects to the websocket and handles events until ctx is done, which is the only reason for Run to return
+func (this *Subscriber) Run(ctx context.Context) error {
+	delay := this.MinReconnectDelay
+	for {
+		authenticated, err := this.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil && this.OnError != nil {
+			this.OnError(err)
+		}
+		if authenticated {
+			delay = this.MinReconnectDelay
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if !authenticated {
+			delay = min(delay*2, this.MaxReconnectDelay)
+		}
+	}
+}
+
+// session handles a single connection until it fails; authenticated reports if the api has accepted the token
+func (this *Subscriber) session(ctx context.Context) (authenticated bool, err error) {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, this.client.eventsUrl(), nil)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		conn.Close()
+	})
+	defer stop()
+	conn.SetPingHandler(func(data string) error {
+		err := conn.SetReadDeadline(time.Now().Add(this.ReadTimeout))
+		if err != nil {
+			return err
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	err = this.authenticate(ctx, conn)
+	if err != nil {
+		return false, err
+	}
+	for {
+		err = conn.SetReadDeadline(time.Now().Add(this.ReadTimeout))
+		if err != nil {
+			return authenticated, err
+		}
+		msg := model.EventMessage{}
+		err = conn.ReadJSON(&msg)
+		if err != nil {
+			return authenticated, err
+		}
+		switch msg.Type {
+		case model.WsAuthOkType:
+			if !authenticated && this.OnConnected != nil {
+				this.OnConnected()
+			}
+			authenticated = true
+		case model.WsAuthRequestType:
+			err = this.authenticate(ctx, conn)
+			if err != nil {
+				return authenticated, err
+			}
+		case model.WsErrorType:
+			//the api answers rejected tokens with an error message, but keeps the connection without subscription
+			return authenticated, fmt.Errorf("event subscription error: %v", msg.Payload)
+		default:
+			this.handler(msg.Type, msg.Payload)
+		}
+	}
+}
+
+func (this *Subscriber) authenticate(ctx context.Context, conn *websocket.Conn) error {
+	if this.client.token == nil {
+		return errors.New("missing token provider")
+	}
+	token, err := this.client.token(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get token: %w", err)
+	}
+	return conn.WriteJSON(model.EventMessage{Type: model.WsAuthType, Payload: token})
+}
+
+func (this *Client) eventsUrl() string {
+	switch {
+	case strings.HasPrefix(this.baseUrl, "https://"):
+		return "wss://" + strings.TrimPrefix(this.baseUrl, "https://") + "/events"
+	case strings.HasPrefix(this.baseUrl, "http://"):
+		return "ws://" + strings.TrimPrefix(this.baseUrl, "http://") + "/events"
+	default:
+		return this.baseUrl + "/events"
+	}
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubscriber(t *testing.T) {
+	config, key := testApi(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := New("http://localhost:"+config.ApiPort, StaticToken(testToken(t, key, "user1", time.Hour)))
+
+	t.Run("re-auth", func(t *testing.T) {
+		tokenRequests := atomic.Int64{}
+		subscriberClient := New(c.baseUrl, func(ctx context.Context) (string, error) {
+			tokenRequests.Add(1)
+			return testToken(t, key, "user1", 2*time.Second), nil
+		})
+		events := make(chan string, 100)
+		connected := make(chan bool, 10)
+		subscriber := subscriberClient.NewSubscriber(func(eventType string, localId string) {
+			events <- eventType + ":" + localId
+		})
+		subscriber.OnConnected = func() {
+			connected <- true
+		}
+		subCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		done := make(chan error)
+		go func() {
+			done <- subscriber.Run(subCtx)
+		}()
+		waitFor(t, connected)
+
+		setTestDevice(t, c, "lid1")
+		if event := waitFor(t, events); event != model.WsUpdateSetType+":lid1" {
+			t.Error(event)
+		}
+
+		//the token expires, the api drops the next event and requests a new token
+		time.Sleep(3 * time.Second)
+		setTestDevice(t, c, "lid2")
+		if !eventually(func() bool {
+			setTestDevice(t, c, "lid3")
+			select {
+			case event := <-events:
+				return event == model.WsUpdateSetType+":lid3"
+			case <-time.After(200 * time.Millisecond):
+				return false
+			}
+		}) {
+			t.Error("missing event after re-auth")
+		}
+		if tokenRequests.Load() < 2 {
+			t.Error(tokenRequests.Load())
+		}
+
+		stop()
+		if err := waitFor(t, done); err != context.Canceled {
+			t.Error(err)
+		}
+	})
+
+	t.Run("reconnect", func(t *testing.T) {
+		proxy := newTestProxy(t, "localhost:"+config.ApiPort)
+		errs := make(chan error, 10)
+		events := make(chan string, 100)
+		connected := make(chan bool, 10)
+		subscriber := New("http://"+proxy.addr(), c.token).NewSubscriber(func(eventType string, localId string) {
+			events <- eventType + ":" + localId
+		})
+		subscriber.MinReconnectDelay = 50 * time.Millisecond
+		subscriber.OnConnected = func() {
+			connected <- true
+		}
+		subscriber.OnError = func(err error) {
+			errs <- err
+		}
+		subCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		go subscriber.Run(subCtx)
+		waitFor(t, connected)
+
+		proxy.closeConnections()
+		waitFor(t, errs)
+		waitFor(t, connected)
+
+		setTestDevice(t, c, "lid4")
+		if event := waitFor(t, events); event != model.WsUpdateSetType+":lid4" {
+			t.Error(event)
+		}
+	})
+
+	t.Run("rejected token", func(t *testing.T) {
+		errs := make(chan error, 10)
+		subscriber := New(c.baseUrl, StaticToken("invalid")).NewSubscriber(func(eventType string, localId string) {})
+		subscriber.MinReconnectDelay = 50 * time.Millisecond
+		subscriber.OnError = func(err error) {
+			errs <- err
+		}
+		subCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		go subscriber.Run(subCtx)
+		if err := waitFor(t, errs); !strings.Contains(err.Error(), "event subscription error") {
+			t.Error(err)
+		}
+		//the subscriber keeps retrying
+		waitFor(t, errs)
+	})
+}
+
+func setTestDevice(t *testing.T, c *Client, localId string) {
+	t.Helper()
+	_, err := c.SetDevice(context.Background(), model.Device{Device: models.Device{LocalId: localId, Attributes: []models.Attribute{}}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitFor[T any](t *testing.T, c chan T) (result T) {
+	t.Helper()
+	select {
+	case result = <-c:
+		return result
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+		return result
+	}
+}
+
+func eventually(f func() bool) bool {
+	for i := 0; i < 20; i++ {
+		if f() {
+			return true
+		}
+	}
+	return false
+}
+
+// testProxy forwards tcp connections to target and can close them, to simulate connection losses
+type testProxy struct {
+	listener net.Listener
+	mux      sync.Mutex
+	conns    []net.Conn
+}
+
+func newTestProxy(t *testing.T, target string) *testProxy {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := &testProxy{listener: listener}
+	t.Cleanup(func() {
+		listener.Close()
+		proxy.closeConnections()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", target)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			proxy.mux.Lock()
+			proxy.conns = append(proxy.conns, conn, upstream)
+			proxy.mux.Unlock()
+			go io.Copy(conn, upstream)
+			go io.Copy(upstream, conn)
+		}
+	}()
+	return proxy
+}
+
+func (this *testProxy) addr() string {
+	return this.listener.Addr().String()
+}
+
+func (this *testProxy) closeConnections() {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for _, conn := range this.conns {
+		conn.Close()
+	}
+	this.conns = nil
+}
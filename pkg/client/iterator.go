@@ -0,0 +1,103 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+)
+
+const defaultPageSize = 100
+
+// DeviceIterator pages through a device list:
+//
+//	it := c.IterateDevices(options.List{Limit: 500})
+//	for it.Next(ctx) {
+//		device := it.Device()
+//	}
+//	if err := it.Err(); err != nil {...}
+//
+// Pages are requested by offset, so devices that are created, deleted or changed while iterating may be skipped or returned twice.
+type DeviceIterator struct {
+	fetch   func(ctx context.Context, limit int, offset int) (model.DeviceList, error)
+	limit   int
+	offset  int
+	page    []model.Device
+	current model.Device
+	total   int64
+	done    bool
+	err     error
+}
+
+func newDeviceIterator(limit int, offset int, fetch func(ctx context.Context, limit int, offset int) (model.DeviceList, error)) *DeviceIterator {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	return &DeviceIterator{fetch: fetch, limit: limit, offset: offset}
+}
+
+// Next advances to the next device, requesting the next page if necessary; it returns false at the end of the list or on errors
+func (this *DeviceIterator) Next(ctx context.Context) bool {
+	if this.err != nil {
+		return false
+	}
+	if len(this.page) == 0 {
+		if this.done {
+			return false
+		}
+		list, err := this.fetch(ctx, this.limit, this.offset)
+		if err != nil {
+			this.err = err
+			return false
+		}
+		this.total = list.Total
+		this.page = list.Result
+		this.offset += len(list.Result)
+		if len(list.Result) < this.limit || int64(this.offset) >= list.Total {
+			this.done = true
+		}
+		if len(this.page) == 0 {
+			return false
+		}
+	}
+	this.current, this.page = this.page[0], this.page[1:]
+	return true
+}
+
+// Device returns the current device
+func (this *DeviceIterator) Device() model.Device {
+	return this.current
+}
+
+// Total returns the total count of the list, as reported by the last requested page
+func (this *DeviceIterator) Total() int64 {
+	return this.total
+}
+
+// Err returns the error that stopped the iteration, if any
+func (this *DeviceIterator) Err() error {
+	return this.err
+}
+
+// All collects the remaining devices
+func (this *DeviceIterator) All(ctx context.Context) ([]model.Device, error) {
+	result := []model.Device{}
+	for this.Next(ctx) {
+		result = append(result, this.Device())
+	}
+	return result, this.Err()
+}
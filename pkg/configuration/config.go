@@ -1,7 +1,9 @@
 package configuration
 
 import (
+	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -9,45 +11,119 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	ApiPort string `json:"api_port"`
-	DbImpl  DbImpl `json:"db_impl"`
+	ApiPort  string `json:"api_port"`
+	GrpcPort string `json:"grpc_port"` //empty disables the grpc api
+	DbImpl   DbImpl `json:"db_impl"`
 
-	MongoUrl              string `json:"mongo_url"`
-	MongoTable            string `json:"mongo_table"`
-	MongoDeviceCollection string `json:"mongo_device_collection"`
+	MongoUrl               string `json:"mongo_url" config:"credentials"`
+	MongoTable             string `json:"mongo_table"`
+	MongoDeviceCollection  string `json:"mongo_device_collection"`
+	MongoHistoryCollection string `json:"mongo_history_collection"` //defaults to mongo_device_collection + "_history"
+	MongoTrashCollection   string `json:"mongo_trash_collection"`   //defaults to mongo_device_collection + "_trash"
 
-	PostgresConnStr string `json:"postgres_conn_str"`
+	PostgresConnStr string `json:"postgres_conn_str" config:"credentials"`
 
-	Debug                      bool   `json:"debug"`
-	DeviceManagerUrl           string `json:"device_manager_url"`
-	DeleteAfterUseWaitDuration string `json:"delete_after_use_wait_duration"`
-	JwtPubRsaKey               string `json:"jwt_pub_rsa_key"` //without -----BEGIN PUBLIC KEY-----
-	WsPingPeriod               string `json:"ws_ping_period"`
+	SqlitePath string `json:"sqlite_path"`
+
+	Debug                      bool     `json:"debug" config:"reloadable"`     //sets the log level to debug
+	LogLevel                   string   `json:"log_level" config:"reloadable"` //debug, info, warn or error
+	DeviceManagerUrl           string   `json:"device_manager_url" config:"credentials,reloadable"`
+	DeleteAfterUseWaitDuration Duration `json:"delete_after_use_wait_duration" config:"reloadable"` //empty, "-" or 0 keeps used devices
+	JwtPubRsaKey               string   `json:"jwt_pub_rsa_key" config:"reloadable"`                //without -----BEGIN PUBLIC KEY-----
+	WsPingPeriod               Duration `json:"ws_ping_period" config:"reloadable"`
+	HistoryLimit               int64    `json:"history_limit" config:"reloadable"` //versions kept per device; 0 disables the history
+	TrashRetention             Duration `json:"trash_retention"`                   //duration, after which deleted devices are purged; empty, "-" or 0 keeps them
+	TrashPurgeInterval         Duration `json:"trash_purge_interval"`              //interval of the background purge of the trash
+
+	HealthCheckDeviceManager bool     `json:"health_check_device_manager" config:"reloadable"` //readiness fails if the device-manager is unreachable
+	ShutdownDelay            Duration `json:"shutdown_delay"`                                  //time between failing readiness and stopping the api on shutdown
+
+	CorsAllowedOrigins  []string `json:"cors_allowed_origins" config:"reloadable"` //e.g. https://example.com; empty allows every origin
+	ConfigWatchInterval Duration `json:"config_watch_interval"`                    //interval to check the config file for changes; empty, "-" or 0 only reloads on SIGHUP
+
+	TracingExporter     string `json:"tracing_exporter"`                           //none, stdout, file or otlp
+	TracingFile         string `json:"tracing_file"`                               //used by tracing_exporter file
+	TracingOtlpEndpoint string `json:"tracing_otlp_endpoint" config:"credentials"` //url used by tracing_exporter otlp, e.g. http://localhost:4318/v1/traces
 }
 
 type DbImpl = string
 
 const Mongo DbImpl = "mongo"
 const Postgres DbImpl = "postgres"
+const Sqlite DbImpl = "sqlite"
+
+// Default returns the values used for settings missing in the config json
+func Default() Config {
+	return Config{
+		ApiPort:                    "8080",
+		DbImpl:                     Mongo,
+		MongoTable:                 "devicerepository",
+		MongoDeviceCollection:      "device",
+		SqlitePath:                 "devices.db",
+		LogLevel:                   "info",
+		DeleteAfterUseWaitDuration: Duration(10 * time.Second),
+		WsPingPeriod:               Duration(10 * time.Second),
+		HistoryLimit:               20,
+		TrashRetention:             Duration(30 * 24 * time.Hour),
+		TrashPurgeInterval:         Duration(time.Hour),
+		TracingExporter:            "none",
+		ConfigWatchInterval:        Duration(5 * time.Second),
+	}
+}
 
 // loads config from json in location and used environment variables (e.g ZookeeperUrl --> ZOOKEEPER_URL)
+// settings missing in the json keep their Default value.
+// Invalid environment variables and the result of Validate are returned as one joined error;
+// in that case the returned config is still filled, so that it can be inspected.
 func Load(location string) (config Config, err error) {
+	config = Default()
 	file, error := os.Open(location)
 	if error != nil {
 		log.Println("error on config load: ", error)
 		return config, error
 	}
-	decoder := json.NewDecoder(file)
-	error = decoder.Decode(&config)
+	defer file.Close()
+	values := map[string]json.RawMessage{}
+	error = json.NewDecoder(file).Decode(&values)
 	if error != nil {
 		log.Println("invalid config json: ", error)
 		return config, error
 	}
-	handleEnvironmentVars(&config)
-	return config, nil
+	return config, errors.Join(decodeValues(values, &config), handleEnvironmentVars(&config), config.Validate())
+}
+
+// decodeValues sets each field separately, to report all invalid values at once
+func decodeValues(values map[string]json.RawMessage, config *Config) (err error) {
+	configValue := reflect.Indirect(reflect.ValueOf(config))
+	configType := configValue.Type()
+	for index := 0; index < configType.NumField(); index++ {
+		key := strings.Split(configType.Field(index).Tag.Get("json"), ",")[0]
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		delete(values, key)
+		decodeErr := json.Unmarshal(value, configValue.Field(index).Addr().Interface())
+		if decodeErr != nil {
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(decodeErr, &typeErr) {
+				expected := configValue.Field(index).Type().String()
+				if configValue.Field(index).Type() == reflect.TypeOf(Duration(0)) {
+					expected = "duration string, e.g. \"10s\""
+				}
+				decodeErr = fmt.Errorf("expected %v, got %v", expected, typeErr.Value)
+			}
+			err = errors.Join(err, fmt.Errorf("invalid %v: %w", key, decodeErr))
+		}
+	}
+	for key := range values {
+		log.Println("WARNING: unknown config field: ", key)
+	}
+	return err
 }
 
 var camel = regexp.MustCompile("(^[^A-Z]*|[A-Z]*)([A-Z][^A-Z]+|$)")
@@ -66,28 +142,49 @@ func fieldNameToEnvName(s string) string {
 }
 
 // preparations for docker
-func handleEnvironmentVars(config *Config) {
+func handleEnvironmentVars(config *Config) (err error) {
 	configValue := reflect.Indirect(reflect.ValueOf(config))
 	configType := configValue.Type()
 	for index := 0; index < configType.NumField(); index++ {
-		fieldName := configType.Field(index).Name
+		field := configType.Field(index)
+		fieldName := field.Name
 		envName := fieldNameToEnvName(fieldName)
 		envValue := os.Getenv(envName)
 		if envValue != "" {
-			fmt.Println("use environment variable: ", envName, " = ", envValue)
+			printValue := envValue
+			if hasOption(field, "credentials") {
+				printValue = redactCredentials(envValue)
+			}
+			log.Println("use environment variable: ", envName, " = ", printValue) //stderr, stdout is used by config check
+			if unmarshaler, ok := configValue.FieldByName(fieldName).Addr().Interface().(encoding.TextUnmarshaler); ok {
+				parseErr := unmarshaler.UnmarshalText([]byte(envValue))
+				if parseErr != nil {
+					err = errors.Join(err, fmt.Errorf("%v: %w", envName, parseErr))
+				}
+				continue
+			}
 			if configValue.FieldByName(fieldName).Kind() == reflect.Int64 {
-				i, _ := strconv.ParseInt(envValue, 10, 64)
+				i, parseErr := strconv.ParseInt(envValue, 10, 64)
+				if parseErr != nil {
+					err = errors.Join(err, fmt.Errorf("%v: invalid integer %q", envName, envValue))
+				}
 				configValue.FieldByName(fieldName).SetInt(i)
 			}
 			if configValue.FieldByName(fieldName).Kind() == reflect.String {
 				configValue.FieldByName(fieldName).SetString(envValue)
 			}
 			if configValue.FieldByName(fieldName).Kind() == reflect.Bool {
-				b, _ := strconv.ParseBool(envValue)
+				b, parseErr := strconv.ParseBool(envValue)
+				if parseErr != nil {
+					err = errors.Join(err, fmt.Errorf("%v: invalid boolean %q", envName, envValue))
+				}
 				configValue.FieldByName(fieldName).SetBool(b)
 			}
 			if configValue.FieldByName(fieldName).Kind() == reflect.Float64 {
-				f, _ := strconv.ParseFloat(envValue, 64)
+				f, parseErr := strconv.ParseFloat(envValue, 64)
+				if parseErr != nil {
+					err = errors.Join(err, fmt.Errorf("%v: invalid number %q", envName, envValue))
+				}
 				configValue.FieldByName(fieldName).SetFloat(f)
 			}
 			if configValue.FieldByName(fieldName).Kind() == reflect.Slice {
@@ -109,4 +206,5 @@ func handleEnvironmentVars(config *Config) {
 			}
 		}
 	}
+	return err
 }
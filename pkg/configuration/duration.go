@@ -0,0 +1,53 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configuration
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration, which is written as string in json and environment variables, e.g. "10s".
+// An empty string or "-" is read as 0.
+type Duration time.Duration
+
+func (this Duration) Duration() time.Duration {
+	return time.Duration(this)
+}
+
+func (this Duration) String() string {
+	return time.Duration(this).String()
+}
+
+func (this Duration) MarshalText() ([]byte, error) {
+	return []byte(this.String()), nil
+}
+
+func (this *Duration) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	if value == "" || value == "-" {
+		*this = 0
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q, expected e.g. 10s, 5m or 1h30m", value)
+	}
+	*this = Duration(d)
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package configuration
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Changes returns the json names of all settings, which differ between current and next,
+// split into settings, which can be applied to the running service (tagged with config:"reloadable"),
+// and settings, which only take effect after a restart
+func Changes(current Config, next Config) (reloadable []string, restartRequired []string) {
+	currentValue := reflect.ValueOf(current)
+	nextValue := reflect.ValueOf(next)
+	configType := currentValue.Type()
+	for index := 0; index < configType.NumField(); index++ {
+		field := configType.Field(index)
+		if reflect.DeepEqual(currentValue.Field(index).Interface(), nextValue.Field(index).Interface()) {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if hasOption(field, "reloadable") {
+			reloadable = append(reloadable, name)
+		} else {
+			restartRequired = append(restartRequired, name)
+		}
+	}
+	return reloadable, restartRequired
+}
+
+// Reload returns current with the reloadable settings taken from next;
+// all other settings keep the values the service has been started with
+func Reload(current Config, next Config) Config {
+	result := reflect.ValueOf(&current).Elem()
+	nextValue := reflect.ValueOf(next)
+	for index := 0; index < result.NumField(); index++ {
+		if hasOption(result.Type().Field(index), "reloadable") {
+			result.Field(index).Set(nextValue.Field(index))
+		}
+	}
+	return current
+}
+
+// hasOption checks the comma separated options of the config tag of field, e.g. `config:"credentials,reloadable"`
+func hasOption(field reflect.StructField, option string) bool {
+	return slices.Contains(strings.Split(field.Tag.Get("config"), ","), option)
+}
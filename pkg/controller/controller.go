@@ -7,250 +7,380 @@ import (
 	"errors"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/metrics"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tracing"
 	"github.com/SENERGY-Platform/models/go/models"
 	"github.com/gorilla/websocket"
-	"log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"net/http"
-	"runtime/debug"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Controller struct {
-	config        configuration.Config
+	config        atomic.Pointer[configuration.Config]
+	configChanged atomic.Pointer[chan struct{}] //closed and replaced by UpdateConfig
 	db            Persistence
+	metrics       *metrics.Metrics
 	subscriptions []Subscription
 	subMux        sync.Mutex
 }
 
-func New(config configuration.Config, db Persistence) *Controller {
-	return &Controller{
-		config: config,
-		db:     db,
+func New(config configuration.Config, db Persistence, metrics *metrics.Metrics) *Controller {
+	result := &Controller{
+		db:      db,
+		metrics: metrics,
 	}
+	result.config.Store(&config)
+	changed := make(chan struct{})
+	result.configChanged.Store(&changed)
+	return result
+}
+
+// Config returns the current config of the controller
+func (this *Controller) Config() configuration.Config {
+	return *this.config.Load()
+}
+
+// UpdateConfig replaces the config used by subsequent calls; open websocket connections switch to a changed ws_ping_period immediately
+func (this *Controller) UpdateConfig(config configuration.Config) {
+	this.config.Store(&config)
+	changed := make(chan struct{})
+	close(*this.configChanged.Swap(&changed))
+}
+
+// configUpdate returns a channel, which is closed on the next UpdateConfig
+func (this *Controller) configUpdate() <-chan struct{} {
+	return *this.configChanged.Load()
 }
 
 type Persistence = persistence.Persistence
 
-func (this *Controller) ListDevices(token auth.Token, options options.List) (result model.DeviceList, err error, errCode int) {
+func (this *Controller) ListDevices(ctx context.Context, token auth.Token, options options.List) (result model.DeviceList, err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId())
+	ctx, span := tracing.Start(ctx, "Controller.ListDevices")
+	defer end(span, &err)
 	result.Limit, result.Offset, result.Sort, result.Search = options.Limit, options.Offset, options.Sort, options.Search
-	result.Result, result.Total, err, errCode = this.db.ListDevices(token.GetUserId(), options)
-	return
+	var errCode int
+	result.Result, result.Total, err, errCode = this.db.ListDevices(ctx, token.GetUserId(), options)
+	if err != nil {
+		return result, persistenceError(err, errCode, "")
+	}
+	if options.Facets.IsEmpty() {
+		return result, nil
+	}
+	facets, err, errCode := this.db.CountFacets(ctx, token.GetUserId(), options)
+	if err != nil {
+		return result, persistenceError(err, errCode, "")
+	}
+	result.Facets = &facets
+	return result, nil
 }
 
-func (this *Controller) ReadDevice(token auth.Token, localId string) (result model.Device, err error, errCode int) {
-	result, err, errCode = this.db.ReadDevice(localId)
+// ExportDevices calls handler for every device of the user matching the options; limit and offset are ignored
+func (this *Controller) ExportDevices(ctx context.Context, token auth.Token, options options.List, handler func(device model.Device) error) (err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId())
+	ctx, span := tracing.Start(ctx, "Controller.ExportDevices")
+	defer end(span, &err)
+	err, errCode := this.db.ExportDevices(ctx, token.GetUserId(), options, handler)
+	return persistenceError(err, errCode, "")
+}
+
+func (this *Controller) ReadDevice(ctx context.Context, token auth.Token, localId string) (result model.Device, err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, localId)
+	ctx, span := tracing.Start(ctx, "Controller.ReadDevice", trace.WithAttributes(attribute.String(logging.LocalIdKey, localId)))
+	defer end(span, &err)
+	return this.readOwnDevice(ctx, token, localId)
+}
+
+// readOwnDevice reads the device and fails with ErrorCodeAccessDenied if it belongs to another user
+func (this *Controller) readOwnDevice(ctx context.Context, token auth.Token, localId string) (result model.Device, err error) {
+	result, err, errCode := this.db.ReadDevice(ctx, localId)
 	if err != nil {
-		return model.Device{}, err, errCode
+		return model.Device{}, persistenceError(err, errCode, localId)
 	}
 	if result.UserId != token.GetUserId() {
-		return model.Device{}, errors.New("access denied"), http.StatusForbidden
+		return model.Device{}, accessDenied(localId)
 	}
-	return result, nil, http.StatusOK
+	return result, nil
 }
 
-func (this *Controller) SetDevice(token auth.Token, device model.Device) (result model.Device, err error, errCode int) {
-	var old model.Device
-	old, err, errCode = this.db.ReadDevice(device.LocalId)
-	if err != nil && errCode != http.StatusNotFound {
-		return model.Device{}, err, errCode
-	}
+// SetDevice upserts the device as reported by a gateway. Fields overridden by the user (see model.Device.Overrides)
+// keep their values, unless overwrite is set, which discards all overrides.
+func (this *Controller) SetDevice(ctx context.Context, token auth.Token, device model.Device, overwrite bool) (result model.Device, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.SetDevice", trace.WithAttributes(attribute.String(logging.LocalIdKey, device.LocalId), attribute.Bool("overwrite", overwrite)))
+	defer end(span, &err)
+	result, _, err = this.setDevice(ctx, token, device, overwrite, false)
+	return result, err
+}
+
+// ImportDevice upserts the device like SetDevice (without overwrite) and reports if the device has been created.
+// With dryRun, the device is only checked and not stored.
+func (this *Controller) ImportDevice(ctx context.Context, token auth.Token, device model.Device, dryRun bool) (created bool, err error) {
+	ctx, span := tracing.Start(ctx, "Controller.ImportDevice", trace.WithAttributes(attribute.String(logging.LocalIdKey, device.LocalId)))
+	defer end(span, &err)
+	_, created, err = this.setDevice(ctx, token, device, false, dryRun)
+	return created, err
+}
+
+func (this *Controller) setDevice(ctx context.Context, token auth.Token, device model.Device, overwrite bool, dryRun bool) (result model.Device, created bool, err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, device.LocalId)
 	device.UserId = token.GetUserId()
-	if errCode == http.StatusNotFound {
-		device.LastUpdate = time.Now()
-		device.CreatedAt = device.LastUpdate
-		device.Hidden = false
-	} else {
-		if old.UserId != device.UserId {
-			return model.Device{}, errors.New("access denied"), http.StatusNotFound //use same error as normal 404 to prevent search of valid ids
+	for attempt := 1; ; attempt++ {
+		var old model.Device
+		var errCode int
+		old, err, errCode = this.db.ReadDevice(ctx, device.LocalId)
+		if err != nil && errCode != http.StatusNotFound {
+			return model.Device{}, false, persistenceError(err, errCode, device.LocalId)
 		}
-		device.LastUpdate = time.Now()
-		device.CreatedAt = old.CreatedAt
-	}
-	err, errCode = this.db.SetDevice(device)
-	if err == nil {
+		created = errCode == http.StatusNotFound
+		result = device
+		result.LastUpdate = time.Now()
+		result.DeletedAt = nil
+		if created {
+			result.CreatedAt = result.LastUpdate
+			result.Hidden = false
+			result.Reported, result.Overrides = nil, nil
+		} else {
+			if old.UserId != device.UserId {
+				return model.Device{}, false, notFound(device.LocalId, nil) //use same error as normal 404 to prevent search of valid ids
+			}
+			result.CreatedAt = old.CreatedAt
+			result = keepOverrides(old, result, overwrite)
+		}
+		if dryRun {
+			return result, created, nil
+		}
+		if created {
+			err, errCode = this.db.SetDevice(ctx, result)
+		} else {
+			//the merge with the overrides depends on the stored device, which may not change in the meantime
+			err, errCode = this.db.SetDeviceIfUnchanged(ctx, result, old.LastUpdate)
+			if errCode == http.StatusConflict && attempt < maxUpdateAttempts {
+				continue
+			}
+		}
+		if err != nil {
+			return result, created, persistenceError(err, errCode, device.LocalId)
+		}
+		this.recordVersion(ctx, model.ChangeSet, result)
 		this.Trigger(token.GetUserId(), model.EventUpdateSetType, device.LocalId)
+		return result, created, nil
 	}
-	return device, err, errCode
 }
 
-func (this *Controller) UseDevice(token auth.Token, localId string) (err error, errCode int) {
-	var device model.Device
-	device, err, errCode = this.db.ReadDevice(localId)
+func (this *Controller) UseDevice(ctx context.Context, token auth.Token, localId string) (err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, localId)
+	ctx, span := tracing.Start(ctx, "Controller.UseDevice", trace.WithAttributes(attribute.String(logging.LocalIdKey, localId)))
+	defer end(span, &err)
+	device, err := this.readOwnDevice(ctx, token, localId)
 	if err != nil {
-		return err, errCode
-	}
-	if device.UserId != token.GetUserId() {
-		return errors.New("access denied"), http.StatusForbidden
+		return err
 	}
-	err, errCode = this.CreateInDeviceManager(token.Token, device.Device)
+	err = this.CreateInDeviceManager(ctx, token.Token, device.Device)
 	if err != nil {
-		return err, errCode
+		return err
 	}
 
+	//the delayed delete outlives the request, but keeps its log attributes
+	deleteCtx := context.WithoutCancel(ctx)
 	go func() {
-		if this.config.DeleteAfterUseWaitDuration == "" || this.config.DeleteAfterUseWaitDuration == "-" {
-			return
-		}
-		d, err := time.ParseDuration(this.config.DeleteAfterUseWaitDuration)
-		if err != nil {
-			log.Println("WARNING: unable to parse DeleteAfterUseWaitDuration;", err)
-			return
-		}
-		if d == 0 {
+		d := this.Config().DeleteAfterUseWaitDuration.Duration()
+		if d <= 0 {
 			return
 		}
 		time.Sleep(d)
-		err, code := this.db.RemoveDevice(localId)
+		err, code := this.db.RemoveDevice(deleteCtx, localId)
 		if err != nil {
-			log.Println("ERROR:", code, err)
-			debug.PrintStack()
+			slog.ErrorContext(deleteCtx, "unable to remove used device after delay", "error", err, "status", code)
 		}
 	}()
-	err, errCode = this.db.RemoveDevice(localId)
-	if err == nil {
-		this.Trigger(token.GetUserId(), model.EventUpdateUseType, device.LocalId)
+	err, errCode := this.db.RemoveDevice(ctx, localId)
+	if err != nil {
+		return persistenceError(err, errCode, localId)
 	}
-	return err, errCode
+	this.recordVersion(ctx, model.ChangeUse, device)
+	this.Trigger(token.GetUserId(), model.EventUpdateUseType, device.LocalId)
+	return nil
 }
 
-func (this *Controller) UseMultipleDevices(token auth.Token, ids []string) (err error, errCode int) {
+func (this *Controller) UseMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.UseMultipleDevices")
+	defer end(span, &err)
 	for _, id := range ids {
-		err, errCode = this.UseDevice(token, id)
+		err = this.UseDevice(ctx, token, id)
 		if err != nil {
-			return err, errCode
+			return err
 		}
 	}
-	return nil, http.StatusOK
+	return nil
 }
 
-func (this *Controller) DeleteDevice(token auth.Token, localId string) (err error, errCode int) {
-	var device model.Device
-	device, err, errCode = this.db.ReadDevice(localId)
+// DeleteDevice moves the device to the trash, from which it can be restored until it is purged (see StartTrashPurge)
+func (this *Controller) DeleteDevice(ctx context.Context, token auth.Token, localId string) (err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, localId)
+	ctx, span := tracing.Start(ctx, "Controller.DeleteDevice", trace.WithAttributes(attribute.String(logging.LocalIdKey, localId)))
+	defer end(span, &err)
+	device, err := this.readOwnDevice(ctx, token, localId)
 	if err != nil {
-		return err, errCode
-	}
-	if device.UserId != token.GetUserId() {
-		return errors.New("access denied"), http.StatusForbidden
+		return err
 	}
-	err, errCode = this.db.RemoveDevice(localId)
-	if err == nil {
-		this.Trigger(token.GetUserId(), model.EventUpdateDeleteType, device.LocalId)
+	err, errCode := this.db.TrashDevice(ctx, localId, time.Now())
+	if err != nil {
+		return persistenceError(err, errCode, localId)
 	}
-	return err, errCode
+	this.Trigger(token.GetUserId(), model.EventUpdateDeleteType, device.LocalId)
+	return nil
 }
 
-func (this *Controller) DeleteMultipleDevices(token auth.Token, ids []string) (err error, errCode int) {
+func (this *Controller) DeleteMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.DeleteMultipleDevices")
+	defer end(span, &err)
 	for _, id := range ids {
-		err, errCode = this.DeleteDevice(token, id)
+		err = this.DeleteDevice(ctx, token, id)
 		if err != nil {
-			return err, errCode
+			return err
 		}
 	}
-	return nil, http.StatusOK
+	return nil
 }
 
-func (this *Controller) CreateInDeviceManager(token string, device models.Device) (err error, errCode int) {
+// CreateInDeviceManager creates the device with the token of the user;
+// rejections keep the client error status of the device-manager, server errors are reported as bad gateway
+func (this *Controller) CreateInDeviceManager(ctx context.Context, token string, device models.Device) (err error) {
+	ctx, span := tracing.Start(ctx, "device-manager POST /devices", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String(logging.LocalIdKey, device.LocalId)))
+	defer end(span, &err)
 	b := new(bytes.Buffer)
 	err = json.NewEncoder(b).Encode(device)
 	if err != nil {
-		debug.PrintStack()
-		return err, http.StatusInternalServerError
+		slog.ErrorContext(ctx, "unable to encode device for device-manager", "error", err)
+		return model.NewError(model.ErrorCodeInternal, err).WithLocalId(device.LocalId)
 	}
-	req, err := http.NewRequest("POST", this.config.DeviceManagerUrl+"/devices", b)
+	req, err := http.NewRequestWithContext(ctx, "POST", this.Config().DeviceManagerUrl+"/devices", b)
 	if err != nil {
-		debug.PrintStack()
-		return err, http.StatusInternalServerError
+		slog.ErrorContext(ctx, "unable to create device-manager request", "error", err)
+		return model.NewError(model.ErrorCodeInternal, err).WithLocalId(device.LocalId)
 	}
 	req.Header.Set("Authorization", token)
+	if requestId := logging.RequestId(ctx); requestId != "" {
+		req.Header.Set(logging.RequestIdHeader, requestId)
+	}
+	tracing.Inject(ctx, req.Header)
+	start := time.Now()
 	resp, err := http.DefaultClient.Do(req)
+	this.metrics.DeviceManagerDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
-		debug.PrintStack()
-		return err, http.StatusInternalServerError
+		this.metrics.DeviceManagerRequests.WithLabelValues(metrics.DeviceManagerUnreachable).Inc()
+		slog.ErrorContext(ctx, "unable to reach device-manager", "error", err)
+		return model.NewError(model.ErrorCodeDeviceManagerUnavailable, err).WithLocalId(device.LocalId)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
+		this.metrics.DeviceManagerRequests.WithLabelValues(metrics.DeviceManagerRejected).Inc()
 		buf := new(bytes.Buffer)
 		buf.ReadFrom(resp.Body)
-		err = errors.New(buf.String())
-		debug.PrintStack()
-		return err, resp.StatusCode
+		err = errors.New(strings.TrimSpace(buf.String()))
+		slog.WarnContext(ctx, "device-manager rejected device", "status", resp.StatusCode, "error", err)
+		result := model.NewError(model.ErrorCodeDeviceManagerRejected, err).WithLocalId(device.LocalId)
+		result.UpstreamStatus = resp.StatusCode
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			result.Status = resp.StatusCode
+		}
+		return result
 	}
-	return nil, resp.StatusCode
+	this.metrics.DeviceManagerRequests.WithLabelValues(metrics.DeviceManagerSuccess).Inc()
+	slog.DebugContext(ctx, "device created in device-manager", "status", resp.StatusCode, "duration_ms", float64(time.Since(start).Microseconds())/1000)
+	return nil
 }
 
-func (this *Controller) HideDevice(token auth.Token, localId string) (err error, errCode int) {
-	var device model.Device
-	device, err, errCode = this.db.ReadDevice(localId)
-	if err != nil {
-		return err, errCode
-	}
-	if device.UserId != token.GetUserId() {
-		return errors.New("access denied"), http.StatusForbidden
-	}
-	device.Hidden = true
-	err, errCode = this.db.SetDevice(device)
-	if err == nil {
-		this.Trigger(token.GetUserId(), model.EventUpdateSetType, device.LocalId)
-	}
-	return err, errCode
+func (this *Controller) HideDevice(ctx context.Context, token auth.Token, localId string) (err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, localId)
+	ctx, span := tracing.Start(ctx, "Controller.HideDevice", trace.WithAttributes(attribute.String(logging.LocalIdKey, localId)))
+	defer end(span, &err)
+	return this.setHidden(ctx, token, localId, true)
 }
 
-func (this *Controller) HideMultipleDevices(token auth.Token, ids []string) (err error, errCode int) {
+func (this *Controller) HideMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.HideMultipleDevices")
+	defer end(span, &err)
 	for _, id := range ids {
-		err, errCode = this.HideDevice(token, id)
+		err = this.HideDevice(ctx, token, id)
 		if err != nil {
-			return err, errCode
+			return err
 		}
 	}
-	return nil, http.StatusOK
+	return nil
 }
 
-func (this *Controller) ShowDevice(token auth.Token, localId string) (err error, errCode int) {
-	var device model.Device
-	device, err, errCode = this.db.ReadDevice(localId)
-	if err != nil {
-		return err, errCode
-	}
-	if device.UserId != token.GetUserId() {
-		return errors.New("access denied"), http.StatusForbidden
-	}
-	device.Hidden = false
-	err, errCode = this.db.SetDevice(device)
-	if err == nil {
-		this.Trigger(token.GetUserId(), model.EventUpdateSetType, device.LocalId)
-	}
-	return err, errCode
+func (this *Controller) ShowDevice(ctx context.Context, token auth.Token, localId string) (err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, localId)
+	ctx, span := tracing.Start(ctx, "Controller.ShowDevice", trace.WithAttributes(attribute.String(logging.LocalIdKey, localId)))
+	defer end(span, &err)
+	return this.setHidden(ctx, token, localId, false)
 }
 
-func (this *Controller) ShowMultipleDevices(token auth.Token, ids []string) (err error, errCode int) {
+func (this *Controller) ShowMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.ShowMultipleDevices")
+	defer end(span, &err)
 	for _, id := range ids {
-		err, errCode = this.ShowDevice(token, id)
+		err = this.ShowDevice(ctx, token, id)
 		if err != nil {
-			return err, errCode
+			return err
 		}
 	}
-	return nil, http.StatusOK
+	return nil
 }
 
-func (this *Controller) startPing(ctx context.Context, conn *websocket.Conn) (err error) {
-	pingPeriod, err := time.ParseDuration(this.config.WsPingPeriod)
+func (this *Controller) setHidden(ctx context.Context, token auth.Token, localId string, hidden bool) error {
+	device, err := this.readOwnDevice(ctx, token, localId)
 	if err != nil {
 		return err
 	}
+	device.Hidden = hidden
+	err, errCode := this.db.SetDevice(ctx, device)
+	if err != nil {
+		return persistenceError(err, errCode, localId)
+	}
+	change := model.ChangeShow
+	if hidden {
+		change = model.ChangeHide
+	}
+	this.recordVersion(ctx, change, device)
+	this.Trigger(token.GetUserId(), model.EventUpdateSetType, device.LocalId)
+	return nil
+}
+
+func (this *Controller) startPing(ctx context.Context, conn *websocket.Conn) (err error) {
+	configUpdate := this.configUpdate()
+	pingPeriod := this.Config().WsPingPeriod.Duration()
+	if pingPeriod <= 0 {
+		return errors.New("invalid ws_ping_period, must be positive")
+	}
 	go func() {
 		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
+			case <-configUpdate:
+				configUpdate = this.configUpdate()
+				if period := this.Config().WsPingPeriod.Duration(); period > 0 && period != pingPeriod {
+					pingPeriod = period
+					ticker.Reset(pingPeriod)
+				}
 			case <-ticker.C:
 				err := conn.WriteMessage(websocket.PingMessage, nil)
 				if err != nil {
-					log.Println("ERROR: sending ws ping:", err)
+					slog.WarnContext(ctx, "unable to send ws ping", "error", err)
 					return
 				}
 			}
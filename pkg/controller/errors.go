@@ -0,0 +1,67 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// persistenceError converts the (error, status code) result of the persistence to *model.Error; nil stays nil
+func persistenceError(err error, errCode int, localId string) error {
+	if err == nil {
+		return nil
+	}
+	switch errCode {
+	case http.StatusNotFound:
+		return notFound(localId, err)
+	case http.StatusConflict:
+		return model.NewError(model.ErrorCodeConflict, err).WithLocalId(localId)
+	case http.StatusBadRequest:
+		return model.NewError(model.ErrorCodeInvalidRequest, err).WithLocalId(localId)
+	default:
+		return model.NewError(model.ErrorCodeInternal, err).WithLocalId(localId)
+	}
+}
+
+// notFound hides the cause from the detail, because backends report missing devices differently
+func notFound(localId string, cause error) *model.Error {
+	return &model.Error{
+		Code:    model.ErrorCodeNotFound,
+		Status:  http.StatusNotFound,
+		Detail:  "device " + localId + " not found",
+		LocalId: localId,
+		Err:     cause,
+	}
+}
+
+func accessDenied(localId string) *model.Error {
+	return &model.Error{
+		Code:    model.ErrorCodeAccessDenied,
+		Status:  http.StatusForbidden,
+		Detail:  "access denied",
+		LocalId: localId,
+	}
+}
+
+// end ends the span with the status code of err
+func end(span trace.Span, err *error) {
+	status := model.ErrorStatus(*err)
+	tracing.End(span, err, &status)
+}
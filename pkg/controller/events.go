@@ -1,9 +1,12 @@
 package controller
 
+import "github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+
 type Subscription struct {
-	SubId  string
-	UserId string
-	F      func(eventType string, id string)
+	SubId     string
+	UserId    string
+	Transport string
+	F         func(eventType string, id string)
 }
 
 func (this *Controller) Trigger(userid string, eventType string, id string) {
@@ -30,14 +33,28 @@ func (this *Controller) Unsubscribe(subId string) {
 		}
 	}
 	this.subscriptions = newList
+	this.updateSubscriptionMetrics()
 }
 
-func (this *Controller) Subscribe(subId string, userId string, f func(eventType string, id string)) {
+// Subscribe calls f for every event of the user; transport is model.TransportWs or model.TransportGrpc
+func (this *Controller) Subscribe(transport string, subId string, userId string, f func(eventType string, id string)) {
 	this.subMux.Lock()
 	defer this.subMux.Unlock()
 	this.subscriptions = append(this.subscriptions, Subscription{
-		SubId:  subId,
-		UserId: userId,
-		F:      f,
+		SubId:     subId,
+		UserId:    userId,
+		Transport: transport,
+		F:         f,
 	})
+	this.updateSubscriptionMetrics()
+}
+
+// updateSubscriptionMetrics has to be called with subMux locked
+func (this *Controller) updateSubscriptionMetrics() {
+	count := map[string]int{}
+	for _, sub := range this.subscriptions {
+		count[sub.Transport]++
+	}
+	this.metrics.WsSubscriptions.Set(float64(count[model.TransportWs]))
+	this.metrics.GrpcWatchStreams.Set(float64(count[model.TransportGrpc]))
 }
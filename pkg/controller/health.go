@@ -0,0 +1,61 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"net/http"
+	"time"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// CheckReadiness checks the database connection, the jwt key if one is configured and, if config.HealthCheckDeviceManager is set,
+// the reachability of the device-manager
+func (this *Controller) CheckReadiness(ctx context.Context) (result model.Health) {
+	result.AddCheck("database", this.db.Ping(ctx))
+	if this.Config().JwtPubRsaKey != "" {
+		_, err := auth.ParsePublicKey(this.Config().JwtPubRsaKey)
+		if err != nil {
+			err = fmt.Errorf("invalid jwt_pub_rsa_key: %w", err)
+		}
+		result.AddCheck("jwt_key", err)
+	}
+	if this.Config().HealthCheckDeviceManager {
+		result.AddCheck("device_manager", this.pingDeviceManager(ctx))
+	}
+	return result
+}
+
+// pingDeviceManager only checks that the device-manager responds; every status code is accepted
+func (this *Controller) pingDeviceManager(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, this.Config().DeviceManagerUrl, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
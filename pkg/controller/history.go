@@ -0,0 +1,169 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tracing"
+	"github.com/SENERGY-Platform/models/go/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// recordVersion adds the device to its history (see configuration.Config.HistoryLimit).
+// Failures are only logged, because the change itself has already been stored.
+func (this *Controller) recordVersion(ctx context.Context, change string, device model.Device) {
+	limit := this.Config().HistoryLimit
+	if limit <= 0 {
+		return
+	}
+	version := model.DeviceVersion{LocalId: device.LocalId, Change: change, Time: time.Now(), Device: device}
+	for attempt := 1; ; attempt++ {
+		_, err, errCode := this.db.AddDeviceVersion(ctx, version, limit)
+		if errCode == http.StatusConflict && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "unable to record device version", "error", err, "status", errCode, "change", change)
+		}
+		return
+	}
+}
+
+// ListDeviceHistory returns the known versions of the device, newest first, each with its changes to the previous version
+func (this *Controller) ListDeviceHistory(ctx context.Context, token auth.Token, localId string) (result model.DeviceHistory, err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, localId)
+	ctx, span := tracing.Start(ctx, "Controller.ListDeviceHistory", trace.WithAttributes(attribute.String(logging.LocalIdKey, localId)))
+	defer end(span, &err)
+	versions, err, errCode := this.db.ListDeviceVersions(ctx, token.GetUserId(), localId)
+	if err != nil {
+		return result, persistenceError(err, errCode, localId)
+	}
+	if len(versions) == 0 {
+		return result, notFound(localId, nil)
+	}
+	for i := range versions {
+		switch {
+		case i+1 < len(versions) && versions[i+1].Version == versions[i].Version-1:
+			versions[i].Diff = diffDevices(versions[i+1].Device, versions[i].Device)
+		case versions[i].Version == 1:
+			versions[i].Diff = diffDevices(model.Device{}, versions[i].Device)
+		default:
+			versions[i].Diff = nil //the previous version has been removed
+		}
+	}
+	return model.DeviceHistory{LocalId: localId, Versions: versions}, nil
+}
+
+// RestoreDeviceVersion sets the device to the state of a version of its history, which is recorded as new version.
+// Devices, which have been used or purged from the trash since, are stored again.
+// Fails with a conflict while the device is in the trash, from where it has to be restored first.
+func (this *Controller) RestoreDeviceVersion(ctx context.Context, token auth.Token, localId string, version int64) (result model.Device, err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, localId)
+	ctx, span := tracing.Start(ctx, "Controller.RestoreDeviceVersion", trace.WithAttributes(attribute.String(logging.LocalIdKey, localId), attribute.Int64("version", version)))
+	defer end(span, &err)
+	restored, err, errCode := this.db.ReadDeviceVersion(ctx, token.GetUserId(), localId, version)
+	if err != nil {
+		if errCode == http.StatusNotFound {
+			return result, model.NewError(model.ErrorCodeNotFound, err).WithLocalId(localId)
+		}
+		return result, persistenceError(err, errCode, localId)
+	}
+	for attempt := 1; ; attempt++ {
+		var current model.Device
+		current, err, errCode = this.db.ReadDevice(ctx, localId)
+		if err != nil && errCode != http.StatusNotFound {
+			return result, persistenceError(err, errCode, localId)
+		}
+		exists := errCode != http.StatusNotFound
+		if exists && current.UserId != token.GetUserId() {
+			return result, accessDenied(localId)
+		}
+		result = restored.Device
+		result.UserId = token.GetUserId()
+		result.LastUpdate = time.Now()
+		if exists {
+			result.CreatedAt = current.CreatedAt
+			err, errCode = this.db.SetDeviceIfUnchanged(ctx, result, current.LastUpdate)
+			if errCode == http.StatusConflict && attempt < maxUpdateAttempts {
+				continue
+			}
+		} else {
+			_, err, errCode = this.db.ReadTrashedDevice(ctx, localId)
+			if err == nil {
+				return model.Device{}, model.NewError(model.ErrorCodeConflict, errors.New("device is in the trash, restore it from the trash first")).WithLocalId(localId)
+			}
+			if errCode != http.StatusNotFound {
+				return model.Device{}, persistenceError(err, errCode, localId)
+			}
+			result.CreatedAt = result.LastUpdate
+			err, errCode = this.db.SetDevice(ctx, result)
+		}
+		if err != nil {
+			return model.Device{}, persistenceError(err, errCode, localId)
+		}
+		this.recordVersion(ctx, model.ChangeRestore, result)
+		this.Trigger(token.GetUserId(), model.EventUpdateSetType, localId)
+		return result, nil
+	}
+}
+
+// diffDevices lists the changed id, name, device_type_id, hidden and attributes (by key, sorted)
+func diffDevices(old model.Device, current model.Device) (result []model.FieldChange) {
+	result = []model.FieldChange{}
+	if old.Id != current.Id {
+		result = append(result, model.FieldChange{Field: "id", Old: old.Id, New: current.Id})
+	}
+	if old.Name != current.Name {
+		result = append(result, model.FieldChange{Field: model.OverrideName, Old: old.Name, New: current.Name})
+	}
+	if old.DeviceTypeId != current.DeviceTypeId {
+		result = append(result, model.FieldChange{Field: model.OverrideDeviceTypeId, Old: old.DeviceTypeId, New: current.DeviceTypeId})
+	}
+	if old.Hidden != current.Hidden {
+		result = append(result, model.FieldChange{Field: "hidden", Old: old.Hidden, New: current.Hidden})
+	}
+	keys := []string{}
+	for _, attr := range append(slices.Clone(old.Attributes), current.Attributes...) {
+		keys = append(keys, attr.Key)
+	}
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		if !attributeChanged(old.Attributes, current.Attributes, key) {
+			continue
+		}
+		result = append(result, model.FieldChange{Field: model.OverrideAttributePrefix + key, Old: attributeValue(old.Attributes, key), New: attributeValue(current.Attributes, key)})
+	}
+	return result
+}
+
+// attributeValue returns the attribute without its key, or nil if it does not exist
+func attributeValue(attributes []models.Attribute, key string) any {
+	attr, ok := findAttribute(attributes, key)
+	if !ok {
+		return nil
+	}
+	return map[string]string{"value": attr.Value, "origin": attr.Origin}
+}
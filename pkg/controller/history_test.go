@@ -0,0 +1,53 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"reflect"
+	"testing"
+)
+
+func TestDiffDevices(t *testing.T) {
+	old := model.Device{
+		Device: models.Device{
+			LocalId:      "lid",
+			Name:         "foo",
+			DeviceTypeId: "dt",
+			Attributes:   []models.Attribute{{Key: "b", Value: "1"}, {Key: "a", Value: "2", Origin: "gw"}},
+		},
+	}
+	current := old
+	current.Name = "bar"
+	current.Hidden = true
+	current.Attributes = []models.Attribute{{Key: "c", Value: "3"}, {Key: "a", Value: "2", Origin: "user"}}
+
+	expected := []model.FieldChange{
+		{Field: "name", Old: "foo", New: "bar"},
+		{Field: "hidden", Old: false, New: true},
+		{Field: "attr.a", Old: map[string]string{"value": "2", "origin": "gw"}, New: map[string]string{"value": "2", "origin": "user"}},
+		{Field: "attr.b", Old: map[string]string{"value": "1", "origin": ""}, New: nil},
+		{Field: "attr.c", Old: nil, New: map[string]string{"value": "3", "origin": ""}},
+	}
+	if actual := diffDevices(old, current); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("\n%#v\n%#v", actual, expected)
+	}
+	if actual := diffDevices(old, old); actual == nil || len(actual) != 0 {
+		t.Errorf("expect empty diff, got %#v", actual)
+	}
+}
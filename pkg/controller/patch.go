@@ -0,0 +1,165 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tracing"
+	"github.com/SENERGY-Platform/models/go/models"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// concurrent changes of a patched or upserted device are retried up to maxUpdateAttempts times before the update fails with a conflict
+const maxUpdateAttempts = 10
+
+// PatchDevice applies a json merge patch (model.MergePatchContentType) or json patch (model.JsonPatchContentType)
+// to the device as represented by patchDocument. The patch is applied to the current state of the device and stored only
+// if the device has not been changed in the meantime; otherwise it is applied again to the new state.
+// Changed names, device types and attributes are recorded as overrides, which are kept on later upserts.
+func (this *Controller) PatchDevice(ctx context.Context, token auth.Token, localId string, contentType string, patch []byte) (result model.Device, err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, localId)
+	ctx, span := tracing.Start(ctx, "Controller.PatchDevice", trace.WithAttributes(attribute.String(logging.LocalIdKey, localId)))
+	defer end(span, &err)
+	for attempt := 1; ; attempt++ {
+		var old model.Device
+		old, err = this.readOwnDevice(ctx, token, localId)
+		if err != nil {
+			return model.Device{}, err
+		}
+		result, err = applyPatch(old, contentType, patch)
+		if err != nil {
+			return model.Device{}, err
+		}
+		result = trackOverrides(old, result)
+		result.LastUpdate = time.Now()
+		var errCode int
+		err, errCode = this.db.SetDeviceIfUnchanged(ctx, result, old.LastUpdate)
+		if errCode == http.StatusConflict && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return model.Device{}, persistenceError(err, errCode, localId)
+		}
+		this.recordVersion(ctx, model.ChangePatch, result)
+		this.Trigger(token.GetUserId(), model.EventUpdateSetType, localId)
+		return result, nil
+	}
+}
+
+// patchDocument is the representation of a device that patches are applied to.
+// Attributes are keyed by their key, so that patches add, replace or remove single attributes,
+// e.g. {"attributes": {"gateway": {"value": "gw-2"}, "old": null}} as merge patch
+// or [{"op": "remove", "path": "/attributes/old"}] as json patch.
+type patchDocument struct {
+	Id           string                    `json:"id"`
+	LocalId      string                    `json:"local_id"`
+	Name         string                    `json:"name"`
+	DeviceTypeId string                    `json:"device_type_id"`
+	Hidden       bool                      `json:"hidden"`
+	Attributes   map[string]patchAttribute `json:"attributes"`
+}
+
+type patchAttribute struct {
+	Value  string `json:"value"`
+	Origin string `json:"origin,omitempty"`
+}
+
+func applyPatch(device model.Device, contentType string, patch []byte) (result model.Device, err error) {
+	doc := patchDocument{
+		Id:           device.Id,
+		LocalId:      device.LocalId,
+		Name:         device.Name,
+		DeviceTypeId: device.DeviceTypeId,
+		Hidden:       device.Hidden,
+		Attributes:   map[string]patchAttribute{},
+	}
+	for _, attr := range device.Attributes {
+		doc.Attributes[attr.Key] = patchAttribute{Value: attr.Value, Origin: attr.Origin}
+	}
+	original, err := json.Marshal(doc)
+	if err != nil {
+		return result, model.NewError(model.ErrorCodeInternal, err).WithLocalId(device.LocalId)
+	}
+
+	var patched []byte
+	switch contentType {
+	case model.MergePatchContentType:
+		patched, err = jsonpatch.MergePatch(original, patch)
+	case model.JsonPatchContentType:
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(patch)
+		if err == nil {
+			patched, err = operations.Apply(original)
+		}
+	default:
+		return result, model.NewError(model.ErrorCodeUnsupportedMediaType, fmt.Errorf("unsupported patch type %v, expected %v or %v", contentType, model.MergePatchContentType, model.JsonPatchContentType))
+	}
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return result, model.NewError(model.ErrorCodeConflict, err).WithLocalId(device.LocalId)
+	}
+	if err != nil {
+		return result, model.NewError(model.ErrorCodeInvalidRequest, fmt.Errorf("invalid patch: %w", err)).WithLocalId(device.LocalId)
+	}
+
+	doc = patchDocument{}
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&doc)
+	if err != nil {
+		return result, model.NewError(model.ErrorCodeInvalidRequest, fmt.Errorf("invalid patch result: %w", err)).WithLocalId(device.LocalId)
+	}
+	if doc.LocalId != device.LocalId {
+		return result, model.NewError(model.ErrorCodeInvalidRequest, errors.New("local_id can not be changed")).WithLocalId(device.LocalId)
+	}
+
+	result = device
+	result.Id, result.Name, result.DeviceTypeId, result.Hidden = doc.Id, doc.Name, doc.DeviceTypeId, doc.Hidden
+	result.Attributes = patchedAttributes(device.Attributes, doc.Attributes)
+	return result, nil
+}
+
+// patchedAttributes keeps the order of remaining attributes and appends new attributes sorted by key
+func patchedAttributes(old []models.Attribute, patched map[string]patchAttribute) (result []models.Attribute) {
+	result = []models.Attribute{}
+	for _, attr := range old {
+		if value, ok := patched[attr.Key]; ok {
+			result = append(result, models.Attribute{Key: attr.Key, Value: value.Value, Origin: value.Origin})
+			delete(patched, attr.Key)
+		}
+	}
+	added := []string{}
+	for key := range patched {
+		added = append(added, key)
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		result = append(result, models.Attribute{Key: key, Value: patched[key].Value, Origin: patched[key].Origin})
+	}
+	return result
+}
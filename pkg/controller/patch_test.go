@@ -0,0 +1,131 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"reflect"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	device := model.Device{
+		Device: models.Device{
+			LocalId:      "lid",
+			Name:         "name",
+			DeviceTypeId: "dt",
+			Attributes: []models.Attribute{
+				{Key: "b", Value: "1", Origin: "gw"},
+				{Key: "a", Value: "2"},
+				{Key: "c", Value: "3"},
+			},
+		},
+		UserId: "user",
+	}
+	cases := []struct {
+		name        string
+		contentType string
+		patch       string
+		expected    []models.Attribute
+		expectName  string
+		errCode     model.ErrorCode
+	}{
+		{
+			name:        "merge rename",
+			contentType: model.MergePatchContentType,
+			patch:       `{"name": "renamed"}`,
+			expected:    device.Attributes,
+			expectName:  "renamed",
+		},
+		{
+			name:        "merge attributes by key",
+			contentType: model.MergePatchContentType,
+			patch:       `{"attributes": {"a": {"value": "changed"}, "c": null, "e": {"value": "5"}, "d": {"value": "4"}}}`,
+			expected:    []models.Attribute{{Key: "b", Value: "1", Origin: "gw"}, {Key: "a", Value: "changed"}, {Key: "d", Value: "4"}, {Key: "e", Value: "5"}},
+			expectName:  "name",
+		},
+		{
+			name:        "merge keeps origin",
+			contentType: model.MergePatchContentType,
+			patch:       `{"attributes": {"b": {"value": "changed"}}}`,
+			expected:    []models.Attribute{{Key: "b", Value: "changed", Origin: "gw"}, {Key: "a", Value: "2"}, {Key: "c", Value: "3"}},
+			expectName:  "name",
+		},
+		{
+			name:        "json patch",
+			contentType: model.JsonPatchContentType,
+			patch:       `[{"op": "replace", "path": "/attributes/a/value", "value": "changed"}, {"op": "remove", "path": "/attributes/b"}, {"op": "add", "path": "/attributes/d", "value": {"value": "4"}}]`,
+			expected:    []models.Attribute{{Key: "a", Value: "changed"}, {Key: "c", Value: "3"}, {Key: "d", Value: "4"}},
+			expectName:  "name",
+		},
+		{
+			name:        "json patch test",
+			contentType: model.JsonPatchContentType,
+			patch:       `[{"op": "test", "path": "/name", "value": "other"}, {"op": "replace", "path": "/name", "value": "renamed"}]`,
+			errCode:     model.ErrorCodeConflict,
+		},
+		{
+			name:        "json patch remove unknown attribute",
+			contentType: model.JsonPatchContentType,
+			patch:       `[{"op": "remove", "path": "/attributes/unknown"}]`,
+			errCode:     model.ErrorCodeInvalidRequest,
+		},
+		{
+			name:        "change local_id",
+			contentType: model.MergePatchContentType,
+			patch:       `{"local_id": "other"}`,
+			errCode:     model.ErrorCodeInvalidRequest,
+		},
+		{
+			name:        "unknown field",
+			contentType: model.MergePatchContentType,
+			patch:       `{"user_id": "other"}`,
+			errCode:     model.ErrorCodeInvalidRequest,
+		},
+		{
+			name:        "invalid attribute",
+			contentType: model.MergePatchContentType,
+			patch:       `{"attributes": {"a": "changed"}}`,
+			errCode:     model.ErrorCodeInvalidRequest,
+		},
+		{
+			name:        "unsupported type",
+			contentType: "text/plain",
+			patch:       `{}`,
+			errCode:     model.ErrorCodeUnsupportedMediaType,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := applyPatch(device, c.contentType, []byte(c.patch))
+			if c.errCode != "" {
+				if !model.IsErrorCode(err, c.errCode) {
+					t.Error(err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if result.Name != c.expectName || result.UserId != "user" || result.DeviceTypeId != "dt" || !reflect.DeepEqual(result.Attributes, c.expected) {
+				t.Errorf("%#v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"slices"
+	"strings"
+)
+
+// keepOverrides merges an upsert into the stored device: the upserted values become the reported values,
+// while fields overridden by the user keep their effective values. With overwrite, all overrides are discarded.
+func keepOverrides(stored model.Device, upsert model.Device, overwrite bool) model.Device {
+	upsert.Reported, upsert.Overrides = nil, nil
+	if overwrite || len(stored.Overrides) == 0 {
+		return upsert
+	}
+	reported := reportedValues(upsert)
+	for _, field := range stored.Overrides {
+		switch {
+		case field == model.OverrideName:
+			upsert.Name = stored.Name
+		case field == model.OverrideDeviceTypeId:
+			upsert.DeviceTypeId = stored.DeviceTypeId
+		case strings.HasPrefix(field, model.OverrideAttributePrefix):
+			key := strings.TrimPrefix(field, model.OverrideAttributePrefix)
+			attr, ok := findAttribute(stored.Attributes, key)
+			upsert.Attributes = replaceAttribute(upsert.Attributes, key, attr, ok)
+		}
+	}
+	return withProvenance(upsert, reported, stored.Overrides)
+}
+
+// trackOverrides adds the fields changed by a patch to the overrides of the device
+func trackOverrides(old model.Device, patched model.Device) model.Device {
+	reported := reportedValues(old)
+	if old.Reported != nil {
+		reported = *old.Reported
+	}
+	overrides := slices.Clone(old.Overrides)
+	if patched.Name != old.Name {
+		overrides = append(overrides, model.OverrideName)
+	}
+	if patched.DeviceTypeId != old.DeviceTypeId {
+		overrides = append(overrides, model.OverrideDeviceTypeId)
+	}
+	for _, attributes := range [][]models.Attribute{old.Attributes, patched.Attributes} {
+		for _, attr := range attributes {
+			if attributeChanged(old.Attributes, patched.Attributes, attr.Key) {
+				overrides = append(overrides, model.OverrideAttributePrefix+attr.Key)
+			}
+		}
+	}
+	return withProvenance(patched, reported, overrides)
+}
+
+// withProvenance sets the reported values and the overrides that still differ from them;
+// if no field differs, the reported values equal the effective values and both are removed
+func withProvenance(device model.Device, reported model.Reported, overrides []string) model.Device {
+	device.Reported, device.Overrides = nil, nil
+	overrides = slices.Clone(overrides)
+	slices.Sort(overrides)
+	for _, field := range slices.Compact(overrides) {
+		if overrideDiffers(device, reported, field) {
+			device.Overrides = append(device.Overrides, field)
+		}
+	}
+	if len(device.Overrides) > 0 {
+		device.Reported = &reported
+	}
+	return device
+}
+
+func overrideDiffers(device model.Device, reported model.Reported, field string) bool {
+	switch {
+	case field == model.OverrideName:
+		return device.Name != reported.Name
+	case field == model.OverrideDeviceTypeId:
+		return device.DeviceTypeId != reported.DeviceTypeId
+	case strings.HasPrefix(field, model.OverrideAttributePrefix):
+		return attributeChanged(reported.Attributes, device.Attributes, strings.TrimPrefix(field, model.OverrideAttributePrefix))
+	default:
+		return false
+	}
+}
+
+func reportedValues(device model.Device) model.Reported {
+	attributes := slices.Clone(device.Attributes)
+	if attributes == nil {
+		attributes = []models.Attribute{}
+	}
+	return model.Reported{Name: device.Name, DeviceTypeId: device.DeviceTypeId, Attributes: attributes}
+}
+
+func attributeChanged(a []models.Attribute, b []models.Attribute, key string) bool {
+	attrA, okA := findAttribute(a, key)
+	attrB, okB := findAttribute(b, key)
+	return okA != okB || attrA != attrB
+}
+
+func findAttribute(attributes []models.Attribute, key string) (models.Attribute, bool) {
+	for _, attr := range attributes {
+		if attr.Key == key {
+			return attr, true
+		}
+	}
+	return models.Attribute{}, false
+}
+
+// replaceAttribute sets or, if !exists, removes the attribute with the given key
+func replaceAttribute(attributes []models.Attribute, key string, attr models.Attribute, exists bool) (result []models.Attribute) {
+	result = []models.Attribute{}
+	replaced := false
+	for _, a := range attributes {
+		if a.Key != key {
+			result = append(result, a)
+		} else if exists && !replaced {
+			result = append(result, attr)
+			replaced = true
+		}
+	}
+	if exists && !replaced {
+		result = append(result, attr)
+	}
+	return result
+}
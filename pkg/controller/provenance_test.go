@@ -0,0 +1,116 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"reflect"
+	"testing"
+)
+
+func TestOverrides(t *testing.T) {
+	gateway := model.Device{
+		Device: models.Device{
+			LocalId:      "lid",
+			Name:         "gw-name",
+			DeviceTypeId: "dt",
+			Attributes: []models.Attribute{
+				{Key: "a", Value: "1", Origin: "gw"},
+				{Key: "b", Value: "2", Origin: "gw"},
+			},
+		},
+	}
+
+	t.Run("patch records overrides", func(t *testing.T) {
+		patched := gateway
+		patched.Name = "user-name"
+		patched.Attributes = []models.Attribute{{Key: "a", Value: "changed", Origin: "gw"}, {Key: "c", Value: "3"}}
+		result := trackOverrides(gateway, patched)
+		expectOverrides(t, result, []string{"attr.a", "attr.b", "attr.c", "name"})
+		if result.Reported == nil || result.Reported.Name != "gw-name" || !reflect.DeepEqual(result.Reported.Attributes, gateway.Attributes) {
+			t.Errorf("unexpected reported values %#v", result.Reported)
+		}
+	})
+
+	t.Run("patch back to reported value removes override", func(t *testing.T) {
+		patched := gateway
+		patched.Name = "user-name"
+		overridden := trackOverrides(gateway, patched)
+		reverted := overridden
+		reverted.Name = "gw-name"
+		result := trackOverrides(overridden, reverted)
+		expectOverrides(t, result, nil)
+		if result.Reported != nil {
+			t.Errorf("unexpected reported values %#v", result.Reported)
+		}
+	})
+
+	t.Run("upsert keeps overrides", func(t *testing.T) {
+		patched := gateway
+		patched.Name = "user-name"
+		patched.Attributes = []models.Attribute{{Key: "a", Value: "1", Origin: "gw"}}
+		stored := trackOverrides(gateway, patched)
+
+		upsert := gateway
+		upsert.Name = "gw-name-2"
+		upsert.DeviceTypeId = "dt-2"
+		upsert.Attributes = []models.Attribute{{Key: "a", Value: "10", Origin: "gw"}, {Key: "b", Value: "20", Origin: "gw"}}
+		result := keepOverrides(stored, upsert, false)
+		if result.Name != "user-name" || result.DeviceTypeId != "dt-2" {
+			t.Errorf("unexpected result %#v", result)
+		}
+		if !reflect.DeepEqual(result.Attributes, []models.Attribute{{Key: "a", Value: "10", Origin: "gw"}}) {
+			t.Errorf("unexpected attributes %#v", result.Attributes)
+		}
+		expectOverrides(t, result, []string{"attr.b", "name"})
+		if result.Reported == nil || result.Reported.Name != "gw-name-2" || !reflect.DeepEqual(result.Reported.Attributes, upsert.Attributes) {
+			t.Errorf("unexpected reported values %#v", result.Reported)
+		}
+	})
+
+	t.Run("upsert matching override removes it", func(t *testing.T) {
+		patched := gateway
+		patched.Name = "user-name"
+		stored := trackOverrides(gateway, patched)
+		upsert := gateway
+		upsert.Name = "user-name"
+		result := keepOverrides(stored, upsert, false)
+		expectOverrides(t, result, nil)
+		if result.Reported != nil || result.Name != "user-name" {
+			t.Errorf("unexpected result %#v", result)
+		}
+	})
+
+	t.Run("upsert with overwrite", func(t *testing.T) {
+		patched := gateway
+		patched.Name = "user-name"
+		stored := trackOverrides(gateway, patched)
+		result := keepOverrides(stored, gateway, true)
+		expectOverrides(t, result, nil)
+		if result.Reported != nil || result.Name != "gw-name" {
+			t.Errorf("unexpected result %#v", result)
+		}
+	})
+}
+
+func expectOverrides(t *testing.T, device model.Device, expected []string) {
+	t.Helper()
+	if !reflect.DeepEqual(device.Overrides, expected) {
+		t.Errorf("unexpected overrides %#v, expected %#v", device.Overrides, expected)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ListDeletedDevices lists the devices of the user in the trash, most recently deleted first
+func (this *Controller) ListDeletedDevices(ctx context.Context, token auth.Token, limit int, offset int) (result model.DeviceList, err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId())
+	ctx, span := tracing.Start(ctx, "Controller.ListDeletedDevices")
+	defer end(span, &err)
+	result.Limit, result.Offset = limit, offset
+	var errCode int
+	result.Result, result.Total, err, errCode = this.db.ListTrashedDevices(ctx, token.GetUserId(), limit, offset)
+	if err != nil {
+		return result, persistenceError(err, errCode, "")
+	}
+	return result, nil
+}
+
+// RestoreDevice moves a deleted device from the trash back to the waiting room.
+// Fails with a conflict if a device with the same local_id has been stored since.
+func (this *Controller) RestoreDevice(ctx context.Context, token auth.Token, localId string) (err error) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId(), logging.LocalIdKey, localId)
+	ctx, span := tracing.Start(ctx, "Controller.RestoreDevice", trace.WithAttributes(attribute.String(logging.LocalIdKey, localId)))
+	defer end(span, &err)
+	device, err, errCode := this.db.ReadTrashedDevice(ctx, localId)
+	if err != nil {
+		return persistenceError(err, errCode, localId)
+	}
+	if device.UserId != token.GetUserId() {
+		return accessDenied(localId)
+	}
+	device.LastUpdate = time.Now()
+	err, errCode = this.db.RestoreTrashedDevice(ctx, device)
+	if err != nil {
+		return persistenceError(err, errCode, localId)
+	}
+	this.Trigger(token.GetUserId(), model.EventUpdateRestoreType, localId)
+	return nil
+}
+
+func (this *Controller) RestoreMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error) {
+	ctx, span := tracing.Start(ctx, "Controller.RestoreMultipleDevices")
+	defer end(span, &err)
+	for _, id := range ids {
+		err = this.RestoreDevice(ctx, token, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartTrashPurge removes deleted devices after the configured trash_retention in the background,
+// once per trash_purge_interval until ctx is done
+func (this *Controller) StartTrashPurge(ctx context.Context, wg *sync.WaitGroup) {
+	config := this.Config()
+	retention := config.TrashRetention.Duration()
+	if retention <= 0 {
+		return
+	}
+	interval := config.TrashPurgeInterval.Duration()
+	if interval <= 0 {
+		slog.Warn("invalid trash_purge_interval, use default", "default", defaultTrashPurgeInterval)
+		interval = defaultTrashPurgeInterval
+	}
+	if wg != nil {
+		wg.Add(1)
+	}
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			this.purgeTrash(ctx, retention)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+const defaultTrashPurgeInterval = time.Hour
+
+func (this *Controller) purgeTrash(ctx context.Context, retention time.Duration) {
+	ctx, span := tracing.Start(ctx, "Controller.purgeTrash")
+	defer span.End()
+	count, err, errCode := this.db.PurgeTrash(ctx, time.Now().Add(-retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "unable to purge trash", "error", err, "status", errCode)
+		}
+		return
+	}
+	if count > 0 {
+		slog.InfoContext(ctx, "purged deleted devices", "count", count)
+	}
+}
@@ -3,21 +3,23 @@ package controller
 import (
 	"context"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/metrics"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	"github.com/gorilla/websocket"
-	"log"
-	"runtime/debug"
+	"log/slog"
 )
 
-func (this *Controller) HandleWs(conn *websocket.Conn) {
+func (this *Controller) HandleWs(ctx context.Context, conn *websocket.Conn) {
 	defer conn.Close()
+	this.metrics.WsConnections.Inc()
+	defer this.metrics.WsConnections.Dec()
 	connId := conn.RemoteAddr().String()
 	defer this.Unsubscribe(connId)
-	ctx, close := context.WithCancel(context.Background())
+	ctx, close := context.WithCancel(ctx)
 	err := this.startPing(ctx, conn)
 	if err != nil {
-		log.Println("ERROR:", err)
-		debug.PrintStack()
+		slog.ErrorContext(ctx, "unable to start ws ping", "error", err)
 		close()
 		return
 	}
@@ -30,20 +32,18 @@ func (this *Controller) HandleWs(conn *websocket.Conn) {
 				return
 			}
 			if err != nil {
-				log.Println("ERROR: ws read:", err)
+				slog.WarnContext(ctx, "unable to read ws message", "error", err)
 				return
 			}
 			switch msg.Type {
 			case model.WsAuthType:
-				err = this.handleWsAuth(connId, close, conn, msg)
+				err = this.handleWsAuth(ctx, connId, close, conn, msg)
 				if err != nil {
-					log.Println("ERROR: handleWsAuth:", err)
+					slog.WarnContext(ctx, "unable to handle ws auth", "error", err)
 					return
 				}
 			default:
-				if this.config.Debug {
-					log.Println("DEBUG: ignore client ws message", msg)
-				}
+				slog.DebugContext(ctx, "ignore client ws message", "type", msg.Type)
 			}
 		}
 	}()
@@ -63,21 +63,24 @@ func (this *Controller) wsSendAuthRequest(conn *websocket.Conn) error {
 	})
 }
 
-func (this *Controller) handleWsAuth(connId string, close func(), conn *websocket.Conn, msg model.EventMessage) error {
+func (this *Controller) handleWsAuth(ctx context.Context, connId string, close func(), conn *websocket.Conn, msg model.EventMessage) error {
 	this.Unsubscribe(connId)
-	token, err := auth.ParseAndValidateToken(msg.Payload, this.config.JwtPubRsaKey)
+	token, err := auth.ParseAndValidateToken(msg.Payload, this.Config().JwtPubRsaKey)
 	if err != nil {
 		return this.wsSendError(conn, err.Error())
 	}
 	if token.IsExpired() {
 		return this.wsSendError(conn, "expired auth token")
 	}
-	this.Subscribe(connId, token.GetUserId(), func(eventType string, id string) {
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId())
+	slog.DebugContext(ctx, "ws authenticated")
+	this.Subscribe(model.TransportWs, connId, token.GetUserId(), func(eventType string, id string) {
 		if token.IsExpired() {
+			this.metrics.DroppedEvents.WithLabelValues(metrics.DroppedExpiredAuth).Inc()
 			this.Unsubscribe(connId)
 			err = this.wsSendAuthRequest(conn)
 			if err != nil {
-				log.Println("ERROR: unable to send auth request", err)
+				slog.WarnContext(ctx, "unable to send ws auth request", "error", err)
 				close()
 			}
 			return
@@ -87,7 +90,8 @@ func (this *Controller) handleWsAuth(connId string, close func(), conn *websocke
 			Payload: id,
 		})
 		if err != nil {
-			log.Println("ERROR: unable to send update message", err)
+			this.metrics.DroppedEvents.WithLabelValues(metrics.DroppedSendFailed).Inc()
+			slog.WarnContext(ctx, "unable to send ws update message", "error", err, "type", eventType, logging.LocalIdKey, id)
 			close()
 		}
 	})
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package logging configures the structured logger of the service and carries request scoped log attributes (like the request id) in a context.Context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const RequestIdHeader = "X-Request-ID"
+
+const RequestIdKey = "request_id"
+const UserIdKey = "user_id"
+const LocalIdKey = "local_id"
+const TraceIdKey = "trace_id"
+const SpanIdKey = "span_id"
+
+// level of the logger created by Setup, changed by UpdateLevel
+var level = &slog.LevelVar{}
+
+// Setup replaces the default logger (including the output of the log package) with a json logger writing to stderr
+func Setup(config configuration.Config) error {
+	err := UpdateLevel(config)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(newLogger(os.Stderr, level))
+	return nil
+}
+
+// UpdateLevel changes the level of the logger created by Setup to the level of config.LogLevel and config.Debug
+func UpdateLevel(config configuration.Config) error {
+	l, err := configLevel(config)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// New creates a json logger with the level of config.LogLevel; config.Debug lowers the level to debug.
+// Attributes added to a context with With and the ids of the current trace span are included in every record logged with this context.
+func New(w io.Writer, config configuration.Config) (*slog.Logger, error) {
+	l, err := configLevel(config)
+	if err != nil {
+		return nil, err
+	}
+	return newLogger(w, l), nil
+}
+
+func newLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(&contextHandler{handler: slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+func configLevel(config configuration.Config) (slog.Level, error) {
+	level, err := ParseLevel(config.LogLevel)
+	if err != nil {
+		return level, err
+	}
+	if config.Debug && level > slog.LevelDebug {
+		level = slog.LevelDebug
+	}
+	return level, nil
+}
+
+// ParseLevel parses debug, info, warn or error (case-insensitive); an empty level is info
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log_level %v, expected debug, info, warn or error", level)
+	}
+}
+
+type contextKey struct{}
+
+// With returns a context, which adds the given key-value pairs to all records logged with it.
+// An attribute replaces an attribute with the same key, which has been added before.
+func With(ctx context.Context, args ...any) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	record := slog.Record{}
+	record.Add(args...)
+	existing := attributes(ctx)
+	result := make([]slog.Attr, 0, len(existing)+record.NumAttrs())
+	replaced := map[string]bool{}
+	record.Attrs(func(attr slog.Attr) bool {
+		replaced[attr.Key] = true
+		return true
+	})
+	for _, attr := range existing {
+		if !replaced[attr.Key] {
+			result = append(result, attr)
+		}
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		result = append(result, attr)
+		return true
+	})
+	return context.WithValue(ctx, contextKey{}, result)
+}
+
+func attributes(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	result, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	return result
+}
+
+// WithRequestId adds the request id to the log attributes of ctx
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return With(ctx, RequestIdKey, requestId)
+}
+
+// RequestId returns the request id added by WithRequestId or an empty string
+func RequestId(ctx context.Context) string {
+	for _, attr := range attributes(ctx) {
+		if attr.Key == RequestIdKey {
+			return attr.Value.String()
+		}
+	}
+	return ""
+}
+
+// NewRequestId generates a random request id
+func NewRequestId() string {
+	return uuid.NewString()
+}
+
+// ValidRequestId checks if a request id received from a client may be used; otherwise a new id should be generated
+func ValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > 128 {
+		return false
+	}
+	for _, r := range requestId {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+type contextHandler struct {
+	handler slog.Handler
+}
+
+func (this *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return this.handler.Enabled(ctx, level)
+}
+
+func (this *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := attributes(ctx)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		attrs = append(attrs[:len(attrs):len(attrs)], slog.String(TraceIdKey, spanContext.TraceID().String()), slog.String(SpanIdKey, spanContext.SpanID().String()))
+	}
+	if len(attrs) == 0 {
+		return this.handler.Handle(ctx, record)
+	}
+	//attributes of the record take precedence over attributes of the context with the same key
+	keys := map[string]bool{}
+	record.Attrs(func(attr slog.Attr) bool {
+		keys[attr.Key] = true
+		return true
+	})
+	record = record.Clone()
+	for _, attr := range attrs {
+		if !keys[attr.Key] {
+			record.AddAttrs(attr)
+		}
+	}
+	return this.handler.Handle(ctx, record)
+}
+
+func (this *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{handler: this.handler.WithAttrs(attrs)}
+}
+
+func (this *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{handler: this.handler.WithGroup(name)}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestContextAttributes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger, err := New(buf, configuration.Config{LogLevel: "info"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ctx := WithRequestId(context.Background(), "r1")
+	ctx = With(ctx, UserIdKey, "u1", LocalIdKey, "d1")
+	ctx = With(ctx, LocalIdKey, "d2")
+	if RequestId(ctx) != "r1" {
+		t.Error(RequestId(ctx))
+	}
+
+	logger.DebugContext(ctx, "hidden by level")
+	logger.InfoContext(ctx, "test", "foo", "bar", LocalIdKey, "d3")
+
+	actual := map[string]interface{}{}
+	err = json.Unmarshal(buf.Bytes(), &actual)
+	if err != nil {
+		t.Error(err, buf.String())
+		return
+	}
+	delete(actual, "time")
+	expected := map[string]interface{}{
+		"level":      "INFO",
+		"msg":        "test",
+		"foo":        "bar",
+		RequestIdKey: "r1",
+		UserIdKey:    "u1",
+		LocalIdKey:   "d3",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error(actual)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	cases := []struct {
+		config   configuration.Config
+		expected slog.Level
+	}{
+		{config: configuration.Config{}, expected: slog.LevelInfo},
+		{config: configuration.Config{LogLevel: "WARN"}, expected: slog.LevelWarn},
+		{config: configuration.Config{LogLevel: "error"}, expected: slog.LevelError},
+		{config: configuration.Config{LogLevel: "error", Debug: true}, expected: slog.LevelDebug},
+	}
+	for _, c := range cases {
+		logger, err := New(&bytes.Buffer{}, c.config)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !logger.Enabled(context.Background(), c.expected) || logger.Enabled(context.Background(), c.expected-1) {
+			t.Error(c.config, c.expected)
+		}
+	}
+	_, err := New(&bytes.Buffer{}, configuration.Config{LogLevel: "verbose"})
+	if err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestValidRequestId(t *testing.T) {
+	for _, id := range []string{"abc", "0b9c7f8e-6d5a-4c3b-9a1f-2e3d4c5b6a79", NewRequestId()} {
+		if !ValidRequestId(id) {
+			t.Error("expected valid", id)
+		}
+	}
+	for _, id := range []string{"", "a b", "ä", string(bytes.Repeat([]byte("a"), 129))} {
+		if ValidRequestId(id) {
+			t.Error("expected invalid", id)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package metrics collects the prometheus metrics of the service, which are served by the api on /metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const namespace = "device_waiting_room"
+
+// outcomes of device-manager calls
+const DeviceManagerSuccess = "success"
+const DeviceManagerRejected = "rejected"       //device-manager responded with a status >= 300
+const DeviceManagerUnreachable = "unreachable" //no response from the device-manager
+
+// reasons for events not delivered to a websocket subscriber
+const DroppedExpiredAuth = "expired_auth"
+const DroppedSendFailed = "send_failed"
+
+// DeviceStateCounter returns the number of devices of all users per state (see model.DeviceStates)
+type DeviceStateCounter = func() (result map[string]int64, err error)
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	HttpRequests          *prometheus.CounterVec
+	HttpRequestDuration   *prometheus.HistogramVec
+	PersistenceDuration   *prometheus.HistogramVec
+	DeviceManagerRequests *prometheus.CounterVec
+	DeviceManagerDuration prometheus.Histogram
+	WsConnections         prometheus.Gauge
+	WsSubscriptions       prometheus.Gauge
+	GrpcWatchStreams      prometheus.Gauge
+	DroppedEvents         *prometheus.CounterVec
+}
+
+// New creates the metrics in their own registry, so that multiple instances (e.g. in tests) do not interfere
+func New() *Metrics {
+	result := &Metrics{
+		registry: prometheus.NewRegistry(),
+		HttpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of handled http requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		HttpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of http requests by route, method and status; websocket connections are not observed.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		PersistenceDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "persistence_duration_seconds",
+			Help:      "Duration of persistence calls by backend and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "method"}),
+		DeviceManagerRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "device_manager_requests_total",
+			Help:      "Number of device-manager calls by outcome (success, rejected, unreachable).",
+		}, []string{"outcome"}),
+		DeviceManagerDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "device_manager_request_duration_seconds",
+			Help:      "Duration of device-manager calls.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		WsConnections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ws_connections",
+			Help:      "Number of open websocket connections.",
+		}),
+		WsSubscriptions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ws_subscriptions",
+			Help:      "Number of authenticated websocket subscriptions.",
+		}),
+		GrpcWatchStreams: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "grpc_watch_streams",
+			Help:      "Number of open grpc Watch streams.",
+		}),
+		DroppedEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dropped_events_total",
+			Help:      "Number of events not delivered to websocket subscribers by reason (expired_auth, send_failed).",
+		}, []string{"reason"}),
+	}
+	result.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		result.HttpRequests,
+		result.HttpRequestDuration,
+		result.PersistenceDuration,
+		result.DeviceManagerRequests,
+		result.DeviceManagerDuration,
+		result.WsConnections,
+		result.WsSubscriptions,
+		result.GrpcWatchStreams,
+		result.DroppedEvents,
+	)
+	return result
+}
+
+// Handler serves the metrics in the prometheus exposition format
+func (this *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(this.registry, promhttp.HandlerOpts{Registry: this.registry})
+}
+
+// RegisterDeviceStates adds the waiting-room size per state, which is counted by counter on every scrape
+func (this *Metrics) RegisterDeviceStates(counter DeviceStateCounter) {
+	this.registry.MustRegister(&deviceStateCollector{
+		counter: counter,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "devices"),
+			"Number of devices in the waiting room by state.",
+			[]string{"state"}, nil),
+	})
+}
+
+// ObservePersistence records the duration of a persistence call started at start
+func (this *Metrics) ObservePersistence(backend string, method string, start time.Time) {
+	this.PersistenceDuration.WithLabelValues(backend, method).Observe(time.Since(start).Seconds())
+}
+
+type deviceStateCollector struct {
+	counter DeviceStateCounter
+	desc    *prometheus.Desc
+}
+
+func (this *deviceStateCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- this.desc
+}
+
+func (this *deviceStateCollector) Collect(metrics chan<- prometheus.Metric) {
+	counts, err := this.counter()
+	if err != nil {
+		slog.Error("unable to count devices for metrics", "error", err)
+		metrics <- prometheus.NewInvalidMetric(this.desc, err)
+		return
+	}
+	for state, count := range counts {
+		metrics <- prometheus.MustNewConstMetric(this.desc, prometheus.GaugeValue, float64(count), state)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorCode identifies the kind of an Error; unlike the detail message, codes are stable and may be used by clients
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest           ErrorCode = "invalid_request"
+	ErrorCodeUnauthorized             ErrorCode = "unauthorized"
+	ErrorCodeAccessDenied             ErrorCode = "access_denied"
+	ErrorCodeNotFound                 ErrorCode = "not_found"
+	ErrorCodeConflict                 ErrorCode = "conflict"
+	ErrorCodeUnsupportedMediaType     ErrorCode = "unsupported_media_type"
+	ErrorCodeTooLarge                 ErrorCode = "too_large"
+	ErrorCodeDeviceManagerRejected    ErrorCode = "device_manager_rejected"
+	ErrorCodeDeviceManagerUnavailable ErrorCode = "device_manager_unavailable"
+	ErrorCodeInternal                 ErrorCode = "internal"
+)
+
+var errorCodeStatus = map[ErrorCode]int{
+	ErrorCodeInvalidRequest:           http.StatusBadRequest,
+	ErrorCodeUnauthorized:             http.StatusUnauthorized,
+	ErrorCodeAccessDenied:             http.StatusForbidden,
+	ErrorCodeNotFound:                 http.StatusNotFound,
+	ErrorCodeConflict:                 http.StatusConflict,
+	ErrorCodeUnsupportedMediaType:     http.StatusUnsupportedMediaType,
+	ErrorCodeTooLarge:                 http.StatusRequestEntityTooLarge,
+	ErrorCodeDeviceManagerRejected:    http.StatusBadGateway,
+	ErrorCodeDeviceManagerUnavailable: http.StatusBadGateway,
+	ErrorCodeInternal:                 http.StatusInternalServerError,
+}
+
+var errorCodeTitle = map[ErrorCode]string{
+	ErrorCodeInvalidRequest:           "Invalid request",
+	ErrorCodeUnauthorized:             "Missing or invalid token",
+	ErrorCodeAccessDenied:             "Access denied",
+	ErrorCodeNotFound:                 "Not found",
+	ErrorCodeConflict:                 "Conflict",
+	ErrorCodeUnsupportedMediaType:     "Unsupported media type",
+	ErrorCodeTooLarge:                 "Request too large",
+	ErrorCodeDeviceManagerRejected:    "Device-manager rejected the device",
+	ErrorCodeDeviceManagerUnavailable: "Device-manager unavailable",
+	ErrorCodeInternal:                 "Internal error",
+}
+
+// Title returns a short, stable description of the code
+func (this ErrorCode) Title() string {
+	if title, ok := errorCodeTitle[this]; ok {
+		return title
+	}
+	return errorCodeTitle[ErrorCodeInternal]
+}
+
+// Status returns the http status code of errors with this code
+func (this ErrorCode) Status() int {
+	if status, ok := errorCodeStatus[this]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is returned by the controller; the api responds with it as Problem
+type Error struct {
+	Code           ErrorCode
+	Status         int    //http status code; defaults to Code.Status()
+	Detail         string //human-readable explanation of this occurrence
+	LocalId        string //device the error refers to, if any
+	UpstreamStatus int    //status code of the device-manager, if it caused the error
+	Err            error  //cause
+}
+
+// NewError creates an Error with the status of the code and the message of err as detail; err may be nil
+func NewError(code ErrorCode, err error) *Error {
+	result := &Error{Code: code, Status: code.Status(), Err: err}
+	if err != nil {
+		result.Detail = err.Error()
+	}
+	return result
+}
+
+func (this *Error) Error() string {
+	if this.Detail != "" {
+		return this.Detail
+	}
+	return this.Code.Title()
+}
+
+func (this *Error) Unwrap() error {
+	return this.Err
+}
+
+// WithLocalId sets the device the error refers to
+func (this *Error) WithLocalId(localId string) *Error {
+	this.LocalId = localId
+	return this
+}
+
+// AsError returns err as *Error; errors of other types are internal errors; nil stays nil
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var result *Error
+	if errors.As(err, &result) {
+		return result
+	}
+	return NewError(ErrorCodeInternal, err)
+}
+
+// ErrorStatus returns the http status code of err; http.StatusOK for nil
+func ErrorStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	result := AsError(err)
+	if result.Status == 0 {
+		return result.Code.Status()
+	}
+	return result.Status
+}
+
+// IsErrorCode reports whether err is an Error with the given code
+func IsErrorCode(err error, code ErrorCode) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix is followed by the ErrorCode in Problem.Type
+const ProblemTypePrefix = "urn:device-waiting-room:problem:"
+
+// Problem is the RFC 7807 representation of Error
+type Problem struct {
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Status         int       `json:"status"`
+	Detail         string    `json:"detail,omitempty"`
+	Instance       string    `json:"instance,omitempty"`
+	Code           ErrorCode `json:"code"`
+	LocalId        string    `json:"local_id,omitempty"`
+	UpstreamStatus int       `json:"upstream_status,omitempty"`
+	RequestId      string    `json:"request_id,omitempty"`
+}
+
+// Problem converts the error; instance and request id are set by the caller
+func (this *Error) Problem() Problem {
+	status := this.Status
+	if status == 0 {
+		status = this.Code.Status()
+	}
+	return Problem{
+		Type:           ProblemTypePrefix + string(this.Code),
+		Title:          this.Code.Title(),
+		Status:         status,
+		Detail:         this.Detail,
+		Code:           this.Code,
+		LocalId:        this.LocalId,
+		UpstreamStatus: this.UpstreamStatus,
+	}
+}
+
+// ToError converts the problem back, e.g. for clients of the api
+func (this Problem) ToError() *Error {
+	return &Error{
+		Code:           this.Code,
+		Status:         this.Status,
+		Detail:         this.Detail,
+		LocalId:        this.LocalId,
+		UpstreamStatus: this.UpstreamStatus,
+	}
+}
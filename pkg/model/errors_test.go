@@ -0,0 +1,64 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorStatus(t *testing.T) {
+	notFound := NewError(ErrorCodeNotFound, errors.New("missing")).WithLocalId("lid")
+	rejected := NewError(ErrorCodeDeviceManagerRejected, errors.New("invalid"))
+	rejected.Status = http.StatusBadRequest
+	for i, c := range []struct {
+		err      error
+		expected int
+	}{
+		{nil, http.StatusOK},
+		{errors.New("untyped"), http.StatusInternalServerError},
+		{notFound, http.StatusNotFound},
+		{fmt.Errorf("wrapped: %w", notFound), http.StatusNotFound},
+		{rejected, http.StatusBadRequest},
+		{&Error{Code: ErrorCodeAccessDenied}, http.StatusForbidden},
+	} {
+		if actual := ErrorStatus(c.err); actual != c.expected {
+			t.Error(i, actual, c.expected)
+		}
+	}
+	if !IsErrorCode(fmt.Errorf("wrapped: %w", notFound), ErrorCodeNotFound) || IsErrorCode(notFound, ErrorCodeInternal) {
+		t.Error("unexpected IsErrorCode result")
+	}
+}
+
+func TestProblem(t *testing.T) {
+	err := NewError(ErrorCodeDeviceManagerRejected, errors.New("invalid device type")).WithLocalId("lid")
+	err.UpstreamStatus = http.StatusBadRequest
+	problem := err.Problem()
+	if problem.Type != "urn:device-waiting-room:problem:device_manager_rejected" || problem.Status != http.StatusBadGateway || problem.Title == "" {
+		t.Error(problem)
+	}
+	back := problem.ToError()
+	if back.Code != err.Code || back.Status != http.StatusBadGateway || back.Detail != err.Detail || back.LocalId != "lid" || back.UpstreamStatus != http.StatusBadRequest {
+		t.Error(back)
+	}
+	if AsError(errors.New("untyped")).Problem().Code != ErrorCodeInternal {
+		t.Error("untyped errors should be internal")
+	}
+}
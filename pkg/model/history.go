@@ -0,0 +1,51 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import "time"
+
+// DeviceVersion is a state of a device in its history, which is recorded on every change of the device
+type DeviceVersion struct {
+	LocalId string        `json:"local_id"`
+	Version int64         `json:"version"` //increases by one per change of the local_id
+	Change  string        `json:"change"`  //see ChangeSet
+	Time    time.Time     `json:"time"`
+	Device  Device        `json:"device"`
+	Diff    []FieldChange `json:"diff"` //changes to the previous version, null if the previous version is not known (any more)
+}
+
+// changes recorded in the device history
+const ChangeSet = "set"
+const ChangePatch = "patch"
+const ChangeHide = "hide"
+const ChangeShow = "show"
+const ChangeUse = "use"
+const ChangeRestore = "restore"
+
+// FieldChange is a changed field of a device. Field is named like the overrides (see OverrideName),
+// or "id" and "hidden". Old or New are null if an attribute has been added or removed.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// DeviceHistory lists the known versions of a device, newest first
+type DeviceHistory struct {
+	LocalId  string          `json:"local_id"`
+	Versions []DeviceVersion `json:"versions"`
+}
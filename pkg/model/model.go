@@ -7,22 +7,117 @@ import (
 
 type Device struct {
 	models.Device
-	UserId       string    `json:"user_id"`
-	Hidden       bool      `json:"hidden"`
-	CreatedAt    time.Time `json:"created_at"`
-	LastUpdate   time.Time `json:"updated_at"`
-	SearchTokens string    `json:"-"` //searchable text for internal use
+	UserId       string     `json:"user_id"`
+	Hidden       bool       `json:"hidden"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUpdate   time.Time  `json:"updated_at"`
+	Reported     *Reported  `json:"reported,omitempty"`   //values of the last upsert, if the user has overridden some of them
+	Overrides    []string   `json:"overrides,omitempty"`  //fields changed by the user (see OverrideName), which are kept on upserts
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"` //set for devices in the trash
+	SearchTokens string     `json:"-"`                    //searchable text for internal use
+	SearchNgrams []string   `json:"-"`                    //n-grams of the searchable text for internal use
+}
+
+// Reported holds the values of the last upsert (PUT or import) of a device, typically by its gateway.
+// The fields of Device are the effective values, which differ from Reported for the fields listed in Device.Overrides.
+type Reported struct {
+	Name         string             `json:"name"`
+	DeviceTypeId string             `json:"device_type_id"`
+	Attributes   []models.Attribute `json:"attributes"`
+}
+
+// fields that may be overridden by the user; attributes are overridden by key with OverrideAttributePrefix + key
+const OverrideName = "name"
+const OverrideDeviceTypeId = "device_type_id"
+const OverrideAttributePrefix = "attr."
+
+// Health is the result of a health check; Status is HealthOk if all Checks are HealthOk
+type Health struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+type HealthCheck struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+const HealthOk = "ok"
+const HealthFailed = "failed"
+
+// AddCheck adds the result of a named check; a non-nil err marks the check and the Health as failed
+func (this *Health) AddCheck(name string, err error) {
+	if this.Checks == nil {
+		this.Checks = map[string]HealthCheck{}
+	}
+	if this.Status == "" {
+		this.Status = HealthOk
+	}
+	if err != nil {
+		this.Checks[name] = HealthCheck{Status: HealthFailed, Error: err.Error()}
+		this.Status = HealthFailed
+		return
+	}
+	this.Checks[name] = HealthCheck{Status: HealthOk}
+}
+
+// states of devices in the waiting room, e.g. for metrics
+const DeviceStateVisible = "visible"
+const DeviceStateHidden = "hidden"
+
+// DeviceStates returns the state of every device as zero count, to be increased by the count of each group
+func DeviceStates() map[string]int64 {
+	return map[string]int64{DeviceStateVisible: 0, DeviceStateHidden: 0}
 }
 
 type DeviceList struct {
-	Total  int64    `json:"total"`
-	Limit  int      `json:"limit"`
-	Offset int      `json:"offset"`
-	Sort   string   `json:"sort"`
-	Search string   `json:"search,omitempty"`
-	Result []Device `json:"result"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+	Sort   string        `json:"sort"`
+	Search string        `json:"search,omitempty"`
+	Result []Device      `json:"result"`
+	Facets *DeviceFacets `json:"facets,omitempty"`
+}
+
+// DeviceFacets contains the number of devices matching the list filter, grouped by the requested fields.
+// The hidden facet ignores the show_hidden filter, to be able to count hidden devices while they are not listed.
+type DeviceFacets struct {
+	DeviceTypeId []FacetCount            `json:"device_type_id,omitempty"`
+	Hidden       []FacetCount            `json:"hidden,omitempty"`
+	Attributes   map[string][]FacetCount `json:"attributes,omitempty"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// ImportResult reports the outcome of every row of an import; rows are counted from 1, not including a csv header
+type ImportResult struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+type ImportRowResult struct {
+	Row     int    `json:"row"`
+	LocalId string `json:"local_id,omitempty"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
 }
 
+const ImportStatusCreated = "created"
+const ImportStatusUpdated = "updated"
+const ImportStatusFailed = "failed"
+
+// content types of patches, see Controller.PatchDevice
+const MergePatchContentType = "application/merge-patch+json" //RFC 7386
+const JsonPatchContentType = "application/json-patch+json"   //RFC 6902
+
 type EventMessage struct {
 	Type    string `json:"type"`
 	Payload string `json:"payload,omitempty"`
@@ -35,7 +130,13 @@ const WsErrorType = "error"
 const WsUpdateSetType = "update_set"
 const WsUpdateDeleteType = "update_delete"
 const WsUpdateUseType = "update_use"
+const WsUpdateRestoreType = "update_restore"
 
 const EventUpdateSetType = WsUpdateSetType
 const EventUpdateDeleteType = WsUpdateDeleteType
 const EventUpdateUseType = WsUpdateUseType
+const EventUpdateRestoreType = WsUpdateRestoreType
+
+// transports of event subscriptions, counted separately in the metrics
+const TransportWs = "ws"
+const TransportGrpc = "grpc"
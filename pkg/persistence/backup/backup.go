@@ -0,0 +1,639 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package backup writes and restores backup archives of all devices, their history and the trash, independent of the persistence implementation.
+//
+// An archive is a gzip compressed stream of json lines. The first line is a Header, followed by one line per device,
+// one line per device version, one line per trashed device and a Trailer with the number of devices, versions and
+// trashed devices and their checksums (see migration.Summarize, migration.SummarizeHistory and migration.SummarizeTrash).
+// A missing trailer or a mismatching count or checksum marks the archive as corrupt.
+// Archives of format version 1 contain neither device history nor trash.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/migration"
+	"io"
+	"log"
+	"time"
+)
+
+const FormatName = "device-waiting-room-backup"
+
+// FormatVersion is increased on incompatible changes of the archive format
+const FormatVersion = 2
+
+// first format version with device history
+const historyFormatVersion = 2
+
+// first format version with trashed devices
+const trashFormatVersion = 2
+
+const lineTypeHeader = "header"
+const lineTypeDevice = "device"
+const lineTypeVersion = "version"
+const lineTypeTrashedDevice = "trashed_device"
+const lineTypeTrailer = "trailer"
+
+type Header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Source    string    `json:"source"` //db_impl of the backed up database
+}
+
+type Trailer struct {
+	migration.Summary
+	History *migration.Summary `json:"history,omitempty"` //versions of the archive, nil for archives of format version 1
+	Trash   *migration.Summary `json:"trash,omitempty"`   //trashed devices of the archive, nil for archives of format version 1
+}
+
+type line struct {
+	Type          string               `json:"type"`
+	Header        *Header              `json:"header,omitempty"`
+	Device        *model.Device        `json:"device,omitempty"`
+	Version       *model.DeviceVersion `json:"version,omitempty"`
+	TrashedDevice *model.Device        `json:"trashed_device,omitempty"`
+	Trailer       *Trailer             `json:"trailer,omitempty"`
+}
+
+type Mode string
+
+// ModeMerge stores the devices, versions and trashed devices of the archive and keeps all others
+const ModeMerge Mode = "merge"
+
+// ModeReplace stores the devices, versions and trashed devices of the archive and removes all others
+const ModeReplace Mode = "replace"
+
+var ErrCorrupt = errors.New("corrupt backup archive")
+
+type Source interface {
+	ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int)
+	migration.HistoryReader
+	migration.TrashReader
+}
+
+type Target interface {
+	migration.Reader
+	migration.HistoryReader
+	migration.TrashReader
+	SetDevices(devices []model.Device) (error, int)
+	RemoveDevices(localIds []string) (error, int)
+	SetDeviceVersions(versions []model.DeviceVersion) (error, int)
+	RemoveDeviceVersions(localIds []string) (error, int)
+	SetTrashedDevices(devices []model.Device) (error, int)
+	RemoveTrashedDevices(localIds []string) (error, int)
+}
+
+// Write writes an archive of all devices, versions and trashed devices of source to writer
+func Write(writer io.Writer, source Source, dbImpl string, batchSize int) (result Trailer, err error) {
+	if batchSize <= 0 {
+		batchSize = migration.DefaultBatchSize
+	}
+	compressed := gzip.NewWriter(writer)
+	encoder := json.NewEncoder(compressed)
+	err = encoder.Encode(line{Type: lineTypeHeader, Header: &Header{
+		Format:    FormatName,
+		Version:   FormatVersion,
+		CreatedAt: time.Now(),
+		Source:    dbImpl,
+	}})
+	if err != nil {
+		return result, err
+	}
+	summary := newSummary()
+	lastLocalId := ""
+	for {
+		batch, err, _ := source.ReadDevicesAfter(lastLocalId, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to read devices after %q: %w", lastLocalId, err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, device := range batch {
+			err = summary.addDevice(device)
+			if err != nil {
+				return result, err
+			}
+			err = encoder.Encode(line{Type: lineTypeDevice, Device: &device})
+			if err != nil {
+				return result, err
+			}
+		}
+		lastLocalId = batch[len(batch)-1].LocalId
+		log.Printf("backed up %v devices\n", summary.count)
+	}
+	history := newSummary()
+	lastLocalId = ""
+	lastVersion := int64(0)
+	for {
+		batch, err, _ := source.ReadDeviceVersionsAfter(lastLocalId, lastVersion, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to read versions after %q version %v: %w", lastLocalId, lastVersion, err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, version := range batch {
+			version.Diff = nil
+			err = history.addVersion(version)
+			if err != nil {
+				return result, err
+			}
+			err = encoder.Encode(line{Type: lineTypeVersion, Version: &version})
+			if err != nil {
+				return result, err
+			}
+		}
+		lastLocalId = batch[len(batch)-1].LocalId
+		lastVersion = batch[len(batch)-1].Version
+		log.Printf("backed up %v versions\n", history.count)
+	}
+	trash := newSummary()
+	lastLocalId = ""
+	for {
+		batch, err, _ := source.ReadTrashedDevicesAfter(lastLocalId, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to read trashed devices after %q: %w", lastLocalId, err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, device := range batch {
+			err = trash.addDevice(device)
+			if err != nil {
+				return result, err
+			}
+			err = encoder.Encode(line{Type: lineTypeTrashedDevice, TrashedDevice: &device})
+			if err != nil {
+				return result, err
+			}
+		}
+		lastLocalId = batch[len(batch)-1].LocalId
+		log.Printf("backed up %v trashed devices\n", trash.count)
+	}
+	historyResult := history.result()
+	trashResult := trash.result()
+	result = Trailer{Summary: summary.result(), History: &historyResult, Trash: &trashResult}
+	err = encoder.Encode(line{Type: lineTypeTrailer, Trailer: &result})
+	if err != nil {
+		return result, err
+	}
+	return result, compressed.Close()
+}
+
+// Verify reads the complete archive and checks format, counts and checksums
+func Verify(reader io.Reader) (header Header, trailer Trailer, err error) {
+	return read(reader, handler{})
+}
+
+type RestoreResult struct {
+	Header           Header `json:"header"`
+	Restored         int64  `json:"restored"`
+	Removed          int64  `json:"removed"` //devices removed by ModeReplace, because they are not part of the archive
+	RestoredVersions int64  `json:"restored_versions"`
+	RemovedVersions  int64  `json:"removed_versions"` //versions removed by ModeReplace before the versions of the archive are stored
+	RestoredTrash    int64  `json:"restored_trash"`
+	RemovedTrash     int64  `json:"removed_trash"` //trashed devices removed by ModeReplace before the trash of the archive is stored
+}
+
+// Restore stores the devices, versions and trashed devices of the archive in target. The archive is expected to be verified before;
+// the content is stored while reading, so that a corrupt archive may already have been partially restored.
+// With ModeReplace, the history and the trash of target are cleared before the content of the archive is stored,
+// devices not contained in the archive are removed after all devices have been stored and the restored database
+// is verified against the counts and checksums of the archive. Archives of format version 1 contain neither
+// history nor trash, so that both are kept.
+func Restore(reader io.Reader, target Target, mode Mode, batchSize int) (result RestoreResult, err error) {
+	if mode != ModeMerge && mode != ModeReplace {
+		return result, fmt.Errorf("unknown restore mode %v, expected %v or %v", mode, ModeMerge, ModeReplace)
+	}
+	if batchSize <= 0 {
+		batchSize = migration.DefaultBatchSize
+	}
+	restored := map[string]bool{}
+	batch := []model.Device{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err, _ := target.SetDevices(batch)
+		if err != nil {
+			return fmt.Errorf("unable to store devices: %w", err)
+		}
+		result.Restored += int64(len(batch))
+		log.Printf("restored %v devices\n", result.Restored)
+		batch = []model.Device{}
+		return nil
+	}
+	versions := []model.DeviceVersion{}
+	flushVersions := func() error {
+		if len(versions) == 0 {
+			return nil
+		}
+		err, _ := target.SetDeviceVersions(versions)
+		if err != nil {
+			return fmt.Errorf("unable to store versions: %w", err)
+		}
+		result.RestoredVersions += int64(len(versions))
+		log.Printf("restored %v versions\n", result.RestoredVersions)
+		versions = []model.DeviceVersion{}
+		return nil
+	}
+	trash := []model.Device{}
+	flushTrash := func() error {
+		if len(trash) == 0 {
+			return nil
+		}
+		err, _ := target.SetTrashedDevices(trash)
+		if err != nil {
+			return fmt.Errorf("unable to store trashed devices: %w", err)
+		}
+		result.RestoredTrash += int64(len(trash))
+		log.Printf("restored %v trashed devices\n", result.RestoredTrash)
+		trash = []model.Device{}
+		return nil
+	}
+	header, trailer, err := read(reader, handler{
+		header: func(header Header) (err error) {
+			if mode != ModeReplace {
+				return nil
+			}
+			if header.Version < historyFormatVersion {
+				log.Printf("backup format version %v contains no device history, the history of the database is kept\n", header.Version)
+			} else {
+				result.RemovedVersions, err = removeHistory(target, batchSize)
+				if err != nil {
+					return fmt.Errorf("unable to clear the device history: %w", err)
+				}
+			}
+			if header.Version < trashFormatVersion {
+				log.Printf("backup format version %v contains no trash, the trash of the database is kept\n", header.Version)
+			} else {
+				result.RemovedTrash, err = removeTrash(target, batchSize)
+				if err != nil {
+					return fmt.Errorf("unable to clear the trash: %w", err)
+				}
+			}
+			return nil
+		},
+		device: func(device model.Device) error {
+			if mode == ModeReplace {
+				restored[device.LocalId] = true
+			}
+			batch = append(batch, device)
+			if len(batch) >= batchSize {
+				return flush()
+			}
+			return nil
+		},
+		version: func(version model.DeviceVersion) error {
+			versions = append(versions, version)
+			if len(versions) >= batchSize {
+				return flushVersions()
+			}
+			return nil
+		},
+		trashedDevice: func(device model.Device) error {
+			trash = append(trash, device)
+			if len(trash) >= batchSize {
+				return flushTrash()
+			}
+			return nil
+		},
+	})
+	result.Header = header
+	if err == nil {
+		err = flush()
+	}
+	if err == nil {
+		err = flushVersions()
+	}
+	if err == nil {
+		err = flushTrash()
+	}
+	if err != nil {
+		return result, err
+	}
+	if mode == ModeReplace {
+		result.Removed, err = removeOthers(target, restored, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to remove devices not contained in the archive: %w", err)
+		}
+		actual, err := migration.Summarize(target, batchSize)
+		if err != nil {
+			return result, err
+		}
+		if actual != trailer.Summary {
+			return result, fmt.Errorf("%w: database contains %v devices with checksum %v, archive contains %v devices with checksum %v", migration.ErrVerificationFailed, actual.Count, actual.Checksum, trailer.Count, trailer.Checksum)
+		}
+		if trailer.History != nil {
+			actual, err = migration.SummarizeHistory(target, batchSize)
+			if err != nil {
+				return result, err
+			}
+			if actual != *trailer.History {
+				return result, fmt.Errorf("%w: database contains %v versions with checksum %v, archive contains %v versions with checksum %v", migration.ErrVerificationFailed, actual.Count, actual.Checksum, trailer.History.Count, trailer.History.Checksum)
+			}
+		}
+		if trailer.Trash != nil {
+			actual, err = migration.SummarizeTrash(target, batchSize)
+			if err != nil {
+				return result, err
+			}
+			if actual != *trailer.Trash {
+				return result, fmt.Errorf("%w: database contains %v trashed devices with checksum %v, archive contains %v trashed devices with checksum %v", migration.ErrVerificationFailed, actual.Count, actual.Checksum, trailer.Trash.Count, trailer.Trash.Checksum)
+			}
+		}
+	}
+	return result, nil
+}
+
+func removeTrash(target Target, batchSize int) (removed int64, err error) {
+	lastLocalId := ""
+	for {
+		batch, err, _ := target.ReadTrashedDevicesAfter(lastLocalId, batchSize)
+		if err != nil {
+			return removed, err
+		}
+		if len(batch) == 0 {
+			return removed, nil
+		}
+		localIds := []string{}
+		for _, device := range batch {
+			localIds = append(localIds, device.LocalId)
+		}
+		err, _ = target.RemoveTrashedDevices(localIds)
+		if err != nil {
+			return removed, err
+		}
+		removed += int64(len(batch))
+		lastLocalId = batch[len(batch)-1].LocalId
+	}
+}
+
+// removeHistory removes all versions of target; the last local_id of a batch is removed with the next batch,
+// so that its remaining versions are still read and counted
+func removeHistory(target Target, batchSize int) (removed int64, err error) {
+	lastLocalId := ""
+	lastVersion := int64(0)
+	pending := ""
+	for {
+		batch, err, _ := target.ReadDeviceVersionsAfter(lastLocalId, lastVersion, batchSize)
+		if err != nil {
+			return removed, err
+		}
+		localIds := []string{}
+		if pending != "" {
+			localIds = append(localIds, pending)
+		}
+		for _, version := range batch {
+			if len(localIds) == 0 || localIds[len(localIds)-1] != version.LocalId {
+				localIds = append(localIds, version.LocalId)
+			}
+		}
+		if len(batch) == 0 {
+			if len(localIds) > 0 {
+				err, _ = target.RemoveDeviceVersions(localIds)
+			}
+			return removed, err
+		}
+		pending = localIds[len(localIds)-1]
+		localIds = localIds[:len(localIds)-1]
+		if len(localIds) > 0 {
+			err, _ = target.RemoveDeviceVersions(localIds)
+			if err != nil {
+				return removed, err
+			}
+		}
+		removed += int64(len(batch))
+		lastLocalId = batch[len(batch)-1].LocalId
+		lastVersion = batch[len(batch)-1].Version
+	}
+}
+
+func removeOthers(target Target, keep map[string]bool, batchSize int) (removed int64, err error) {
+	lastLocalId := ""
+	for {
+		batch, err, _ := target.ReadDevicesAfter(lastLocalId, batchSize)
+		if err != nil {
+			return removed, err
+		}
+		if len(batch) == 0 {
+			return removed, nil
+		}
+		remove := []string{}
+		for _, device := range batch {
+			if !keep[device.LocalId] {
+				remove = append(remove, device.LocalId)
+			}
+		}
+		if len(remove) > 0 {
+			err, _ = target.RemoveDevices(remove)
+			if err != nil {
+				return removed, err
+			}
+			removed += int64(len(remove))
+		}
+		lastLocalId = batch[len(batch)-1].LocalId
+	}
+}
+
+// handler receives the content of an archive while it is read, nil functions are skipped
+type handler struct {
+	header        func(header Header) error
+	device        func(device model.Device) error
+	version       func(version model.DeviceVersion) error
+	trashedDevice func(device model.Device) error
+}
+
+func read(reader io.Reader, handler handler) (header Header, trailer Trailer, err error) {
+	decompressed, err := gzip.NewReader(reader)
+	if err != nil {
+		return header, trailer, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	defer decompressed.Close()
+	decoder := json.NewDecoder(bufio.NewReader(decompressed))
+	first := line{}
+	err = decoder.Decode(&first)
+	if err != nil {
+		return header, trailer, fmt.Errorf("%w: unable to read header: %v", ErrCorrupt, err)
+	}
+	if first.Type != lineTypeHeader || first.Header == nil || first.Header.Format != FormatName {
+		return header, trailer, fmt.Errorf("%w: missing header", ErrCorrupt)
+	}
+	header = *first.Header
+	if header.Version > FormatVersion {
+		return header, trailer, fmt.Errorf("unsupported backup format version %v, latest known version is %v", header.Version, FormatVersion)
+	}
+	if handler.header != nil {
+		err = handler.header(header)
+		if err != nil {
+			return header, trailer, err
+		}
+	}
+	summary := newSummary()
+	history := newSummary()
+	trash := newSummary()
+	localIds := map[string]bool{}
+	versions := map[string]map[int64]bool{}
+	trashedLocalIds := map[string]bool{}
+	for {
+		current := line{}
+		err = decoder.Decode(&current)
+		if err == io.EOF {
+			return header, trailer, fmt.Errorf("%w: missing trailer, the archive may be truncated", ErrCorrupt)
+		}
+		if err != nil {
+			return header, trailer, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		switch {
+		case current.Type == lineTypeDevice && current.Device != nil:
+			device := *current.Device
+			if device.LocalId == "" {
+				return header, trailer, fmt.Errorf("%w: device %v without local_id", ErrCorrupt, summary.count+1)
+			}
+			if localIds[device.LocalId] {
+				return header, trailer, fmt.Errorf("%w: duplicate local_id %v", ErrCorrupt, device.LocalId)
+			}
+			localIds[device.LocalId] = true
+			err = summary.addDevice(device)
+			if err != nil {
+				return header, trailer, err
+			}
+			if handler.device != nil {
+				err = handler.device(device)
+				if err != nil {
+					return header, trailer, err
+				}
+			}
+		case current.Type == lineTypeVersion && current.Version != nil && header.Version >= historyFormatVersion:
+			version := *current.Version
+			if version.LocalId == "" || version.Version <= 0 {
+				return header, trailer, fmt.Errorf("%w: version %v without local_id or version number", ErrCorrupt, history.count+1)
+			}
+			if versions[version.LocalId][version.Version] {
+				return header, trailer, fmt.Errorf("%w: duplicate version %v of local_id %v", ErrCorrupt, version.Version, version.LocalId)
+			}
+			if versions[version.LocalId] == nil {
+				versions[version.LocalId] = map[int64]bool{}
+			}
+			versions[version.LocalId][version.Version] = true
+			err = history.addVersion(version)
+			if err != nil {
+				return header, trailer, err
+			}
+			if handler.version != nil {
+				err = handler.version(version)
+				if err != nil {
+					return header, trailer, err
+				}
+			}
+		case current.Type == lineTypeTrashedDevice && current.TrashedDevice != nil && header.Version >= trashFormatVersion:
+			device := *current.TrashedDevice
+			if device.LocalId == "" || device.DeletedAt == nil {
+				return header, trailer, fmt.Errorf("%w: trashed device %v without local_id or deleted_at", ErrCorrupt, trash.count+1)
+			}
+			if trashedLocalIds[device.LocalId] {
+				return header, trailer, fmt.Errorf("%w: duplicate trashed local_id %v", ErrCorrupt, device.LocalId)
+			}
+			trashedLocalIds[device.LocalId] = true
+			err = trash.addDevice(device)
+			if err != nil {
+				return header, trailer, err
+			}
+			if handler.trashedDevice != nil {
+				err = handler.trashedDevice(device)
+				if err != nil {
+					return header, trailer, err
+				}
+			}
+		case current.Type == lineTypeTrailer && current.Trailer != nil:
+			trailer = *current.Trailer
+			if actual := summary.result(); actual != trailer.Summary {
+				return header, trailer, fmt.Errorf("%w: archive contains %v devices with checksum %v, trailer expects %v devices with checksum %v", ErrCorrupt, actual.Count, actual.Checksum, trailer.Count, trailer.Checksum)
+			}
+			if header.Version >= historyFormatVersion {
+				if trailer.History == nil {
+					return header, trailer, fmt.Errorf("%w: trailer without history summary", ErrCorrupt)
+				}
+				if actual := history.result(); actual != *trailer.History {
+					return header, trailer, fmt.Errorf("%w: archive contains %v versions with checksum %v, trailer expects %v versions with checksum %v", ErrCorrupt, actual.Count, actual.Checksum, trailer.History.Count, trailer.History.Checksum)
+				}
+			}
+			if header.Version >= trashFormatVersion {
+				if trailer.Trash == nil {
+					return header, trailer, fmt.Errorf("%w: trailer without trash summary", ErrCorrupt)
+				}
+				if actual := trash.result(); actual != *trailer.Trash {
+					return header, trailer, fmt.Errorf("%w: archive contains %v trashed devices with checksum %v, trailer expects %v trashed devices with checksum %v", ErrCorrupt, actual.Count, actual.Checksum, trailer.Trash.Count, trailer.Trash.Checksum)
+				}
+			}
+			if decoder.More() {
+				return header, trailer, fmt.Errorf("%w: unexpected content after trailer", ErrCorrupt)
+			}
+			return header, trailer, nil
+		default:
+			return header, trailer, fmt.Errorf("%w: unexpected line type %q", ErrCorrupt, current.Type)
+		}
+	}
+}
+
+// summary computes the same count and checksum as migration.Summarize, migration.SummarizeHistory and migration.SummarizeTrash, while the content is streamed
+type summary struct {
+	count    int64
+	checksum [sha256.Size]byte
+}
+
+func newSummary() *summary {
+	return &summary{}
+}
+
+func (this *summary) addDevice(device model.Device) error {
+	deviceChecksum, err := migration.DeviceChecksum(device)
+	if err != nil {
+		return err
+	}
+	this.add(deviceChecksum)
+	return nil
+}
+
+func (this *summary) addVersion(version model.DeviceVersion) error {
+	versionChecksum, err := migration.VersionChecksum(version)
+	if err != nil {
+		return err
+	}
+	this.add(versionChecksum)
+	return nil
+}
+
+func (this *summary) add(checksum [sha256.Size]byte) {
+	for i := range this.checksum {
+		this.checksum[i] ^= checksum[i]
+	}
+	this.count++
+}
+
+func (this *summary) result() migration.Summary {
+	return migration.Summary{Count: this.count, Checksum: hex.EncodeToString(this.checksum[:])}
+}
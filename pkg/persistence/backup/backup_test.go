@@ -0,0 +1,406 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/migration"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	devices  map[string]model.Device
+	versions map[string][]model.DeviceVersion //ordered by version
+	trash    map[string]model.Device
+}
+
+// newMemoryStore creates count devices with one version each and count/5 trashed devices
+func newMemoryStore(prefix string, count int) *memoryStore {
+	result := &memoryStore{devices: map[string]model.Device{}, versions: map[string][]model.DeviceVersion{}, trash: map[string]model.Device{}}
+	now := time.Now()
+	for i := 0; i < count; i++ {
+		localId := prefix + strconv.Itoa(i)
+		result.devices[localId] = model.Device{
+			Device: models.Device{
+				LocalId:    localId,
+				Name:       "device " + strconv.Itoa(i),
+				Attributes: []models.Attribute{{Key: "index", Value: strconv.Itoa(i)}},
+			},
+			UserId:     "user" + strconv.Itoa(i%3),
+			Hidden:     i%2 == 0,
+			CreatedAt:  now,
+			LastUpdate: now,
+		}
+		result.versions[localId] = []model.DeviceVersion{{LocalId: localId, Version: 1, Change: model.ChangeSet, Time: now, Device: result.devices[localId]}}
+	}
+	for i := 0; i < count/5; i++ {
+		localId := prefix + "-trash" + strconv.Itoa(i)
+		result.trash[localId] = model.Device{
+			Device:     models.Device{LocalId: localId, Name: "trashed " + strconv.Itoa(i)},
+			UserId:     "user" + strconv.Itoa(i%3),
+			CreatedAt:  now,
+			LastUpdate: now,
+			DeletedAt:  &now,
+		}
+	}
+	return result
+}
+
+func (this *memoryStore) ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	localIds := []string{}
+	for localId := range this.devices {
+		if localId > lastLocalId {
+			localIds = append(localIds, localId)
+		}
+	}
+	sort.Strings(localIds)
+	for i := 0; i < len(localIds) && i < limit; i++ {
+		result = append(result, this.devices[localIds[i]])
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *memoryStore) CountAllDevices() (count int64, err error, errCode int) {
+	return int64(len(this.devices)), nil, http.StatusOK
+}
+
+func (this *memoryStore) SetDevices(devices []model.Device) (error, int) {
+	for _, device := range devices {
+		this.devices[device.LocalId] = device
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) RemoveDevices(localIds []string) (error, int) {
+	for _, localId := range localIds {
+		delete(this.devices, localId)
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) ReadDeviceVersionsAfter(lastLocalId string, lastVersion int64, limit int) (result []model.DeviceVersion, err error, errCode int) {
+	localIds := []string{}
+	for localId := range this.versions {
+		if localId >= lastLocalId {
+			localIds = append(localIds, localId)
+		}
+	}
+	sort.Strings(localIds)
+	for _, localId := range localIds {
+		for _, version := range this.versions[localId] {
+			if localId == lastLocalId && version.Version <= lastVersion {
+				continue
+			}
+			if len(result) >= limit {
+				return result, nil, http.StatusOK
+			}
+			result = append(result, version)
+		}
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *memoryStore) SetDeviceVersions(versions []model.DeviceVersion) (error, int) {
+	for _, version := range versions {
+		list := []model.DeviceVersion{}
+		for _, existing := range this.versions[version.LocalId] {
+			if existing.Version != version.Version {
+				list = append(list, existing)
+			}
+		}
+		list = append(list, version)
+		sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+		this.versions[version.LocalId] = list
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) RemoveDeviceVersions(localIds []string) (error, int) {
+	for _, localId := range localIds {
+		delete(this.versions, localId)
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) ReadTrashedDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	localIds := []string{}
+	for localId := range this.trash {
+		if localId > lastLocalId {
+			localIds = append(localIds, localId)
+		}
+	}
+	sort.Strings(localIds)
+	for i := 0; i < len(localIds) && i < limit; i++ {
+		result = append(result, this.trash[localIds[i]])
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *memoryStore) SetTrashedDevices(devices []model.Device) (error, int) {
+	for _, device := range devices {
+		this.trash[device.LocalId] = device
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) RemoveTrashedDevices(localIds []string) (error, int) {
+	for _, localId := range localIds {
+		delete(this.trash, localId)
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) countVersions() (result int) {
+	for _, versions := range this.versions {
+		result += len(versions)
+	}
+	return result
+}
+
+func writeArchive(t *testing.T, source *memoryStore) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	_, err := Write(buf, source, "test", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteVerify(t *testing.T) {
+	source := newMemoryStore("a", 25)
+	archive := writeArchive(t, source)
+	header, trailer, err := Verify(bytes.NewReader(archive))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if header.Format != FormatName || header.Version != FormatVersion || header.Source != "test" {
+		t.Error(header)
+	}
+	expected, err := migration.Summarize(source, 10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if trailer.Summary != expected {
+		t.Error(trailer, expected)
+	}
+	expected, err = migration.SummarizeHistory(source, 10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if trailer.History == nil || *trailer.History != expected || expected.Count != 25 {
+		t.Error(trailer.History, expected)
+	}
+	expected, err = migration.SummarizeTrash(source, 10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if trailer.Trash == nil || *trailer.Trash != expected || expected.Count != 5 {
+		t.Error(trailer.Trash, expected)
+	}
+}
+
+// writeArchiveV1 writes the devices of source in format version 1, without history and trash
+func writeArchiveV1(t *testing.T, source *memoryStore) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	compressed := gzip.NewWriter(buf)
+	encoder := json.NewEncoder(compressed)
+	err := encoder.Encode(line{Type: lineTypeHeader, Header: &Header{Format: FormatName, Version: 1, CreatedAt: time.Now(), Source: "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices, _, _ := source.ReadDevicesAfter("", len(source.devices))
+	for _, device := range devices {
+		err = encoder.Encode(line{Type: lineTypeDevice, Device: &device})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	summary, err := migration.Summarize(source, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = encoder.Encode(line{Type: lineTypeTrailer, Trailer: &Trailer{Summary: summary}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = compressed.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestVerifyCorrupt(t *testing.T) {
+	archive := writeArchive(t, newMemoryStore("a", 25))
+	content, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := io.ReadAll(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(plain)), "\n")
+	firstVersion := 26 //after the header and 25 devices
+	firstTrashed := 51 //after the 25 versions
+
+	compress := func(lines []string) io.Reader {
+		buf := &bytes.Buffer{}
+		writer := gzip.NewWriter(buf)
+		writer.Write([]byte(strings.Join(lines, "\n")))
+		writer.Close()
+		return buf
+	}
+
+	cases := map[string]io.Reader{
+		"truncated":          compress(lines[:len(lines)-1]),
+		"missing device":     compress(append(append([]string{}, lines[:3]...), lines[4:]...)),
+		"modified device":    compress(append(append(append([]string{}, lines[:3]...), strings.Replace(lines[3], `"name":"device`, `"name":"changed`, 1)), lines[4:]...)),
+		"duplicate device":   compress(append(append([]string{}, lines[:4]...), lines[3:]...)),
+		"missing header":     compress(lines[1:]),
+		"not gzip":           strings.NewReader(string(plain)),
+		"truncated gzip":     bytes.NewReader(archive[:len(archive)/2]),
+		"content after end":  compress(append(append([]string{}, lines...), lines[3])),
+		"modified version":   compress(append(append(append([]string{}, lines[:firstVersion]...), strings.Replace(lines[firstVersion], `"change":"set"`, `"change":"patch"`, 1)), lines[firstVersion+1:]...)),
+		"duplicate version":  compress(append(append([]string{}, lines[:firstVersion+1]...), lines[firstVersion:]...)),
+		"missing history":    compress(append(append([]string{}, lines[:len(lines)-1]...), strings.Replace(lines[len(lines)-1], `"history":`, `"other":`, 1))),
+		"missing deleted_at": compress(append(append(append([]string{}, lines[:firstTrashed]...), strings.Replace(lines[firstTrashed], `"deleted_at":`, `"other":`, 1)), lines[firstTrashed+1:]...)),
+		"duplicate trashed":  compress(append(append([]string{}, lines[:firstTrashed+1]...), lines[firstTrashed:]...)),
+		"missing trash":      compress(append(append([]string{}, lines[:len(lines)-1]...), strings.Replace(lines[len(lines)-1], `"trash":`, `"other":`, 1))),
+	}
+	for name, reader := range cases {
+		_, _, err = Verify(reader)
+		if !errors.Is(err, ErrCorrupt) {
+			t.Error(name, err)
+		}
+	}
+}
+
+func TestRestoreMerge(t *testing.T) {
+	archive := writeArchive(t, newMemoryStore("a", 25))
+	target := newMemoryStore("b", 5)
+	result, err := Restore(bytes.NewReader(archive), target, ModeMerge, 10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Restored != 25 || result.Removed != 0 || len(target.devices) != 30 {
+		t.Error(result, len(target.devices))
+	}
+	if result.RestoredVersions != 25 || result.RemovedVersions != 0 || target.countVersions() != 30 {
+		t.Error(result, target.countVersions())
+	}
+	if result.RestoredTrash != 5 || result.RemovedTrash != 0 || len(target.trash) != 6 {
+		t.Error(result, len(target.trash))
+	}
+}
+
+func TestRestoreReplace(t *testing.T) {
+	source := newMemoryStore("a", 25)
+	archive := writeArchive(t, source)
+	target := newMemoryStore("a", 40)
+	for localId, device := range target.devices {
+		device.Name = "changed"
+		target.devices[localId] = device
+		target.versions[localId] = append(target.versions[localId], model.DeviceVersion{LocalId: localId, Version: 2, Change: model.ChangeSet, Device: device})
+	}
+	result, err := Restore(bytes.NewReader(archive), target, ModeReplace, 7)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Restored != 25 || result.Removed != 15 || len(target.devices) != 25 {
+		t.Error(result, len(target.devices))
+	}
+	if result.RestoredVersions != 25 || result.RemovedVersions != 80 || target.countVersions() != 25 {
+		t.Error(result, target.countVersions())
+	}
+	if result.RestoredTrash != 5 || result.RemovedTrash != 8 || len(target.trash) != 5 {
+		t.Error(result, len(target.trash))
+	}
+	expected, _ := migration.Summarize(source, 10)
+	actual, _ := migration.Summarize(target, 10)
+	if expected != actual {
+		t.Error(expected, actual)
+	}
+	expected, _ = migration.SummarizeHistory(source, 10)
+	actual, _ = migration.SummarizeHistory(target, 10)
+	if expected != actual {
+		t.Error(expected, actual)
+	}
+	expected, _ = migration.SummarizeTrash(source, 10)
+	actual, _ = migration.SummarizeTrash(target, 10)
+	if expected != actual {
+		t.Error(expected, actual)
+	}
+}
+
+func TestRestoreFormatVersion1(t *testing.T) {
+	archive := writeArchiveV1(t, newMemoryStore("a", 25))
+	_, trailer, err := Verify(bytes.NewReader(archive))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if trailer.History != nil || trailer.Trash != nil {
+		t.Error(trailer)
+	}
+	target := newMemoryStore("a", 40)
+	result, err := Restore(bytes.NewReader(archive), target, ModeReplace, 10)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Restored != 25 || result.Removed != 15 || len(target.devices) != 25 {
+		t.Error(result, len(target.devices))
+	}
+	if result.RestoredVersions != 0 || result.RemovedVersions != 0 || target.countVersions() != 40 {
+		t.Error("the history should be kept for archives without history", result, target.countVersions())
+	}
+	if result.RestoredTrash != 0 || result.RemovedTrash != 0 || len(target.trash) != 8 {
+		t.Error("the trash should be kept for archives without trash", result, len(target.trash))
+	}
+}
+
+func TestRestoreUnknownMode(t *testing.T) {
+	archive := writeArchive(t, newMemoryStore("a", 5))
+	target := newMemoryStore("b", 5)
+	_, err := Restore(bytes.NewReader(archive), target, "foo", 10)
+	if err == nil {
+		t.Error("expected error")
+	}
+	if len(target.devices) != 5 {
+		t.Error(len(target.devices))
+	}
+}
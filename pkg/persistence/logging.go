@@ -0,0 +1,189 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package persistence
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WithLogging logs every call to db with the log attributes of the call context (e.g. the request id).
+// Successful calls and client errors are logged at debug level, server errors at error level.
+func WithLogging(db Persistence, backend string) Persistence {
+	return &logged{db: db, backend: backend}
+}
+
+type logged struct {
+	db      Persistence
+	backend string
+}
+
+func (this *logged) log(ctx context.Context, method string, start time.Time, err *error, errCode *int, args ...any) {
+	level := slog.LevelDebug
+	if *err != nil && *errCode >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+	args = append(args, "backend", this.backend, "method", method, "duration_ms", float64(time.Since(start).Microseconds())/1000)
+	if *err != nil {
+		args = append(args, "error", (*err).Error(), "status", *errCode)
+	}
+	slog.Log(ctx, level, "persistence call", args...)
+}
+
+func (this *logged) ListDevices(ctx context.Context, userId string, options options.List) (result []model.Device, total int64, err error, errCode int) {
+	defer this.log(ctx, "ListDevices", time.Now(), &err, &errCode, logging.UserIdKey, userId)
+	return this.db.ListDevices(ctx, userId, options)
+}
+
+func (this *logged) CountFacets(ctx context.Context, userId string, options options.List) (result model.DeviceFacets, err error, errCode int) {
+	defer this.log(ctx, "CountFacets", time.Now(), &err, &errCode, logging.UserIdKey, userId)
+	return this.db.CountFacets(ctx, userId, options)
+}
+
+func (this *logged) ExportDevices(ctx context.Context, userId string, options options.List, handler func(device model.Device) error) (err error, errCode int) {
+	defer this.log(ctx, "ExportDevices", time.Now(), &err, &errCode, logging.UserIdKey, userId)
+	return this.db.ExportDevices(ctx, userId, options, handler)
+}
+
+func (this *logged) ReadDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	defer this.log(ctx, "ReadDevice", time.Now(), &err, &errCode, logging.LocalIdKey, localId)
+	return this.db.ReadDevice(ctx, localId)
+}
+
+func (this *logged) SetDevice(ctx context.Context, device model.Device) (err error, errCode int) {
+	defer this.log(ctx, "SetDevice", time.Now(), &err, &errCode, logging.LocalIdKey, device.LocalId)
+	return this.db.SetDevice(ctx, device)
+}
+
+func (this *logged) SetDeviceIfUnchanged(ctx context.Context, device model.Device, lastUpdate time.Time) (err error, errCode int) {
+	defer this.log(ctx, "SetDeviceIfUnchanged", time.Now(), &err, &errCode, logging.LocalIdKey, device.LocalId)
+	return this.db.SetDeviceIfUnchanged(ctx, device, lastUpdate)
+}
+
+func (this *logged) RemoveDevice(ctx context.Context, localId string) (err error, errCode int) {
+	defer this.log(ctx, "RemoveDevice", time.Now(), &err, &errCode, logging.LocalIdKey, localId)
+	return this.db.RemoveDevice(ctx, localId)
+}
+
+func (this *logged) AddDeviceVersion(ctx context.Context, version model.DeviceVersion, keep int64) (result model.DeviceVersion, err error, errCode int) {
+	defer this.log(ctx, "AddDeviceVersion", time.Now(), &err, &errCode, logging.LocalIdKey, version.LocalId)
+	return this.db.AddDeviceVersion(ctx, version, keep)
+}
+
+func (this *logged) ListDeviceVersions(ctx context.Context, userId string, localId string) (result []model.DeviceVersion, err error, errCode int) {
+	defer this.log(ctx, "ListDeviceVersions", time.Now(), &err, &errCode, logging.UserIdKey, userId, logging.LocalIdKey, localId)
+	return this.db.ListDeviceVersions(ctx, userId, localId)
+}
+
+func (this *logged) ReadDeviceVersion(ctx context.Context, userId string, localId string, version int64) (result model.DeviceVersion, err error, errCode int) {
+	defer this.log(ctx, "ReadDeviceVersion", time.Now(), &err, &errCode, logging.UserIdKey, userId, logging.LocalIdKey, localId, "version", version)
+	return this.db.ReadDeviceVersion(ctx, userId, localId, version)
+}
+
+func (this *logged) TrashDevice(ctx context.Context, localId string, deletedAt time.Time) (err error, errCode int) {
+	defer this.log(ctx, "TrashDevice", time.Now(), &err, &errCode, logging.LocalIdKey, localId)
+	return this.db.TrashDevice(ctx, localId, deletedAt)
+}
+
+func (this *logged) ListTrashedDevices(ctx context.Context, userId string, limit int, offset int) (result []model.Device, total int64, err error, errCode int) {
+	defer this.log(ctx, "ListTrashedDevices", time.Now(), &err, &errCode, logging.UserIdKey, userId)
+	return this.db.ListTrashedDevices(ctx, userId, limit, offset)
+}
+
+func (this *logged) ReadTrashedDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	defer this.log(ctx, "ReadTrashedDevice", time.Now(), &err, &errCode, logging.LocalIdKey, localId)
+	return this.db.ReadTrashedDevice(ctx, localId)
+}
+
+func (this *logged) RestoreTrashedDevice(ctx context.Context, device model.Device) (err error, errCode int) {
+	defer this.log(ctx, "RestoreTrashedDevice", time.Now(), &err, &errCode, logging.LocalIdKey, device.LocalId)
+	return this.db.RestoreTrashedDevice(ctx, device)
+}
+
+func (this *logged) PurgeTrash(ctx context.Context, deletedBefore time.Time) (count int64, err error, errCode int) {
+	defer this.log(ctx, "PurgeTrash", time.Now(), &err, &errCode, "deleted_before", deletedBefore)
+	return this.db.PurgeTrash(ctx, deletedBefore)
+}
+
+func (this *logged) Ping(ctx context.Context) (err error) {
+	errCode := http.StatusInternalServerError
+	defer this.log(ctx, "Ping", time.Now(), &err, &errCode)
+	return this.db.Ping(ctx)
+}
+
+func (this *logged) CountDevicesByState() (result map[string]int64, err error, errCode int) {
+	defer this.log(context.Background(), "CountDevicesByState", time.Now(), &err, &errCode)
+	return this.db.CountDevicesByState()
+}
+
+func (this *logged) ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	defer this.log(context.Background(), "ReadDevicesAfter", time.Now(), &err, &errCode)
+	return this.db.ReadDevicesAfter(lastLocalId, limit)
+}
+
+func (this *logged) CountAllDevices() (count int64, err error, errCode int) {
+	defer this.log(context.Background(), "CountAllDevices", time.Now(), &err, &errCode)
+	return this.db.CountAllDevices()
+}
+
+func (this *logged) SetDevices(devices []model.Device) (err error, errCode int) {
+	defer this.log(context.Background(), "SetDevices", time.Now(), &err, &errCode)
+	return this.db.SetDevices(devices)
+}
+
+func (this *logged) RemoveDevices(localIds []string) (err error, errCode int) {
+	defer this.log(context.Background(), "RemoveDevices", time.Now(), &err, &errCode)
+	return this.db.RemoveDevices(localIds)
+}
+
+func (this *logged) ReadDeviceVersionsAfter(lastLocalId string, lastVersion int64, limit int) (result []model.DeviceVersion, err error, errCode int) {
+	defer this.log(context.Background(), "ReadDeviceVersionsAfter", time.Now(), &err, &errCode)
+	return this.db.ReadDeviceVersionsAfter(lastLocalId, lastVersion, limit)
+}
+
+func (this *logged) SetDeviceVersions(versions []model.DeviceVersion) (err error, errCode int) {
+	defer this.log(context.Background(), "SetDeviceVersions", time.Now(), &err, &errCode)
+	return this.db.SetDeviceVersions(versions)
+}
+
+func (this *logged) RemoveDeviceVersions(localIds []string) (err error, errCode int) {
+	defer this.log(context.Background(), "RemoveDeviceVersions", time.Now(), &err, &errCode)
+	return this.db.RemoveDeviceVersions(localIds)
+}
+
+func (this *logged) ReadTrashedDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	defer this.log(context.Background(), "ReadTrashedDevicesAfter", time.Now(), &err, &errCode)
+	return this.db.ReadTrashedDevicesAfter(lastLocalId, limit)
+}
+
+func (this *logged) SetTrashedDevices(devices []model.Device) (err error, errCode int) {
+	defer this.log(context.Background(), "SetTrashedDevices", time.Now(), &err, &errCode)
+	return this.db.SetTrashedDevices(devices)
+}
+
+func (this *logged) RemoveTrashedDevices(localIds []string) (err error, errCode int) {
+	defer this.log(context.Background(), "RemoveTrashedDevices", time.Now(), &err, &errCode)
+	return this.db.RemoveTrashedDevices(localIds)
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package persistence
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/metrics"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"time"
+)
+
+// WithMetrics records the duration of every call to db as metrics.PersistenceDuration, labeled with backend and method name
+func WithMetrics(db Persistence, backend string, m *metrics.Metrics) Persistence {
+	return &instrumented{db: db, backend: backend, metrics: m}
+}
+
+type instrumented struct {
+	db      Persistence
+	backend string
+	metrics *metrics.Metrics
+}
+
+func (this *instrumented) ListDevices(ctx context.Context, userId string, options options.List) (result []model.Device, total int64, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ListDevices", time.Now())
+	return this.db.ListDevices(ctx, userId, options)
+}
+
+func (this *instrumented) CountFacets(ctx context.Context, userId string, options options.List) (result model.DeviceFacets, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "CountFacets", time.Now())
+	return this.db.CountFacets(ctx, userId, options)
+}
+
+func (this *instrumented) ExportDevices(ctx context.Context, userId string, options options.List, handler func(device model.Device) error) (err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ExportDevices", time.Now())
+	return this.db.ExportDevices(ctx, userId, options, handler)
+}
+
+func (this *instrumented) ReadDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ReadDevice", time.Now())
+	return this.db.ReadDevice(ctx, localId)
+}
+
+func (this *instrumented) SetDevice(ctx context.Context, device model.Device) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "SetDevice", time.Now())
+	return this.db.SetDevice(ctx, device)
+}
+
+func (this *instrumented) SetDeviceIfUnchanged(ctx context.Context, device model.Device, lastUpdate time.Time) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "SetDeviceIfUnchanged", time.Now())
+	return this.db.SetDeviceIfUnchanged(ctx, device, lastUpdate)
+}
+
+func (this *instrumented) RemoveDevice(ctx context.Context, localId string) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "RemoveDevice", time.Now())
+	return this.db.RemoveDevice(ctx, localId)
+}
+
+func (this *instrumented) AddDeviceVersion(ctx context.Context, version model.DeviceVersion, keep int64) (result model.DeviceVersion, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "AddDeviceVersion", time.Now())
+	return this.db.AddDeviceVersion(ctx, version, keep)
+}
+
+func (this *instrumented) ListDeviceVersions(ctx context.Context, userId string, localId string) (result []model.DeviceVersion, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ListDeviceVersions", time.Now())
+	return this.db.ListDeviceVersions(ctx, userId, localId)
+}
+
+func (this *instrumented) ReadDeviceVersion(ctx context.Context, userId string, localId string, version int64) (result model.DeviceVersion, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ReadDeviceVersion", time.Now())
+	return this.db.ReadDeviceVersion(ctx, userId, localId, version)
+}
+
+func (this *instrumented) TrashDevice(ctx context.Context, localId string, deletedAt time.Time) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "TrashDevice", time.Now())
+	return this.db.TrashDevice(ctx, localId, deletedAt)
+}
+
+func (this *instrumented) ListTrashedDevices(ctx context.Context, userId string, limit int, offset int) (result []model.Device, total int64, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ListTrashedDevices", time.Now())
+	return this.db.ListTrashedDevices(ctx, userId, limit, offset)
+}
+
+func (this *instrumented) ReadTrashedDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ReadTrashedDevice", time.Now())
+	return this.db.ReadTrashedDevice(ctx, localId)
+}
+
+func (this *instrumented) RestoreTrashedDevice(ctx context.Context, device model.Device) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "RestoreTrashedDevice", time.Now())
+	return this.db.RestoreTrashedDevice(ctx, device)
+}
+
+func (this *instrumented) PurgeTrash(ctx context.Context, deletedBefore time.Time) (count int64, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "PurgeTrash", time.Now())
+	return this.db.PurgeTrash(ctx, deletedBefore)
+}
+
+func (this *instrumented) Ping(ctx context.Context) error {
+	defer this.metrics.ObservePersistence(this.backend, "Ping", time.Now())
+	return this.db.Ping(ctx)
+}
+
+func (this *instrumented) CountDevicesByState() (result map[string]int64, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "CountDevicesByState", time.Now())
+	return this.db.CountDevicesByState()
+}
+
+func (this *instrumented) ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ReadDevicesAfter", time.Now())
+	return this.db.ReadDevicesAfter(lastLocalId, limit)
+}
+
+func (this *instrumented) CountAllDevices() (count int64, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "CountAllDevices", time.Now())
+	return this.db.CountAllDevices()
+}
+
+func (this *instrumented) SetDevices(devices []model.Device) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "SetDevices", time.Now())
+	return this.db.SetDevices(devices)
+}
+
+func (this *instrumented) RemoveDevices(localIds []string) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "RemoveDevices", time.Now())
+	return this.db.RemoveDevices(localIds)
+}
+
+func (this *instrumented) ReadDeviceVersionsAfter(lastLocalId string, lastVersion int64, limit int) (result []model.DeviceVersion, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ReadDeviceVersionsAfter", time.Now())
+	return this.db.ReadDeviceVersionsAfter(lastLocalId, lastVersion, limit)
+}
+
+func (this *instrumented) SetDeviceVersions(versions []model.DeviceVersion) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "SetDeviceVersions", time.Now())
+	return this.db.SetDeviceVersions(versions)
+}
+
+func (this *instrumented) RemoveDeviceVersions(localIds []string) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "RemoveDeviceVersions", time.Now())
+	return this.db.RemoveDeviceVersions(localIds)
+}
+
+func (this *instrumented) ReadTrashedDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	defer this.metrics.ObservePersistence(this.backend, "ReadTrashedDevicesAfter", time.Now())
+	return this.db.ReadTrashedDevicesAfter(lastLocalId, limit)
+}
+
+func (this *instrumented) SetTrashedDevices(devices []model.Device) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "SetTrashedDevices", time.Now())
+	return this.db.SetTrashedDevices(devices)
+}
+
+func (this *instrumented) RemoveTrashedDevices(localIds []string) (error, int) {
+	defer this.metrics.ObservePersistence(this.backend, "RemoveTrashedDevices", time.Now())
+	return this.db.RemoveTrashedDevices(localIds)
+}
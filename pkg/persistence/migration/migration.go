@@ -0,0 +1,509 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package migration copies all devices, their history and the trash from one persistence implementation to another.
+//
+// Devices are copied in batches ordered by local_id, followed by the device history ordered by local_id and version
+// and the trashed devices ordered by local_id. After every batch the position of the last copied entry is written
+// to an optional checkpoint file, from which an interrupted migration resumes. After the copy, the number of devices,
+// versions and trashed devices and a checksum over their content are compared between source and target,
+// before the source may be cleared.
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const DefaultBatchSize = 500
+
+type Reader interface {
+	// ReadDevicesAfter returns up to limit devices of all users with a local_id greater than lastLocalId, ordered by local_id
+	ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int)
+	CountAllDevices() (count int64, err error, errCode int)
+}
+
+type HistoryReader interface {
+	// ReadDeviceVersionsAfter returns up to limit versions of all users, which follow lastLocalId and lastVersion in the order of local_id and version
+	ReadDeviceVersionsAfter(lastLocalId string, lastVersion int64, limit int) (result []model.DeviceVersion, err error, errCode int)
+}
+
+type TrashReader interface {
+	// ReadTrashedDevicesAfter returns up to limit trashed devices of all users with a local_id greater than lastLocalId, ordered by local_id
+	ReadTrashedDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int)
+}
+
+type Source interface {
+	Reader
+	HistoryReader
+	TrashReader
+	RemoveDevices(localIds []string) (error, int)
+	RemoveDeviceVersions(localIds []string) (error, int)
+	RemoveTrashedDevices(localIds []string) (error, int)
+}
+
+type Target interface {
+	Reader
+	HistoryReader
+	TrashReader
+	SetDevices(devices []model.Device) (error, int)
+	SetDeviceVersions(versions []model.DeviceVersion) (error, int)
+	SetTrashedDevices(devices []model.Device) (error, int)
+}
+
+type Options struct {
+	BatchSize      int    //devices, versions or trashed devices per batch, DefaultBatchSize if 0
+	CheckpointFile string //if set, the progress is stored in this file and an existing checkpoint is resumed
+	DryRun         bool   //only read the source, nothing is written or deleted
+	DeleteSource   bool   //remove all devices, their history and the trash from the source after the target has been verified
+}
+
+type Result struct {
+	Migrated         int64   `json:"migrated"`          //devices copied by this run, not including devices copied before a resumed checkpoint
+	MigratedVersions int64   `json:"migrated_versions"` //versions copied by this run, not including versions copied before a resumed checkpoint
+	MigratedTrash    int64   `json:"migrated_trash"`    //trashed devices copied by this run, not including trashed devices copied before a resumed checkpoint
+	Source           Summary `json:"source"`
+	Target           Summary `json:"target"`
+	SourceHistory    Summary `json:"source_history"`
+	TargetHistory    Summary `json:"target_history"`
+	SourceTrash      Summary `json:"source_trash"`
+	TargetTrash      Summary `json:"target_trash"`
+}
+
+// Summary identifies the content of a device, history or trash store independent of the implementation and the order of devices
+type Summary struct {
+	Count    int64  `json:"count"`
+	Checksum string `json:"checksum"`
+}
+
+type Checkpoint struct {
+	LastLocalId        string    `json:"last_local_id"`
+	Migrated           int64     `json:"migrated"`
+	LastVersionLocalId string    `json:"last_version_local_id"`
+	LastVersion        int64     `json:"last_version"`
+	MigratedVersions   int64     `json:"migrated_versions"`
+	LastTrashLocalId   string    `json:"last_trash_local_id"`
+	MigratedTrash      int64     `json:"migrated_trash"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+var ErrVerificationFailed = errors.New("migration verification failed")
+
+func Run(source Source, target Target, options Options) (result Result, err error) {
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	total, err, _ := source.CountAllDevices()
+	if err != nil {
+		return result, fmt.Errorf("unable to count source devices: %w", err)
+	}
+
+	checkpoint := Checkpoint{}
+	if options.CheckpointFile != "" {
+		checkpoint, err = loadCheckpoint(options.CheckpointFile)
+		if err != nil {
+			return result, err
+		}
+		if checkpoint.LastLocalId != "" {
+			log.Printf("resume migration after local_id %q (%v devices already migrated)\n", checkpoint.LastLocalId, checkpoint.Migrated)
+		}
+	}
+
+	if options.DryRun {
+		log.Printf("dry run: %v devices in source\n", total)
+	} else {
+		log.Printf("migrate %v devices in batches of %v\n", total, options.BatchSize)
+	}
+	start := time.Now()
+	for {
+		batch, err, _ := source.ReadDevicesAfter(checkpoint.LastLocalId, options.BatchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to read source devices after %q: %w", checkpoint.LastLocalId, err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if !options.DryRun {
+			err, _ = target.SetDevices(batch)
+			if err != nil {
+				return result, fmt.Errorf("unable to write batch after %q to target: %w", checkpoint.LastLocalId, err)
+			}
+		}
+		checkpoint.LastLocalId = batch[len(batch)-1].LocalId
+		checkpoint.Migrated += int64(len(batch))
+		checkpoint.UpdatedAt = time.Now()
+		result.Migrated += int64(len(batch))
+		if !options.DryRun && options.CheckpointFile != "" {
+			err = storeCheckpoint(options.CheckpointFile, checkpoint)
+			if err != nil {
+				return result, err
+			}
+		}
+		logProgress(options.DryRun, checkpoint.Migrated, total, start)
+	}
+
+	for {
+		batch, err, _ := source.ReadDeviceVersionsAfter(checkpoint.LastVersionLocalId, checkpoint.LastVersion, options.BatchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to read source versions after %q version %v: %w", checkpoint.LastVersionLocalId, checkpoint.LastVersion, err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if !options.DryRun {
+			err, _ = target.SetDeviceVersions(batch)
+			if err != nil {
+				return result, fmt.Errorf("unable to write versions after %q version %v to target: %w", checkpoint.LastVersionLocalId, checkpoint.LastVersion, err)
+			}
+		}
+		checkpoint.LastVersionLocalId = batch[len(batch)-1].LocalId
+		checkpoint.LastVersion = batch[len(batch)-1].Version
+		checkpoint.MigratedVersions += int64(len(batch))
+		checkpoint.UpdatedAt = time.Now()
+		result.MigratedVersions += int64(len(batch))
+		if !options.DryRun && options.CheckpointFile != "" {
+			err = storeCheckpoint(options.CheckpointFile, checkpoint)
+			if err != nil {
+				return result, err
+			}
+		}
+		log.Printf("%v %v versions\n", progressVerb(options.DryRun), checkpoint.MigratedVersions)
+	}
+
+	for {
+		batch, err, _ := source.ReadTrashedDevicesAfter(checkpoint.LastTrashLocalId, options.BatchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to read source trash after %q: %w", checkpoint.LastTrashLocalId, err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if !options.DryRun {
+			err, _ = target.SetTrashedDevices(batch)
+			if err != nil {
+				return result, fmt.Errorf("unable to write trash after %q to target: %w", checkpoint.LastTrashLocalId, err)
+			}
+		}
+		checkpoint.LastTrashLocalId = batch[len(batch)-1].LocalId
+		checkpoint.MigratedTrash += int64(len(batch))
+		checkpoint.UpdatedAt = time.Now()
+		result.MigratedTrash += int64(len(batch))
+		if !options.DryRun && options.CheckpointFile != "" {
+			err = storeCheckpoint(options.CheckpointFile, checkpoint)
+			if err != nil {
+				return result, err
+			}
+		}
+		log.Printf("%v %v trashed devices\n", progressVerb(options.DryRun), checkpoint.MigratedTrash)
+	}
+
+	result.Source, err = Summarize(source, options.BatchSize)
+	if err != nil {
+		return result, fmt.Errorf("unable to summarize source: %w", err)
+	}
+	log.Printf("source: %v devices, checksum %v\n", result.Source.Count, result.Source.Checksum)
+	result.SourceHistory, err = SummarizeHistory(source, options.BatchSize)
+	if err != nil {
+		return result, fmt.Errorf("unable to summarize source history: %w", err)
+	}
+	log.Printf("source: %v versions, checksum %v\n", result.SourceHistory.Count, result.SourceHistory.Checksum)
+	result.SourceTrash, err = SummarizeTrash(source, options.BatchSize)
+	if err != nil {
+		return result, fmt.Errorf("unable to summarize source trash: %w", err)
+	}
+	log.Printf("source: %v trashed devices, checksum %v\n", result.SourceTrash.Count, result.SourceTrash.Checksum)
+	if options.DryRun {
+		log.Println("dry run finished, nothing has been written")
+		return result, nil
+	}
+
+	result.Target, err = Summarize(target, options.BatchSize)
+	if err != nil {
+		return result, fmt.Errorf("unable to summarize target: %w", err)
+	}
+	log.Printf("target: %v devices, checksum %v\n", result.Target.Count, result.Target.Checksum)
+	if result.Source != result.Target {
+		return result, fmt.Errorf("%w: source has %v devices with checksum %v, target has %v devices with checksum %v", ErrVerificationFailed, result.Source.Count, result.Source.Checksum, result.Target.Count, result.Target.Checksum)
+	}
+	result.TargetHistory, err = SummarizeHistory(target, options.BatchSize)
+	if err != nil {
+		return result, fmt.Errorf("unable to summarize target history: %w", err)
+	}
+	log.Printf("target: %v versions, checksum %v\n", result.TargetHistory.Count, result.TargetHistory.Checksum)
+	if result.SourceHistory != result.TargetHistory {
+		return result, fmt.Errorf("%w: source has %v versions with checksum %v, target has %v versions with checksum %v", ErrVerificationFailed, result.SourceHistory.Count, result.SourceHistory.Checksum, result.TargetHistory.Count, result.TargetHistory.Checksum)
+	}
+	result.TargetTrash, err = SummarizeTrash(target, options.BatchSize)
+	if err != nil {
+		return result, fmt.Errorf("unable to summarize target trash: %w", err)
+	}
+	log.Printf("target: %v trashed devices, checksum %v\n", result.TargetTrash.Count, result.TargetTrash.Checksum)
+	if result.SourceTrash != result.TargetTrash {
+		return result, fmt.Errorf("%w: source has %v trashed devices with checksum %v, target has %v trashed devices with checksum %v", ErrVerificationFailed, result.SourceTrash.Count, result.SourceTrash.Checksum, result.TargetTrash.Count, result.TargetTrash.Checksum)
+	}
+	log.Println("migration verified")
+
+	if options.CheckpointFile != "" {
+		//a completed migration is not resumed
+		err = os.Remove(options.CheckpointFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return result, err
+		}
+	}
+
+	if options.DeleteSource {
+		err = deleteAll(source.ReadDevicesAfter, source.RemoveDevices, options.BatchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to delete source devices: %w", err)
+		}
+		log.Printf("deleted %v devices from source\n", result.Source.Count)
+		err = deleteAllVersions(source, options.BatchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to delete source versions: %w", err)
+		}
+		log.Printf("deleted %v versions from source\n", result.SourceHistory.Count)
+		err = deleteAll(source.ReadTrashedDevicesAfter, source.RemoveTrashedDevices, options.BatchSize)
+		if err != nil {
+			return result, fmt.Errorf("unable to delete source trash: %w", err)
+		}
+		log.Printf("deleted %v trashed devices from source\n", result.SourceTrash.Count)
+	}
+	return result, nil
+}
+
+func progressVerb(dryRun bool) string {
+	if dryRun {
+		return "read"
+	}
+	return "migrated"
+}
+
+func logProgress(dryRun bool, done int64, total int64, start time.Time) {
+	percent := 100.0
+	if total > 0 {
+		percent = float64(done) / float64(total) * 100
+	}
+	log.Printf("%v %v/%v devices (%.1f%%) in %v\n", progressVerb(dryRun), done, total, percent, time.Since(start).Round(time.Second))
+}
+
+// Summarize counts all devices and combines their checksums order independent,
+// to be comparable between implementations with different orderings of local_id
+func Summarize(source Reader, batchSize int) (result Summary, err error) {
+	return summarize(source.ReadDevicesAfter, batchSize)
+}
+
+// SummarizeTrash counts all trashed devices and combines their checksums order independent, like Summarize
+func SummarizeTrash(source TrashReader, batchSize int) (result Summary, err error) {
+	return summarize(source.ReadTrashedDevicesAfter, batchSize)
+}
+
+func summarize(read func(lastLocalId string, limit int) ([]model.Device, error, int), batchSize int) (result Summary, err error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	checksum := [sha256.Size]byte{}
+	lastLocalId := ""
+	for {
+		batch, err, _ := read(lastLocalId, batchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, device := range batch {
+			deviceChecksum, err := DeviceChecksum(device)
+			if err != nil {
+				return result, err
+			}
+			//local_ids are unique, so no device can cancel out another
+			for i := range checksum {
+				checksum[i] ^= deviceChecksum[i]
+			}
+		}
+		result.Count += int64(len(batch))
+		lastLocalId = batch[len(batch)-1].LocalId
+	}
+	result.Checksum = hex.EncodeToString(checksum[:])
+	return result, nil
+}
+
+// SummarizeHistory counts all versions of all devices and combines their checksums order independent, like Summarize
+func SummarizeHistory(source HistoryReader, batchSize int) (result Summary, err error) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	checksum := [sha256.Size]byte{}
+	lastLocalId := ""
+	lastVersion := int64(0)
+	for {
+		batch, err, _ := source.ReadDeviceVersionsAfter(lastLocalId, lastVersion, batchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, version := range batch {
+			versionChecksum, err := VersionChecksum(version)
+			if err != nil {
+				return result, err
+			}
+			//local_id and version are unique, so no version can cancel out another
+			for i := range checksum {
+				checksum[i] ^= versionChecksum[i]
+			}
+		}
+		result.Count += int64(len(batch))
+		lastLocalId = batch[len(batch)-1].LocalId
+		lastVersion = batch[len(batch)-1].Version
+	}
+	result.Checksum = hex.EncodeToString(checksum[:])
+	return result, nil
+}
+
+// DeviceChecksum hashes the stored fields of a device, normalized to the precision every implementation can store
+func DeviceChecksum(device model.Device) (result [sha256.Size]byte, err error) {
+	buf, err := json.Marshal(normalize(device))
+	if err != nil {
+		return result, err
+	}
+	return sha256.Sum256(buf), nil
+}
+
+// VersionChecksum hashes the stored fields of a device version, normalized like DeviceChecksum
+func VersionChecksum(version model.DeviceVersion) (result [sha256.Size]byte, err error) {
+	version.Diff = nil
+	version.Time = version.Time.UTC().Truncate(time.Millisecond)
+	version.Device = normalize(version.Device)
+	buf, err := json.Marshal(version)
+	if err != nil {
+		return result, err
+	}
+	return sha256.Sum256(buf), nil
+}
+
+func normalize(device model.Device) model.Device {
+	if device.Attributes == nil {
+		device.Attributes = []models.Attribute{}
+	}
+	device.CreatedAt = device.CreatedAt.UTC().Truncate(time.Millisecond)
+	device.LastUpdate = device.LastUpdate.UTC().Truncate(time.Millisecond)
+	if device.DeletedAt != nil {
+		deletedAt := device.DeletedAt.UTC().Truncate(time.Millisecond)
+		device.DeletedAt = &deletedAt
+	}
+	return device
+}
+
+func deleteAll(read func(lastLocalId string, limit int) ([]model.Device, error, int), remove func(localIds []string) (error, int), batchSize int) error {
+	lastLocalId := ""
+	for {
+		batch, err, _ := read(lastLocalId, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		localIds := []string{}
+		for _, device := range batch {
+			localIds = append(localIds, device.LocalId)
+		}
+		err, _ = remove(localIds)
+		if err != nil {
+			return err
+		}
+		lastLocalId = batch[len(batch)-1].LocalId
+	}
+}
+
+func deleteAllVersions(source Source, batchSize int) error {
+	lastLocalId := ""
+	lastVersion := int64(0)
+	for {
+		batch, err, _ := source.ReadDeviceVersionsAfter(lastLocalId, lastVersion, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		localIds := []string{}
+		for _, version := range batch {
+			if len(localIds) == 0 || localIds[len(localIds)-1] != version.LocalId {
+				localIds = append(localIds, version.LocalId)
+			}
+		}
+		err, _ = source.RemoveDeviceVersions(localIds)
+		if err != nil {
+			return err
+		}
+		lastLocalId = batch[len(batch)-1].LocalId
+		lastVersion = batch[len(batch)-1].Version
+	}
+}
+
+func loadCheckpoint(location string) (result Checkpoint, err error) {
+	buf, err := os.ReadFile(location)
+	if errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("unable to read checkpoint: %w", err)
+	}
+	err = json.Unmarshal(buf, &result)
+	if err != nil {
+		return result, fmt.Errorf("invalid checkpoint %v: %w", location, err)
+	}
+	return result, nil
+}
+
+// storeCheckpoint replaces the checkpoint file atomically, so that an interruption never leaves a partial checkpoint
+func storeCheckpoint(location string, checkpoint Checkpoint) error {
+	buf, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(location), filepath.Base(location)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("unable to write checkpoint: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.Write(buf)
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), location)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,401 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migration
+
+import (
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	devices           map[string]model.Device
+	versions          map[versionKey]model.DeviceVersion
+	trash             map[string]model.Device
+	failAfter         int //fail SetDevices after this many successful calls, if > 0
+	setCalls          int
+	failVersionsAfter int //fail SetDeviceVersions after this many successful calls, if > 0
+	setVersionsCalls  int
+}
+
+type versionKey struct {
+	localId string
+	version int64
+}
+
+// newMemoryStore creates count devices with two versions each and count/5 trashed devices
+func newMemoryStore(count int) *memoryStore {
+	result := &memoryStore{devices: map[string]model.Device{}, versions: map[versionKey]model.DeviceVersion{}, trash: map[string]model.Device{}}
+	now := time.Now()
+	for i := 0; i < count; i++ {
+		localId := "d" + strconv.Itoa(i)
+		result.devices[localId] = model.Device{
+			Device: models.Device{
+				LocalId:    localId,
+				Name:       "device " + strconv.Itoa(i),
+				Attributes: []models.Attribute{{Key: "index", Value: strconv.Itoa(i)}},
+			},
+			UserId:     "user" + strconv.Itoa(i%3),
+			CreatedAt:  now,
+			LastUpdate: now,
+		}
+		for version := int64(1); version <= 2; version++ {
+			result.versions[versionKey{localId: localId, version: version}] = model.DeviceVersion{
+				LocalId: localId,
+				Version: version,
+				Change:  model.ChangeSet,
+				Time:    now,
+				Device:  result.devices[localId],
+			}
+		}
+	}
+	for i := 0; i < count/5; i++ {
+		localId := "t" + strconv.Itoa(i)
+		result.trash[localId] = model.Device{
+			Device:     models.Device{LocalId: localId, Name: "trashed " + strconv.Itoa(i)},
+			UserId:     "user" + strconv.Itoa(i%3),
+			CreatedAt:  now,
+			LastUpdate: now,
+			DeletedAt:  &now,
+		}
+	}
+	return result
+}
+
+func (this *memoryStore) ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	localIds := []string{}
+	for localId := range this.devices {
+		if localId > lastLocalId {
+			localIds = append(localIds, localId)
+		}
+	}
+	sort.Strings(localIds)
+	for i := 0; i < len(localIds) && i < limit; i++ {
+		result = append(result, this.devices[localIds[i]])
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *memoryStore) CountAllDevices() (count int64, err error, errCode int) {
+	return int64(len(this.devices)), nil, http.StatusOK
+}
+
+func (this *memoryStore) RemoveDevices(localIds []string) (error, int) {
+	for _, localId := range localIds {
+		delete(this.devices, localId)
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) SetDevices(devices []model.Device) (error, int) {
+	if this.failAfter > 0 && this.setCalls >= this.failAfter {
+		return errors.New("test error"), http.StatusInternalServerError
+	}
+	this.setCalls++
+	for _, device := range devices {
+		//simulates an implementation storing timestamps with lower precision
+		device.CreatedAt = device.CreatedAt.Truncate(time.Millisecond)
+		device.LastUpdate = device.LastUpdate.Truncate(time.Millisecond)
+		this.devices[device.LocalId] = device
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) ReadDeviceVersionsAfter(lastLocalId string, lastVersion int64, limit int) (result []model.DeviceVersion, err error, errCode int) {
+	keys := []versionKey{}
+	for key := range this.versions {
+		if key.localId > lastLocalId || (key.localId == lastLocalId && key.version > lastVersion) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].localId != keys[j].localId {
+			return keys[i].localId < keys[j].localId
+		}
+		return keys[i].version < keys[j].version
+	})
+	for i := 0; i < len(keys) && i < limit; i++ {
+		result = append(result, this.versions[keys[i]])
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *memoryStore) SetDeviceVersions(versions []model.DeviceVersion) (error, int) {
+	if this.failVersionsAfter > 0 && this.setVersionsCalls >= this.failVersionsAfter {
+		return errors.New("test error"), http.StatusInternalServerError
+	}
+	this.setVersionsCalls++
+	for _, version := range versions {
+		version.Time = version.Time.Truncate(time.Millisecond)
+		this.versions[versionKey{localId: version.LocalId, version: version.Version}] = version
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) RemoveDeviceVersions(localIds []string) (error, int) {
+	for _, localId := range localIds {
+		for key := range this.versions {
+			if key.localId == localId {
+				delete(this.versions, key)
+			}
+		}
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) ReadTrashedDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	localIds := []string{}
+	for localId := range this.trash {
+		if localId > lastLocalId {
+			localIds = append(localIds, localId)
+		}
+	}
+	sort.Strings(localIds)
+	for i := 0; i < len(localIds) && i < limit; i++ {
+		result = append(result, this.trash[localIds[i]])
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *memoryStore) SetTrashedDevices(devices []model.Device) (error, int) {
+	for _, device := range devices {
+		deletedAt := device.DeletedAt.Truncate(time.Millisecond)
+		device.DeletedAt = &deletedAt
+		this.trash[device.LocalId] = device
+	}
+	return nil, http.StatusOK
+}
+
+func (this *memoryStore) RemoveTrashedDevices(localIds []string) (error, int) {
+	for _, localId := range localIds {
+		delete(this.trash, localId)
+	}
+	return nil, http.StatusOK
+}
+
+func TestRun(t *testing.T) {
+	source := newMemoryStore(105)
+	target := newMemoryStore(0)
+	result, err := Run(source, target, Options{BatchSize: 10})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Migrated != 105 || len(target.devices) != 105 || target.setCalls != 11 {
+		t.Error(result, len(target.devices), target.setCalls)
+	}
+	if result.Source != result.Target || result.Source.Count != 105 {
+		t.Error(result)
+	}
+	if result.MigratedVersions != 210 || len(target.versions) != 210 || target.setVersionsCalls != 21 {
+		t.Error(result, len(target.versions), target.setVersionsCalls)
+	}
+	if result.SourceHistory != result.TargetHistory || result.SourceHistory.Count != 210 {
+		t.Error(result)
+	}
+	if result.MigratedTrash != 21 || len(target.trash) != 21 || result.SourceTrash != result.TargetTrash || result.SourceTrash.Count != 21 {
+		t.Error(result, len(target.trash))
+	}
+	if len(source.devices) != 105 || len(source.versions) != 210 || len(source.trash) != 21 {
+		t.Error(len(source.devices), len(source.versions), len(source.trash))
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	source := newMemoryStore(25)
+	target := newMemoryStore(0)
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+	result, err := Run(source, target, Options{BatchSize: 10, DryRun: true, DeleteSource: true, CheckpointFile: checkpoint})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Migrated != 25 || result.Source.Count != 25 || result.MigratedVersions != 50 || result.SourceHistory.Count != 50 {
+		t.Error(result)
+	}
+	if len(target.devices) != 0 || len(source.devices) != 25 || len(target.versions) != 0 || len(source.versions) != 50 {
+		t.Error(len(target.devices), len(source.devices), len(target.versions), len(source.versions))
+	}
+	if result.MigratedTrash != 5 || len(target.trash) != 0 || len(source.trash) != 5 {
+		t.Error(result, len(target.trash), len(source.trash))
+	}
+	if _, err = os.Stat(checkpoint); !errors.Is(err, os.ErrNotExist) {
+		t.Error("dry run should not write a checkpoint", err)
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	source := newMemoryStore(45)
+	target := newMemoryStore(0)
+	target.failAfter = 2
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+	_, err := Run(source, target, Options{BatchSize: 10, CheckpointFile: checkpoint})
+	if err == nil {
+		t.Error("expected error")
+		return
+	}
+	stored, err := loadCheckpoint(checkpoint)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if stored.Migrated != 20 || stored.LastLocalId != "d26" {
+		t.Error(stored)
+		return
+	}
+
+	target.failAfter = 0
+	result, err := Run(source, target, Options{BatchSize: 10, CheckpointFile: checkpoint})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Migrated != 25 || len(target.devices) != 45 || target.setCalls != 5 {
+		t.Error(result, len(target.devices), target.setCalls)
+	}
+	if _, err = os.Stat(checkpoint); !errors.Is(err, os.ErrNotExist) {
+		t.Error("completed migration should remove the checkpoint", err)
+	}
+}
+
+func TestRunResumeHistory(t *testing.T) {
+	source := newMemoryStore(15)
+	target := newMemoryStore(0)
+	target.failVersionsAfter = 1
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+	_, err := Run(source, target, Options{BatchSize: 10, CheckpointFile: checkpoint})
+	if err == nil {
+		t.Error("expected error")
+		return
+	}
+	stored, err := loadCheckpoint(checkpoint)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if stored.Migrated != 15 || stored.MigratedVersions != 10 || stored.LastVersionLocalId != "d12" || stored.LastVersion != 2 {
+		t.Error(stored)
+		return
+	}
+
+	target.failVersionsAfter = 0
+	result, err := Run(source, target, Options{BatchSize: 10, CheckpointFile: checkpoint})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if result.Migrated != 0 || result.MigratedVersions != 20 || len(target.versions) != 30 || target.setVersionsCalls != 3 {
+		t.Error(result, len(target.versions), target.setVersionsCalls)
+	}
+}
+
+func TestRunDeleteSource(t *testing.T) {
+	source := newMemoryStore(35)
+	target := newMemoryStore(0)
+	_, err := Run(source, target, Options{BatchSize: 10, DeleteSource: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(source.devices) != 0 || len(target.devices) != 35 {
+		t.Error(len(source.devices), len(target.devices))
+	}
+	if len(source.versions) != 0 || len(target.versions) != 70 {
+		t.Error(len(source.versions), len(target.versions))
+	}
+	if len(source.trash) != 0 || len(target.trash) != 7 {
+		t.Error(len(source.trash), len(target.trash))
+	}
+}
+
+func TestRunVerificationFailed(t *testing.T) {
+	source := newMemoryStore(15)
+	target := newMemoryStore(0)
+	target.devices["other"] = model.Device{Device: models.Device{LocalId: "other"}}
+	_, err := Run(source, target, Options{BatchSize: 10, DeleteSource: true})
+	if !errors.Is(err, ErrVerificationFailed) {
+		t.Error(err)
+		return
+	}
+	if len(source.devices) != 15 {
+		t.Error("source should not be deleted after a failed verification", len(source.devices))
+	}
+
+	source = newMemoryStore(15)
+	target = newMemoryStore(0)
+	target.versions[versionKey{localId: "other", version: 1}] = model.DeviceVersion{LocalId: "other", Version: 1}
+	_, err = Run(source, target, Options{BatchSize: 10, DeleteSource: true})
+	if !errors.Is(err, ErrVerificationFailed) {
+		t.Error(err)
+		return
+	}
+	if len(source.versions) != 30 {
+		t.Error("source history should not be deleted after a failed verification", len(source.versions))
+	}
+
+	source = newMemoryStore(15)
+	target = newMemoryStore(0)
+	target.trash["other"] = model.Device{Device: models.Device{LocalId: "other"}}
+	_, err = Run(source, target, Options{BatchSize: 10, DeleteSource: true})
+	if !errors.Is(err, ErrVerificationFailed) {
+		t.Error(err)
+		return
+	}
+	if len(source.trash) != 3 {
+		t.Error("source trash should not be deleted after a failed verification", len(source.trash))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	a := newMemoryStore(30)
+	b := newMemoryStore(0)
+	for localId, device := range a.devices {
+		device.Attributes = append([]models.Attribute{}, device.Attributes...)
+		b.devices[localId] = device
+	}
+	summaryA, err := Summarize(a, 7)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	summaryB, err := Summarize(b, 11)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if summaryA != summaryB {
+		t.Error(summaryA, summaryB)
+	}
+	device := b.devices["d3"]
+	device.Name = "changed"
+	b.devices["d3"] = device
+	summaryB, err = Summarize(b, 11)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if summaryA.Count != summaryB.Count || summaryA.Checksum == summaryB.Checksum {
+		t.Error(summaryA, summaryB)
+	}
+}
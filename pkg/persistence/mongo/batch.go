@@ -0,0 +1,234 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"net/http"
+)
+
+// ReadDevicesAfter returns up to limit devices of all users with a local_id greater than lastLocalId, ordered by local_id
+func (this *Mongo) ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	ctx, _ := getTimeoutContext(context.Background())
+	cursor, err := this.deviceCollection().Find(ctx,
+		bson.M{deviceLocalIdKey: bson.M{"$gt": lastLocalId}},
+		options.Find().SetSort(bson.D{{Key: deviceLocalIdKey, Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		element := model.Device{}
+		err = cursor.Decode(&element)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		result = append(result, element)
+	}
+	err = cursor.Err()
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// SetDevices stores all devices with a single bulk write
+func (this *Mongo) SetDevices(devices []model.Device) (error, int) {
+	if len(devices) == 0 {
+		return nil, http.StatusOK
+	}
+	writes := []mongo.WriteModel{}
+	for _, device := range devices {
+		device.SearchTokens = search.DeviceText(device)
+		device.SearchNgrams = search.Ngrams(search.DeviceTokens(device))
+		writes = append(writes, mongo.NewReplaceOneModel().SetFilter(bson.M{deviceLocalIdKey: device.LocalId}).SetReplacement(device).SetUpsert(true))
+	}
+	ctx, _ := getTimeoutContext(context.Background())
+	_, err := this.deviceCollection().BulkWrite(ctx, writes)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+func (this *Mongo) RemoveDevices(localIds []string) (error, int) {
+	ctx, _ := getTimeoutContext(context.Background())
+	_, err := this.deviceCollection().DeleteMany(ctx, bson.M{deviceLocalIdKey: bson.M{"$in": localIds}})
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// ReadDeviceVersionsAfter returns up to limit versions of all users, which follow lastLocalId and lastVersion in the order of local_id and version
+func (this *Mongo) ReadDeviceVersionsAfter(lastLocalId string, lastVersion int64, limit int) (result []model.DeviceVersion, err error, errCode int) {
+	ctx, _ := getTimeoutContext(context.Background())
+	cursor, err := this.historyCollection().Find(ctx,
+		bson.M{"$or": bson.A{
+			bson.M{versionLocalIdKey: bson.M{"$gt": lastLocalId}},
+			bson.M{versionLocalIdKey: lastLocalId, versionVersionKey: bson.M{"$gt": lastVersion}},
+		}},
+		options.Find().SetSort(bson.D{{Key: versionLocalIdKey, Value: 1}, {Key: versionVersionKey, Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		element := model.DeviceVersion{}
+		err = cursor.Decode(&element)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		result = append(result, element)
+	}
+	err = cursor.Err()
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// SetDeviceVersions stores all versions with their version numbers with a single bulk write
+func (this *Mongo) SetDeviceVersions(versions []model.DeviceVersion) (error, int) {
+	if len(versions) == 0 {
+		return nil, http.StatusOK
+	}
+	writes := []mongo.WriteModel{}
+	for _, version := range versions {
+		version.Diff = nil
+		writes = append(writes, mongo.NewReplaceOneModel().SetFilter(bson.M{versionLocalIdKey: version.LocalId, versionVersionKey: version.Version}).SetReplacement(version).SetUpsert(true))
+	}
+	ctx, _ := getTimeoutContext(context.Background())
+	_, err := this.historyCollection().BulkWrite(ctx, writes)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// RemoveDeviceVersions removes all versions of the local_ids
+func (this *Mongo) RemoveDeviceVersions(localIds []string) (error, int) {
+	ctx, _ := getTimeoutContext(context.Background())
+	_, err := this.historyCollection().DeleteMany(ctx, bson.M{versionLocalIdKey: bson.M{"$in": localIds}})
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// ReadTrashedDevicesAfter returns up to limit trashed devices of all users with a local_id greater than lastLocalId, ordered by local_id
+func (this *Mongo) ReadTrashedDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	ctx, _ := getTimeoutContext(context.Background())
+	cursor, err := this.trashCollection().Find(ctx,
+		bson.M{deviceLocalIdKey: bson.M{"$gt": lastLocalId}},
+		options.Find().SetSort(bson.D{{Key: deviceLocalIdKey, Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		element := model.Device{}
+		err = cursor.Decode(&element)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		result = append(result, element)
+	}
+	err = cursor.Err()
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// SetTrashedDevices stores all devices in the trash with a single bulk write, each device needs its deleted_at
+func (this *Mongo) SetTrashedDevices(devices []model.Device) (error, int) {
+	if len(devices) == 0 {
+		return nil, http.StatusOK
+	}
+	writes := []mongo.WriteModel{}
+	for _, device := range devices {
+		if device.DeletedAt == nil {
+			return fmt.Errorf("trashed device %v without deleted_at", device.LocalId), http.StatusBadRequest
+		}
+		writes = append(writes, mongo.NewReplaceOneModel().SetFilter(bson.M{deviceLocalIdKey: device.LocalId}).SetReplacement(device).SetUpsert(true))
+	}
+	ctx, _ := getTimeoutContext(context.Background())
+	_, err := this.trashCollection().BulkWrite(ctx, writes)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+func (this *Mongo) RemoveTrashedDevices(localIds []string) (error, int) {
+	ctx, _ := getTimeoutContext(context.Background())
+	_, err := this.trashCollection().DeleteMany(ctx, bson.M{deviceLocalIdKey: bson.M{"$in": localIds}})
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// CountAllDevices returns the number of devices of all users
+func (this *Mongo) CountAllDevices() (count int64, err error, errCode int) {
+	ctx, _ := getTimeoutContext(context.Background())
+	count, err = this.deviceCollection().CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return count, err, http.StatusInternalServerError
+	}
+	return count, nil, http.StatusOK
+}
+
+// CountDevicesByState returns the number of devices of all users per state
+func (this *Mongo) CountDevicesByState() (result map[string]int64, err error, errCode int) {
+	result = model.DeviceStates()
+	ctx, _ := getTimeoutContext(context.Background())
+	cursor, err := this.deviceCollection().Aggregate(ctx, bson.A{
+		bson.M{"$group": bson.M{"_id": "$" + deviceHiddenKey, "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		element := struct {
+			Hidden bool  `bson:"_id"`
+			Count  int64 `bson:"count"`
+		}{}
+		err = cursor.Decode(&element)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		if element.Hidden {
+			result[model.DeviceStateHidden] += element.Count
+		} else {
+			result[model.DeviceStateVisible] += element.Count
+		}
+	}
+	err = cursor.Err()
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
@@ -1,33 +1,54 @@
 package mongo
 
 import (
+	"context"
+	"errors"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	persistencoptions "github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/query"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/search"
+	"github.com/SENERGY-Platform/models/go/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const deviceLocalIdFieldName = "Device.LocalId"
 const deviceNameIdFieldName = "Device.Name"
+const deviceIdFieldName = "Device.Id"
 const deviceUserIdFieldName = "UserId"
 const deviceHiddenFieldName = "Hidden"
 const deviceCreatedAtFieldName = "CreatedAt"
 const deviceUpdatedAtFieldName = "LastUpdate"
+const deviceDeviceTypeIdFieldName = "Device.DeviceTypeId"
+const deviceAttributesFieldName = "Device.Attributes"
+const attributeKeyFieldName = "Key"
+const attributeValueFieldName = "Value"
 
 const deviceSearchTokensFieldName = "SearchTokens"
+const deviceSearchNgramsFieldName = "SearchNgrams"
+
+const relevanceFieldName = "_relevance"
 
 var deviceLocalIdKey string
 var deviceNameKey string
+var deviceIdKey string
 var deviceUserIdKey string
 var deviceHiddenKey string
 var deviceCreatedAtKey string
 var deviceUpdatedAtKey string
+var deviceDeviceTypeIdKey string
+var deviceAttributesKey string
+var attributeKeyKey string
+var attributeValueKey string
 
 var deviceSearchTokensKey string
+var deviceSearchNgramsKey string
 
 func init() {
 	var err error
@@ -39,6 +60,10 @@ func init() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	deviceIdKey, err = getBsonFieldPath(model.Device{}, deviceIdFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
 	deviceUserIdKey, err = getBsonFieldName(model.Device{}, deviceUserIdFieldName)
 	if err != nil {
 		log.Fatal(err)
@@ -59,58 +84,163 @@ func init() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	deviceSearchNgramsKey, err = getBsonFieldPath(model.Device{}, deviceSearchNgramsFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	deviceDeviceTypeIdKey, err = getBsonFieldPath(model.Device{}, deviceDeviceTypeIdFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	deviceAttributesKey, err = getBsonFieldPath(model.Device{}, deviceAttributesFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	attributeKeyKey, err = getBsonFieldName(models.Attribute{}, attributeKeyFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	attributeValueKey, err = getBsonFieldName(models.Attribute{}, attributeValueFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	CreateCollections = append(CreateCollections, func(db *Mongo) error {
-		collection := db.db.Database(db.config.MongoTable).Collection(db.config.MongoDeviceCollection)
-		err = db.ensureIndex(collection, "devicelocalidindex", deviceLocalIdKey, true, false)
-		if err != nil {
-			return err
-		}
-		err = db.ensureIndex(collection, "devicenameindex", deviceNameKey, true, false)
+	Migrations = append(Migrations,
+		schema.Migration[*Mongo]{Version: 1, Description: "create device indexes", Up: createDeviceIndexes},
+		schema.Migration[*Mongo]{Version: 2, Description: "replace text index by search n-grams", Up: addSearchNgrams},
+		schema.Migration[*Mongo]{Version: 3, Description: "add filter indexes", Up: addDeviceFilterIndexes},
+	)
+}
+
+func createDeviceIndexes(db *Mongo) error {
+	collection := db.deviceCollection()
+	err := db.ensureIndex(collection, "devicelocalidindex", deviceLocalIdKey, true, false)
+	if err != nil {
+		return err
+	}
+	err = db.ensureIndex(collection, "devicenameindex", deviceNameKey, true, false)
+	if err != nil {
+		return err
+	}
+	err = db.ensureIndex(collection, "devicecreatedatindex", deviceCreatedAtKey, true, false)
+	if err != nil {
+		return err
+	}
+	return db.ensureIndex(collection, "deviceupdatedatindex", deviceUpdatedAtKey, true, false)
+}
+
+func addSearchNgrams(db *Mongo) error {
+	collection := db.deviceCollection()
+	err := db.dropIndexIfExists(collection, "devicesearchindex")
+	if err != nil {
+		return err
+	}
+	err = db.ensureIndex(collection, "devicesearchngramsindex", deviceSearchNgramsKey, true, false)
+	if err != nil {
+		return err
+	}
+	return db.fillSearchNgrams()
+}
+
+func addDeviceFilterIndexes(db *Mongo) error {
+	collection := db.deviceCollection()
+	err := db.ensureIndex(collection, "devicedevicetypeidindex", deviceDeviceTypeIdKey, true, false)
+	if err != nil {
+		return err
+	}
+	return db.ensureCompoundIndex(collection, "deviceattributesindex", true, false, deviceAttributesKey+"."+attributeKeyKey, deviceAttributesKey+"."+attributeValueKey)
+}
+
+// fillSearchNgrams sets the search fields of devices stored before search n-grams existed
+func (this *Mongo) fillSearchNgrams() error {
+	for {
+		ctx, _ := getTimeoutContext(context.Background())
+		cursor, err := this.deviceCollection().Find(ctx, bson.M{deviceSearchNgramsKey: bson.M{"$exists": false}}, options.Find().SetLimit(1000))
 		if err != nil {
 			return err
 		}
-		err = db.ensureIndex(collection, "devicecreatedatindex", deviceCreatedAtKey, true, false)
+		devices := []model.Device{}
+		err = cursor.All(ctx, &devices)
 		if err != nil {
 			return err
 		}
-		err = db.ensureIndex(collection, "deviceupdatedatindex", deviceUpdatedAtKey, true, false)
-		if err != nil {
-			return err
+		if len(devices) == 0 {
+			return nil
 		}
-		err = db.ensureTextIndex(collection, "devicesearchindex", deviceSearchTokensKey)
-		if err != nil {
-			return err
+		for _, device := range devices {
+			err, _ = this.SetDevice(context.Background(), device)
+			if err != nil {
+				return err
+			}
 		}
-		return nil
-	})
+	}
 }
 
 func (this *Mongo) deviceCollection() *mongo.Collection {
 	return this.db.Database(this.config.MongoTable).Collection(this.config.MongoDeviceCollection)
 }
 
-func (this *Mongo) MigrateTo(target persistencoptions.MigrationTarget) error {
-	cursor, err := this.deviceCollection().Find(nil, bson.D{})
+func (this *Mongo) ListDevices(ctx context.Context, userId string, o persistencoptions.List) (result []model.Device, total int64, err error, errCode int) {
+	result = []model.Device{}
+	filter, err := getDeviceFilter(userId, o)
+	if err != nil {
+		return result, total, err, http.StatusBadRequest
+	}
+
+	ctx, _ = getTimeoutContext(ctx)
+	total, err = this.deviceCollection().CountDocuments(ctx, filter)
 	if err != nil {
-		return err
+		return result, total, err, http.StatusInternalServerError
+	}
+	cursor, err := this.findDevices(ctx, filter, o)
+	if err != nil {
+		return result, total, err, http.StatusInternalServerError
 	}
-	for cursor.Next(nil) {
+	for cursor.Next(ctx) {
 		element := model.Device{}
 		err = cursor.Decode(&element)
 		if err != nil {
-			return err
+			return result, total, err, http.StatusInternalServerError
 		}
-		err, _ = target.SetDevice(element)
+		result = append(result, element)
+	}
+	err = cursor.Err()
+	return
+}
+
+// ExportDevices calls handler for every device matching the options, ignoring limit and offset.
+// The devices are read with a cursor, so they are never held in memory all at once.
+func (this *Mongo) ExportDevices(ctx context.Context, userId string, o persistencoptions.List, handler func(device model.Device) error) (err error, errCode int) {
+	filter, err := getDeviceFilter(userId, o)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	o.Limit, o.Offset = 0, 0
+	cursor, err := this.findDevices(ctx, filter, o)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		element := model.Device{}
+		err = cursor.Decode(&element)
 		if err != nil {
-			return err
+			return err, http.StatusInternalServerError
+		}
+		err = handler(element)
+		if err != nil {
+			return err, http.StatusInternalServerError
 		}
 	}
-	return cursor.Err()
+	err = cursor.Err()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
 }
 
-func (this *Mongo) ListDevices(userId string, o persistencoptions.List) (result []model.Device, total int64, err error, errCode int) {
-	result = []model.Device{}
+// findDevices returns a cursor over the devices matching filter, sorted, skipped and limited as described by o; a limit of 0 is unlimited
+func (this *Mongo) findDevices(ctx context.Context, filter bson.M, o persistencoptions.List) (*mongo.Cursor, error) {
 	opt := options.Find()
 	opt.SetLimit(int64(o.Limit))
 	opt.SetSkip(int64(o.Offset))
@@ -126,6 +256,8 @@ func (this *Mongo) ListDevices(userId string, o persistencoptions.List) (result
 		sortby = deviceCreatedAtKey
 	case "updated_at":
 		sortby = deviceUpdatedAtKey
+	case "relevance":
+		sortby = deviceLocalIdKey //used if no search terms are given
 	}
 	direction := int32(1)
 	if len(parts) > 1 && parts[1] == "desc" {
@@ -133,39 +265,100 @@ func (this *Mongo) ListDevices(userId string, o persistencoptions.List) (result
 	}
 	opt.SetSort(bson.D{{sortby, direction}})
 
-	filter := bson.M{deviceUserIdKey: userId}
-	if !o.ShowHidden {
-		filter[deviceHiddenKey] = false
-	}
-	if o.Search != "" {
-		filter["$text"] = bson.M{"$search": o.Search}
-	}
-
-	ctx, _ := getTimeoutContext()
 	collection := this.deviceCollection()
+	searchQuery, _ := o.SearchQuery() //already validated by getDeviceFilter
+	if terms := query.TextTerms(searchQuery); parts[0] == "relevance" && len(terms) > 0 {
+		pipeline := bson.A{
+			bson.M{"$match": filter},
+			bson.M{"$addFields": bson.M{relevanceFieldName: getRelevanceExpression(terms)}},
+			bson.M{"$sort": bson.D{{Key: relevanceFieldName, Value: -1}, {Key: deviceLocalIdKey, Value: 1}}},
+			bson.M{"$skip": int64(o.Offset)},
+		}
+		if o.Limit > 0 {
+			pipeline = append(pipeline, bson.M{"$limit": int64(o.Limit)})
+		}
+		return collection.Aggregate(ctx, pipeline)
+	}
+	return collection.Find(ctx, filter, opt)
+}
 
-	total, err = collection.CountDocuments(ctx, filter)
+func getDeviceFilter(userId string, o persistencoptions.List) (filter bson.M, err error) {
+	filter = bson.M{deviceUserIdKey: userId}
+	searchQuery, err := o.SearchQuery()
 	if err != nil {
-		return result, total, err, http.StatusInternalServerError
+		return filter, err
 	}
-	cursor, err := collection.Find(ctx, filter, opt)
-	if err != nil {
-		return result, total, err, http.StatusInternalServerError
+	if o.HideHidden(searchQuery) {
+		filter[deviceHiddenKey] = false
 	}
-	for cursor.Next(ctx) {
-		element := model.Device{}
-		err = cursor.Decode(&element)
+	and := bson.A{}
+	if searchQuery != nil {
+		condition, err := compileQuery(searchQuery)
 		if err != nil {
-			return result, total, err, http.StatusInternalServerError
+			return filter, err
 		}
-		result = append(result, element)
+		and = append(and, condition)
 	}
-	err = cursor.Err()
-	return
+	for _, attrFilter := range o.AttributeFilter {
+		switch attrFilter.Operation {
+		case persistencoptions.AttributeEquals:
+			and = append(and, bson.M{deviceAttributesKey: bson.M{"$elemMatch": bson.M{attributeKeyKey: attrFilter.Key, attributeValueKey: attrFilter.Value}}})
+		case persistencoptions.AttributeExists:
+			and = append(and, bson.M{deviceAttributesKey + "." + attributeKeyKey: attrFilter.Key})
+		default:
+			return filter, errors.New("unknown attribute filter operation")
+		}
+	}
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+	if len(o.DeviceTypeIds) > 0 {
+		filter[deviceDeviceTypeIdKey] = bson.M{"$in": o.DeviceTypeIds}
+	}
+	if timeRange := getTimeRangeFilter(o.CreatedAfter, o.CreatedBefore); timeRange != nil {
+		filter[deviceCreatedAtKey] = timeRange
+	}
+	if timeRange := getTimeRangeFilter(o.UpdatedAfter, o.UpdatedBefore); timeRange != nil {
+		filter[deviceUpdatedAtKey] = timeRange
+	}
+	return filter, nil
+}
+
+// getRelevanceExpression ranks devices by the number of search terms matching a complete token (3), the start of a token (2) or another part of a token (1)
+func getRelevanceExpression(terms []string) bson.M {
+	tokens := bson.M{"$split": bson.A{bson.M{"$ifNull": bson.A{"$" + deviceSearchTokensKey, ""}}, " "}}
+	scores := bson.A{}
+	for _, term := range terms {
+		isPrefix := bson.M{"$anyElementTrue": bson.A{bson.M{"$map": bson.M{
+			"input": tokens,
+			"as":    "token",
+			"in":    bson.M{"$eq": bson.A{bson.M{"$indexOfCP": bson.A{"$$token", term}}, 0}},
+		}}}}
+		scores = append(scores, bson.M{"$cond": bson.A{
+			bson.M{"$in": bson.A{term, tokens}},
+			3,
+			bson.M{"$cond": bson.A{isPrefix, 2, 1}},
+		}})
+	}
+	return bson.M{"$add": scores}
 }
 
-func (this *Mongo) ReadDevice(localId string) (result model.Device, err error, errCode int) {
-	ctx, _ := getTimeoutContext()
+func getTimeRangeFilter(after time.Time, before time.Time) bson.M {
+	if after.IsZero() && before.IsZero() {
+		return nil
+	}
+	result := bson.M{}
+	if !after.IsZero() {
+		result["$gte"] = after
+	}
+	if !before.IsZero() {
+		result["$lt"] = before
+	}
+	return result
+}
+
+func (this *Mongo) ReadDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	ctx, _ = getTimeoutContext(ctx)
 	temp := this.deviceCollection().FindOne(
 		ctx,
 		bson.M{
@@ -188,9 +381,10 @@ func (this *Mongo) ReadDevice(localId string) (result model.Device, err error, e
 	return result, nil, http.StatusOK
 }
 
-func (this *Mongo) SetDevice(device model.Device) (error, int) {
-	device.SearchTokens = this.getSearchTokens(device)
-	ctx, _ := getTimeoutContext()
+func (this *Mongo) SetDevice(ctx context.Context, device model.Device) (error, int) {
+	device.SearchTokens = search.DeviceText(device)
+	device.SearchNgrams = search.Ngrams(search.DeviceTokens(device))
+	ctx, _ = getTimeoutContext(ctx)
 	_, err := this.deviceCollection().ReplaceOne(
 		ctx,
 		bson.M{
@@ -204,8 +398,28 @@ func (this *Mongo) SetDevice(device model.Device) (error, int) {
 	return nil, http.StatusOK
 }
 
-func (this *Mongo) RemoveDevice(localId string) (error, int) {
-	ctx, _ := getTimeoutContext()
+func (this *Mongo) SetDeviceIfUnchanged(ctx context.Context, device model.Device, lastUpdate time.Time) (error, int) {
+	device.SearchTokens = search.DeviceText(device)
+	device.SearchNgrams = search.Ngrams(search.DeviceTokens(device))
+	ctx, _ = getTimeoutContext(ctx)
+	result, err := this.deviceCollection().ReplaceOne(
+		ctx,
+		bson.M{
+			deviceLocalIdKey:   device.LocalId,
+			deviceUpdatedAtKey: lastUpdate,
+		},
+		device)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("device has been changed concurrently"), http.StatusConflict
+	}
+	return nil, http.StatusOK
+}
+
+func (this *Mongo) RemoveDevice(ctx context.Context, localId string) (error, int) {
+	ctx, _ = getTimeoutContext(ctx)
 	_, err := this.deviceCollection().DeleteMany(
 		ctx,
 		bson.M{
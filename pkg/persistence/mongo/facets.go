@@ -0,0 +1,134 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	persistencoptions "github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"go.mongodb.org/mongo-driver/bson"
+	"net/http"
+	"strconv"
+)
+
+const deviceTypeIdFacetName = "device_type_id"
+const hiddenFacetName = "hidden"
+const attributeFacetPrefix = "attribute_"
+
+type facetCountElement struct {
+	Value interface{} `bson:"_id"`
+	Count int64       `bson:"count"`
+}
+
+func (this *Mongo) CountFacets(ctx context.Context, userId string, o persistencoptions.List) (result model.DeviceFacets, err error, errCode int) {
+	filter, err := getDeviceFilter(userId, o)
+	if err != nil {
+		return result, err, http.StatusBadRequest
+	}
+	facets := bson.M{}
+	if o.Facets.DeviceTypeId {
+		facets[deviceTypeIdFacetName] = bson.A{
+			bson.M{"$group": bson.M{"_id": "$" + deviceDeviceTypeIdKey, "count": bson.M{"$sum": 1}}},
+			facetSortStage(),
+		}
+	}
+	for i, key := range o.Facets.AttributeKeys {
+		facets[attributeFacetPrefix+strconv.Itoa(i)] = bson.A{
+			bson.M{"$unwind": "$" + deviceAttributesKey},
+			bson.M{"$match": bson.M{deviceAttributesKey + "." + attributeKeyKey: key}},
+			//count every device once, even if it has multiple attributes with the same key and value
+			bson.M{"$group": bson.M{"_id": bson.M{"value": "$" + deviceAttributesKey + "." + attributeValueKey, "device": "$_id"}}},
+			bson.M{"$group": bson.M{"_id": "$_id.value", "count": bson.M{"$sum": 1}}},
+			facetSortStage(),
+		}
+	}
+	if len(facets) > 0 {
+		facetResults, err := this.aggregateFacets(ctx, bson.A{bson.M{"$match": filter}, bson.M{"$facet": facets}})
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		if o.Facets.DeviceTypeId {
+			result.DeviceTypeId = toFacetCounts(facetResults[deviceTypeIdFacetName])
+		}
+		for i, key := range o.Facets.AttributeKeys {
+			if result.Attributes == nil {
+				result.Attributes = map[string][]model.FacetCount{}
+			}
+			result.Attributes[key] = toFacetCounts(facetResults[attributeFacetPrefix+strconv.Itoa(i)])
+		}
+	}
+	if o.Facets.Hidden {
+		withHidden := o
+		withHidden.ShowHidden = true
+		hiddenFilter, err := getDeviceFilter(userId, withHidden)
+		if err != nil {
+			return result, err, http.StatusBadRequest
+		}
+		facetResults, err := this.aggregateFacets(ctx, bson.A{
+			bson.M{"$match": hiddenFilter},
+			bson.M{"$facet": bson.M{hiddenFacetName: bson.A{
+				bson.M{"$group": bson.M{"_id": "$" + deviceHiddenKey, "count": bson.M{"$sum": 1}}},
+				facetSortStage(),
+			}}},
+		})
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		result.Hidden = toFacetCounts(facetResults[hiddenFacetName])
+	}
+	return result, nil, http.StatusOK
+}
+
+func facetSortStage() bson.M {
+	return bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}
+}
+
+func (this *Mongo) aggregateFacets(ctx context.Context, pipeline bson.A) (result map[string][]facetCountElement, err error) {
+	ctx, _ = getTimeoutContext(ctx)
+	cursor, err := this.deviceCollection().Aggregate(ctx, pipeline)
+	if err != nil {
+		return result, err
+	}
+	temp := []map[string][]facetCountElement{}
+	err = cursor.All(ctx, &temp)
+	if err != nil {
+		return result, err
+	}
+	if len(temp) == 0 {
+		return map[string][]facetCountElement{}, nil
+	}
+	return temp[0], nil
+}
+
+func toFacetCounts(elements []facetCountElement) (result []model.FacetCount) {
+	result = []model.FacetCount{}
+	for _, element := range elements {
+		value := ""
+		switch v := element.Value.(type) {
+		case nil:
+		case string:
+			value = v
+		case bool:
+			value = strconv.FormatBool(v)
+		default:
+			value = fmt.Sprint(v)
+		}
+		result = append(result, model.FacetCount{Value: value, Count: element.Count})
+	}
+	return result
+}
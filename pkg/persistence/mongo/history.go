@@ -0,0 +1,123 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"net/http"
+)
+
+const versionLocalIdFieldName = "LocalId"
+const versionVersionFieldName = "Version"
+const versionUserIdFieldName = "Device.UserId"
+
+var versionLocalIdKey string
+var versionVersionKey string
+var versionUserIdKey string
+
+func init() {
+	var err error
+	versionLocalIdKey, err = getBsonFieldName(model.DeviceVersion{}, versionLocalIdFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	versionVersionKey, err = getBsonFieldName(model.DeviceVersion{}, versionVersionFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	versionUserIdKey, err = getBsonFieldPath(model.DeviceVersion{}, versionUserIdFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	Migrations = append(Migrations,
+		schema.Migration[*Mongo]{Version: 4, Description: "create device history indexes", Up: createDeviceHistoryIndexes},
+	)
+}
+
+func createDeviceHistoryIndexes(db *Mongo) error {
+	collection := db.historyCollection()
+	err := db.ensureCompoundIndex(collection, "historyversionindex", true, true, versionLocalIdKey, versionVersionKey)
+	if err != nil {
+		return err
+	}
+	return db.ensureCompoundIndex(collection, "historyuserindex", true, false, versionLocalIdKey, versionUserIdKey)
+}
+
+// historyCollection defaults to the device collection name with the suffix _history
+func (this *Mongo) historyCollection() *mongo.Collection {
+	name := this.config.MongoHistoryCollection
+	if name == "" {
+		name = this.config.MongoDeviceCollection + "_history"
+	}
+	return this.db.Database(this.config.MongoTable).Collection(name)
+}
+
+func (this *Mongo) AddDeviceVersion(ctx context.Context, version model.DeviceVersion, keep int64) (result model.DeviceVersion, err error, errCode int) {
+	ctx, _ = getTimeoutContext(ctx)
+	version.Diff = nil
+	latest := model.DeviceVersion{}
+	err = this.historyCollection().FindOne(ctx, bson.M{versionLocalIdKey: version.LocalId}, options.FindOne().SetSort(bson.D{{Key: versionVersionKey, Value: -1}})).Decode(&latest)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return result, err, http.StatusInternalServerError
+	}
+	version.Version = latest.Version + 1
+	_, err = this.historyCollection().InsertOne(ctx, version)
+	if mongo.IsDuplicateKeyError(err) {
+		return result, err, http.StatusConflict
+	}
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	_, err = this.historyCollection().DeleteMany(ctx, bson.M{versionLocalIdKey: version.LocalId, versionVersionKey: bson.M{"$lte": version.Version - keep}})
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return version, nil, http.StatusOK
+}
+
+func (this *Mongo) ListDeviceVersions(ctx context.Context, userId string, localId string) (result []model.DeviceVersion, err error, errCode int) {
+	ctx, _ = getTimeoutContext(ctx)
+	cursor, err := this.historyCollection().Find(ctx, bson.M{versionLocalIdKey: localId, versionUserIdKey: userId}, options.Find().SetSort(bson.D{{Key: versionVersionKey, Value: -1}}))
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	result = []model.DeviceVersion{}
+	err = cursor.All(ctx, &result)
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *Mongo) ReadDeviceVersion(ctx context.Context, userId string, localId string, version int64) (result model.DeviceVersion, err error, errCode int) {
+	ctx, _ = getTimeoutContext(ctx)
+	err = this.historyCollection().FindOne(ctx, bson.M{versionLocalIdKey: localId, versionUserIdKey: userId, versionVersionKey: version}).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, errors.New("version not found"), http.StatusNotFound
+	}
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
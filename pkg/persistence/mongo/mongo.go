@@ -4,12 +4,12 @@ import (
 	"context"
 	"errors"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
-	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"log"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"log/slog"
 	"reflect"
 	"strings"
 	"sync"
@@ -21,21 +21,18 @@ type Mongo struct {
 	db     *mongo.Client
 }
 
-var CreateCollections = []func(db *Mongo) error{}
-
 func New(ctx context.Context, wg *sync.WaitGroup, conf configuration.Config) (*Mongo, error) {
-	timeout, _ := getTimeoutContext()
+	timeout, _ := getTimeoutContext(context.Background())
 	db, err := mongo.Connect(timeout, options.Client().ApplyURI(conf.MongoUrl))
 	if err != nil {
 		return nil, err
 	}
 	client := &Mongo{config: conf, db: db}
-	for _, creators := range CreateCollections {
-		err = creators(client)
-		if err != nil {
-			client.disconnect()
-			return nil, err
-		}
+	err = client.migrateSchema()
+	if err != nil {
+		slog.Error("unable to migrate schema", "error", err)
+		client.disconnect()
+		return nil, err
 	}
 	if wg != nil {
 		wg.Add(1)
@@ -51,7 +48,7 @@ func New(ctx context.Context, wg *sync.WaitGroup, conf configuration.Config) (*M
 }
 
 func (this *Mongo) ensureIndex(collection *mongo.Collection, indexname string, indexKey string, asc bool, unique bool) error {
-	ctx, _ := getTimeoutContext()
+	ctx, _ := getTimeoutContext(context.Background())
 	var direction int32 = -1
 	if asc {
 		direction = 1
@@ -64,7 +61,7 @@ func (this *Mongo) ensureIndex(collection *mongo.Collection, indexname string, i
 }
 
 func (this *Mongo) ensureCompoundIndex(collection *mongo.Collection, indexname string, asc bool, unique bool, indexKeys ...string) error {
-	ctx, _ := getTimeoutContext()
+	ctx, _ := getTimeoutContext(context.Background())
 	var direction int32 = -1
 	if asc {
 		direction = 1
@@ -88,7 +85,7 @@ func (this *Mongo) ensureTextIndex(collection *mongo.Collection, indexname strin
 	for _, key := range indexKeys {
 		keys = append(keys, bson.E{Key: key, Value: "text"})
 	}
-	ctx, _ := getTimeoutContext()
+	ctx, _ := getTimeoutContext(context.Background())
 	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys:    keys,
 		Options: options.Index().SetName(indexname),
@@ -96,23 +93,32 @@ func (this *Mongo) ensureTextIndex(collection *mongo.Collection, indexname strin
 	return err
 }
 
-func (this *Mongo) disconnect() {
-	timeout, _ := context.WithTimeout(context.Background(), 10*time.Second)
-	log.Println("disconnect mongo:", this.db.Disconnect(timeout))
+func (this *Mongo) dropIndexIfExists(collection *mongo.Collection, indexname string) error {
+	ctx, _ := getTimeoutContext(context.Background())
+	_, err := collection.Indexes().DropOne(ctx, indexname)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == indexNotFoundErrorCode || cmdErr.Code == namespaceNotFoundErrorCode) {
+		return nil
+	}
+	return err
+}
+
+const namespaceNotFoundErrorCode = 26
+const indexNotFoundErrorCode = 27
+
+func (this *Mongo) Ping(ctx context.Context) error {
+	ctx, _ = getTimeoutContext(ctx)
+	return this.db.Ping(ctx, readpref.Primary())
 }
 
-func (this *Mongo) getSearchTokens(device model.Device) string {
-	return strings.Join([]string{
-		device.LocalId,
-		device.Name,
-		strings.NewReplacer(
-			"_", " ",
-			"-", " ",
-			".", " ",
-			":", " ",
-			"/", " ").
-			Replace(device.Name),
-	}, " ")
+func (this *Mongo) disconnect() {
+	timeout, _ := context.WithTimeout(context.Background(), 10*time.Second)
+	err := this.db.Disconnect(timeout)
+	if err != nil {
+		slog.Error("unable to disconnect mongo", "error", err)
+		return
+	}
+	slog.Info("disconnected mongo")
 }
 
 func getBsonFieldName(obj interface{}, fieldName string) (bsonName string, err error) {
@@ -144,6 +150,6 @@ func getBsonFieldPath(obj interface{}, path string) (bsonPath string, err error)
 	return
 }
 
-func getTimeoutContext() (context.Context, context.CancelFunc) {
-	return context.WithTimeout(context.Background(), 10*time.Second)
+func getTimeoutContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, 10*time.Second)
 }
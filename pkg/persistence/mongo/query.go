@@ -0,0 +1,110 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/query"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"regexp"
+	"strings"
+)
+
+func getQueryFieldKey(field query.Field) (string, error) {
+	switch field {
+	case query.FieldName:
+		return deviceNameKey, nil
+	case query.FieldLocalId:
+		return deviceLocalIdKey, nil
+	case query.FieldId:
+		return deviceIdKey, nil
+	case query.FieldDeviceTypeId:
+		return deviceDeviceTypeIdKey, nil
+	default:
+		return "", errors.New("unknown search field " + string(field))
+	}
+}
+
+func compileQuery(node query.Node) (bson.M, error) {
+	switch n := node.(type) {
+	case query.And:
+		children, err := compileQueryList(n.Children)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$and": children}, nil
+	case query.Or:
+		children, err := compileQueryList(n.Children)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$or": children}, nil
+	case query.Not:
+		child, err := compileQuery(n.Child)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": bson.A{child}}, nil
+	case query.Text:
+		and := bson.A{}
+		for _, term := range search.Terms(n.Value) {
+			ngram, complete := search.NgramOf(term)
+			and = append(and, bson.M{deviceSearchNgramsKey: ngram})
+			if !complete {
+				and = append(and, bson.M{deviceSearchTokensKey: bson.M{"$regex": regexp.QuoteMeta(term)}})
+			}
+		}
+		if len(and) == 0 {
+			return bson.M{}, nil
+		}
+		return bson.M{"$and": and}, nil
+	case query.Match:
+		key, err := getQueryFieldKey(n.Field)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{key: getPatternRegex(n.Pattern)}, nil
+	case query.AttributeMatch:
+		return bson.M{deviceAttributesKey: bson.M{"$elemMatch": bson.M{attributeKeyKey: n.Key, attributeValueKey: getPatternRegex(n.Pattern)}}}, nil
+	case query.AttributeExists:
+		return bson.M{deviceAttributesKey + "." + attributeKeyKey: n.Key}, nil
+	case query.Hidden:
+		return bson.M{deviceHiddenKey: n.Value}, nil
+	default:
+		return nil, errors.New("unknown search expression")
+	}
+}
+
+func compileQueryList(nodes []query.Node) (result bson.A, err error) {
+	for _, node := range nodes {
+		child, err := compileQuery(node)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, child)
+	}
+	return result, nil
+}
+
+func getPatternRegex(pattern query.Pattern) bson.M {
+	parts := []string{}
+	for _, part := range pattern.Parts() {
+		parts = append(parts, regexp.QuoteMeta(part))
+	}
+	return bson.M{"$regex": "^" + strings.Join(parts, ".*") + "$", "$options": "is"}
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Migrations are applied in order of their version; mongo has no transactions for index changes, so every migration has to be idempotent
+var Migrations = []schema.Migration[*Mongo]{}
+
+const schemaCollectionName = "schema_version"
+const schemaVersionDocumentId = "version"
+const schemaLockDocumentId = "lock"
+
+// a lock of a crashed instance expires after schemaLockLease
+const schemaLockLease = 10 * time.Minute
+const schemaLockTimeout = 15 * time.Minute
+
+type schemaVersionDocument struct {
+	Id      string              `bson:"_id"`
+	Version int                 `bson:"version"`
+	Applied []schemaAppliedInfo `bson:"applied"`
+}
+
+type schemaAppliedInfo struct {
+	Version     int       `bson:"version"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+func (this *Mongo) schemaCollection() *mongo.Collection {
+	return this.db.Database(this.config.MongoTable).Collection(schemaCollectionName)
+}
+
+func (this *Mongo) migrateSchema() error {
+	migrations, err := schema.Sorted(Migrations)
+	if err != nil {
+		return err
+	}
+	release, err := this.lockSchema()
+	if err != nil {
+		return fmt.Errorf("unable to lock schema: %w", err)
+	}
+	defer release()
+
+	current, err := this.readSchemaVersion()
+	if err != nil {
+		return fmt.Errorf("unable to read schema version: %w", err)
+	}
+	if latest := schema.Latest(migrations); current.Version > latest {
+		slog.Warn("mongo schema version is newer than the latest known version", "version", current.Version, "latest", latest)
+	}
+	for _, migration := range migrations {
+		if migration.Version <= current.Version {
+			continue
+		}
+		slog.Info("apply mongo schema migration", "version", migration.Version, "description", migration.Description)
+		err = migration.Up(this)
+		if err != nil {
+			return fmt.Errorf("unable to apply schema migration %v (%v): %w", migration.Version, migration.Description, err)
+		}
+		ctx, _ := getTimeoutContext(context.Background())
+		_, err = this.schemaCollection().UpdateOne(ctx, bson.M{"_id": schemaVersionDocumentId}, bson.M{
+			"$set":  bson.M{"version": migration.Version},
+			"$push": bson.M{"applied": schemaAppliedInfo{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}},
+		}, options.Update().SetUpsert(true))
+		if err != nil {
+			return fmt.Errorf("unable to store schema version %v: %w", migration.Version, err)
+		}
+	}
+	return nil
+}
+
+func (this *Mongo) readSchemaVersion() (result schemaVersionDocument, err error) {
+	ctx, _ := getTimeoutContext(context.Background())
+	err = this.schemaCollection().FindOne(ctx, bson.M{"_id": schemaVersionDocumentId}).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, nil
+	}
+	return result, err
+}
+
+// lockSchema acquires a lease on the lock document; inserting a second lock document fails with a duplicate key error while the lease is valid
+func (this *Mongo) lockSchema() (release func(), err error) {
+	owner, _ := os.Hostname()
+	owner = fmt.Sprintf("%v-%v-%v", owner, os.Getpid(), time.Now().UnixNano())
+	deadline := time.Now().Add(schemaLockTimeout)
+	for {
+		now := time.Now()
+		ctx, _ := getTimeoutContext(context.Background())
+		_, err = this.schemaCollection().UpdateOne(ctx,
+			bson.M{"_id": schemaLockDocumentId, "locked_until": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"locked_until": now.Add(schemaLockLease), "owner": owner}},
+			options.Update().SetUpsert(true))
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+		if now.After(deadline) {
+			return nil, errors.New("timeout while waiting for schema lock")
+		}
+		slog.Info("wait for schema lock held by another instance")
+		time.Sleep(time.Second)
+	}
+	return func() {
+		ctx, _ := getTimeoutContext(context.Background())
+		_, err := this.schemaCollection().DeleteOne(ctx, bson.M{"_id": schemaLockDocumentId, "owner": owner})
+		if err != nil {
+			slog.Error("unable to release schema lock", "error", err)
+		}
+	}, nil
+}
+
+// SchemaStatus lists the known migrations and if they have been applied, without applying pending migrations
+func SchemaStatus(conf configuration.Config) (result []schema.Status, err error) {
+	timeout, _ := getTimeoutContext(context.Background())
+	db, err := mongo.Connect(timeout, options.Client().ApplyURI(conf.MongoUrl))
+	if err != nil {
+		return nil, err
+	}
+	client := &Mongo{config: conf, db: db}
+	defer client.disconnect()
+	current, err := client.readSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	applied := map[int]time.Time{}
+	for _, info := range current.Applied {
+		applied[info.Version] = info.AppliedAt
+	}
+	return schema.GetStatus(Migrations, applied)
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/search"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"log"
+	"net/http"
+	"time"
+)
+
+const deviceDeletedAtFieldName = "DeletedAt"
+
+var deviceDeletedAtKey string
+
+func init() {
+	var err error
+	deviceDeletedAtKey, err = getBsonFieldName(model.Device{}, deviceDeletedAtFieldName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	Migrations = append(Migrations,
+		schema.Migration[*Mongo]{Version: 5, Description: "create trash indexes", Up: createTrashIndexes},
+	)
+}
+
+func createTrashIndexes(db *Mongo) error {
+	collection := db.trashCollection()
+	err := db.ensureIndex(collection, "trashlocalidindex", deviceLocalIdKey, true, true)
+	if err != nil {
+		return err
+	}
+	err = db.ensureCompoundIndex(collection, "trashuserindex", false, false, deviceUserIdKey, deviceDeletedAtKey)
+	if err != nil {
+		return err
+	}
+	return db.ensureIndex(collection, "trashdeletedatindex", deviceDeletedAtKey, true, false)
+}
+
+// trashCollection defaults to the device collection name with the suffix _trash
+func (this *Mongo) trashCollection() *mongo.Collection {
+	name := this.config.MongoTrashCollection
+	if name == "" {
+		name = this.config.MongoDeviceCollection + "_trash"
+	}
+	return this.db.Database(this.config.MongoTable).Collection(name)
+}
+
+// TrashDevice copies the device to the trash before it is removed, so that a failed removal keeps the device
+func (this *Mongo) TrashDevice(ctx context.Context, localId string, deletedAt time.Time) (error, int) {
+	device, err, errCode := this.ReadDevice(ctx, localId)
+	if err != nil {
+		return err, errCode
+	}
+	device.DeletedAt = &deletedAt
+	timeout, _ := getTimeoutContext(ctx)
+	_, err = this.trashCollection().ReplaceOne(timeout, bson.M{deviceLocalIdKey: localId}, device, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return this.RemoveDevice(ctx, localId)
+}
+
+func (this *Mongo) ListTrashedDevices(ctx context.Context, userId string, limit int, offset int) (result []model.Device, total int64, err error, errCode int) {
+	ctx, _ = getTimeoutContext(ctx)
+	filter := bson.M{deviceUserIdKey: userId}
+	total, err = this.trashCollection().CountDocuments(ctx, filter)
+	if err != nil {
+		return result, total, err, http.StatusInternalServerError
+	}
+	opt := options.Find().SetSort(bson.D{{Key: deviceDeletedAtKey, Value: -1}, {Key: deviceLocalIdKey, Value: 1}}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := this.trashCollection().Find(ctx, filter, opt)
+	if err != nil {
+		return result, total, err, http.StatusInternalServerError
+	}
+	result = []model.Device{}
+	err = cursor.All(ctx, &result)
+	if err != nil {
+		return result, total, err, http.StatusInternalServerError
+	}
+	return result, total, nil, http.StatusOK
+}
+
+func (this *Mongo) ReadTrashedDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	ctx, _ = getTimeoutContext(ctx)
+	err = this.trashCollection().FindOne(ctx, bson.M{deviceLocalIdKey: localId}).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return result, err, http.StatusNotFound
+	}
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// RestoreTrashedDevice inserts the device before the trash entry is removed, so that a failed insert keeps the trashed device;
+// the unique local_id index rejects devices which have been created in the meantime
+func (this *Mongo) RestoreTrashedDevice(ctx context.Context, device model.Device) (error, int) {
+	_, err, errCode := this.ReadTrashedDevice(ctx, device.LocalId)
+	if errCode == http.StatusNotFound {
+		return errors.New("device not found in trash"), http.StatusNotFound
+	}
+	if err != nil {
+		return err, errCode
+	}
+	device.DeletedAt = nil
+	device.SearchTokens = search.DeviceText(device)
+	device.SearchNgrams = search.Ngrams(search.DeviceTokens(device))
+	timeout, _ := getTimeoutContext(ctx)
+	_, err = this.deviceCollection().InsertOne(timeout, device)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.New("device with the same local_id exists"), http.StatusConflict
+	}
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	_, err = this.trashCollection().DeleteOne(timeout, bson.M{deviceLocalIdKey: device.LocalId})
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+func (this *Mongo) PurgeTrash(ctx context.Context, deletedBefore time.Time) (count int64, err error, errCode int) {
+	ctx, _ = getTimeoutContext(ctx)
+	result, err := this.trashCollection().DeleteMany(ctx, bson.M{deviceDeletedAtKey: bson.M{"$lt": deletedBefore}})
+	if err != nil {
+		return count, err, http.StatusInternalServerError
+	}
+	return result.DeletedCount, nil, http.StatusOK
+}
@@ -16,10 +16,76 @@
 
 package options
 
+import (
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/query"
+	"strings"
+	"time"
+)
+
 type List struct {
 	Limit      int
 	Offset     int
 	Sort       string
 	ShowHidden bool
-	Search     string
+	Search     string //query as described in package query
+
+	AttributeFilter []AttributeFilter
+	DeviceTypeIds   []string //matches devices with any of the given device types
+
+	//time ranges; zero values are ignored, After is inclusive, Before is exclusive
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+
+	Facets Facets
+}
+
+// SearchQuery returns the parsed Search; nil if Search is empty
+func (this List) SearchQuery() (query.Node, error) {
+	return query.Parse(this.Search)
+}
+
+// HideHidden checks if hidden devices should be excluded, which is the case if ShowHidden is false
+// and the search query does not filter by the hidden state itself
+func (this List) HideHidden(searchQuery query.Node) bool {
+	return !this.ShowHidden && !query.ReferencesHidden(searchQuery)
+}
+
+type Facets struct {
+	DeviceTypeId  bool
+	Hidden        bool
+	AttributeKeys []string
+}
+
+func (this Facets) IsEmpty() bool {
+	return !this.DeviceTypeId && !this.Hidden && len(this.AttributeKeys) == 0
+}
+
+// Add requests the facet with the given name: device_type_id, hidden or attr.<key>; empty names are ignored
+func (this *Facets) Add(facet string) error {
+	switch {
+	case facet == "":
+	case facet == "device_type_id":
+		this.DeviceTypeId = true
+	case facet == "hidden":
+		this.Hidden = true
+	case strings.HasPrefix(facet, "attr.") && len(facet) > len("attr."):
+		this.AttributeKeys = append(this.AttributeKeys, strings.TrimPrefix(facet, "attr."))
+	default:
+		return fmt.Errorf("unknown facet %v", facet)
+	}
+	return nil
+}
+
+type AttributeFilterOperation string
+
+const AttributeEquals AttributeFilterOperation = "eq"
+const AttributeExists AttributeFilterOperation = "exists"
+
+type AttributeFilter struct {
+	Key       string
+	Value     string //ignored for AttributeExists
+	Operation AttributeFilterOperation
 }
@@ -24,15 +24,65 @@ import (
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/mongo"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/postgres"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/sqlite"
 	"sync"
+	"time"
 )
 
 type Persistence interface {
-	ListDevices(userId string, options options.List) (result []model.Device, total int64, err error, errCode int)
-	ReadDevice(localId string) (result model.Device, err error, errCode int)
-	SetDevice(device model.Device) (error, int)
-	RemoveDevice(localId string) (error, int)
-	MigrateTo(target options.MigrationTarget) error
+	ListDevices(ctx context.Context, userId string, options options.List) (result []model.Device, total int64, err error, errCode int)
+	CountFacets(ctx context.Context, userId string, options options.List) (result model.DeviceFacets, err error, errCode int)
+	ExportDevices(ctx context.Context, userId string, options options.List, handler func(device model.Device) error) (err error, errCode int)
+	ReadDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int)
+	SetDevice(ctx context.Context, device model.Device) (error, int)
+	//replaces the device only if the stored device has been updated at lastUpdate, for atomic read-modify-write;
+	//fails with http.StatusConflict if the device has been changed or removed in the meantime
+	SetDeviceIfUnchanged(ctx context.Context, device model.Device, lastUpdate time.Time) (error, int)
+	RemoveDevice(ctx context.Context, localId string) (error, int)
+
+	//adds the version with the next version number of the local_id and removes all but the newest keep versions;
+	//fails with http.StatusConflict if the version number has been taken concurrently
+	AddDeviceVersion(ctx context.Context, version model.DeviceVersion, keep int64) (result model.DeviceVersion, err error, errCode int)
+	//lists the versions of the local_id owned by the user, newest first
+	ListDeviceVersions(ctx context.Context, userId string, localId string) (result []model.DeviceVersion, err error, errCode int)
+	ReadDeviceVersion(ctx context.Context, userId string, localId string, version int64) (result model.DeviceVersion, err error, errCode int)
+
+	//moves the device to the trash, replacing a trashed device with the same local_id
+	TrashDevice(ctx context.Context, localId string, deletedAt time.Time) (error, int)
+	//lists the trashed devices of the user, most recently deleted first
+	ListTrashedDevices(ctx context.Context, userId string, limit int, offset int) (result []model.Device, total int64, err error, errCode int)
+	ReadTrashedDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int)
+	//moves the device from the trash back to the devices, stored as given;
+	//fails with http.StatusConflict if a device with the local_id exists
+	RestoreTrashedDevice(ctx context.Context, device model.Device) (error, int)
+	//removes all devices, which have been trashed before deletedBefore
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (count int64, err error, errCode int)
+
+	//checks the connection to the database, used by the readiness check
+	Ping(ctx context.Context) error
+
+	//number of devices of all users per state (see model.DeviceStates), used by metrics
+	CountDevicesByState() (result map[string]int64, err error, errCode int)
+
+	//batch access to the devices of all users, used by migration, backup and restore
+	ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int)
+	CountAllDevices() (count int64, err error, errCode int)
+	SetDevices(devices []model.Device) (error, int)
+	RemoveDevices(localIds []string) (error, int)
+
+	//batch access to the device history of all users, used by migration, backup and restore;
+	//versions are read in the order of local_id and version and stored with their version numbers
+	ReadDeviceVersionsAfter(lastLocalId string, lastVersion int64, limit int) (result []model.DeviceVersion, err error, errCode int)
+	SetDeviceVersions(versions []model.DeviceVersion) (error, int)
+	//removes all versions of the local_ids
+	RemoveDeviceVersions(localIds []string) (error, int)
+
+	//batch access to the trashed devices of all users, used by migration, backup and restore;
+	//trashed devices are stored with their deleted_at, which is required
+	ReadTrashedDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int)
+	SetTrashedDevices(devices []model.Device) (error, int)
+	RemoveTrashedDevices(localIds []string) (error, int)
 }
 
 func New(ctx context.Context, wg *sync.WaitGroup, config configuration.Config) (Persistence, error) {
@@ -41,6 +91,22 @@ func New(ctx context.Context, wg *sync.WaitGroup, config configuration.Config) (
 		return mongo.New(ctx, wg, config)
 	case configuration.Postgres:
 		return postgres.New(ctx, wg, config)
+	case configuration.Sqlite:
+		return sqlite.New(ctx, wg, config)
+	default:
+		return nil, errors.New("unknown configuration.db_impl: " + config.DbImpl)
+	}
+}
+
+// SchemaStatus lists the schema migrations of the configured implementation and if they have been applied, without applying pending migrations
+func SchemaStatus(config configuration.Config) ([]schema.Status, error) {
+	switch config.DbImpl {
+	case configuration.Mongo:
+		return mongo.SchemaStatus(config)
+	case configuration.Postgres:
+		return postgres.SchemaStatus(config)
+	case configuration.Sqlite:
+		return sqlite.SchemaStatus(config)
 	default:
 		return nil, errors.New("unknown configuration.db_impl: " + config.DbImpl)
 	}
@@ -0,0 +1,248 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/lib/pq"
+	"net/http"
+)
+
+// ReadDevicesAfter returns up to limit devices of all users with a local_id greater than lastLocalId, ordered by local_id
+func (this *Postgres) ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	deviceFields, scan := getDeviceScanInfo()
+	rows, err := this.db.QueryContext(this.getTimeoutContext(context.Background()), `SELECT `+deviceFields+` FROM devices WHERE local_id > $1 ORDER BY local_id LIMIT $2`, lastLocalId, limit)
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	for rows.Next() {
+		element, err := scan(rows)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		result = append(result, element)
+	}
+	err = rows.Err()
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// SetDevices stores all devices in a single transaction
+func (this *Postgres) SetDevices(devices []model.Device) (error, int) {
+	timeout := this.getTimeoutContext(context.Background())
+	tx, err := this.db.BeginTx(timeout, nil)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(timeout, setDeviceQuery)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer stmt.Close()
+	for _, device := range devices {
+		args, err := getSetDeviceArgs(device)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+		_, err = stmt.ExecContext(timeout, args...)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+func (this *Postgres) RemoveDevices(localIds []string) (error, int) {
+	_, err := this.db.ExecContext(this.getTimeoutContext(context.Background()), "DELETE FROM devices WHERE local_id = ANY($1)", pq.Array(localIds))
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// ReadDeviceVersionsAfter returns up to limit versions of all users, which follow lastLocalId and lastVersion in the order of local_id and version
+func (this *Postgres) ReadDeviceVersionsAfter(lastLocalId string, lastVersion int64, limit int) (result []model.DeviceVersion, err error, errCode int) {
+	rows, err := this.db.QueryContext(this.getTimeoutContext(context.Background()), `SELECT `+deviceVersionFields+` FROM device_history WHERE (local_id, version) > ($1, $2) ORDER BY local_id, version LIMIT $3`, lastLocalId, lastVersion, limit)
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	for rows.Next() {
+		element, err := scanDeviceVersion(rows)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		result = append(result, element)
+	}
+	err = rows.Err()
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+const setDeviceVersionQuery = `INSERT INTO device_history(local_id, version, change, time, user_id, device) VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (local_id, version) DO UPDATE SET change = EXCLUDED.change, time = EXCLUDED.time, user_id = EXCLUDED.user_id, device = EXCLUDED.device;`
+
+// SetDeviceVersions stores all versions with their version numbers in a single transaction
+func (this *Postgres) SetDeviceVersions(versions []model.DeviceVersion) (error, int) {
+	timeout := this.getTimeoutContext(context.Background())
+	tx, err := this.db.BeginTx(timeout, nil)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(timeout, setDeviceVersionQuery)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer stmt.Close()
+	for _, version := range versions {
+		deviceBuf, err := json.Marshal(version.Device)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+		_, err = stmt.ExecContext(timeout, version.LocalId, version.Version, version.Change, version.Time, version.Device.UserId, deviceBuf)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// RemoveDeviceVersions removes all versions of the local_ids
+func (this *Postgres) RemoveDeviceVersions(localIds []string) (error, int) {
+	_, err := this.db.ExecContext(this.getTimeoutContext(context.Background()), "DELETE FROM device_history WHERE local_id = ANY($1)", pq.Array(localIds))
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// ReadTrashedDevicesAfter returns up to limit trashed devices of all users with a local_id greater than lastLocalId, ordered by local_id
+func (this *Postgres) ReadTrashedDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	rows, err := this.db.QueryContext(this.getTimeoutContext(context.Background()), `SELECT device FROM deleted_devices WHERE local_id > $1 ORDER BY local_id LIMIT $2`, lastLocalId, limit)
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	for rows.Next() {
+		element, err := scanTrashedDevice(rows)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		result = append(result, element)
+	}
+	err = rows.Err()
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+// SetTrashedDevices stores all devices in the trash in a single transaction, each device needs its deleted_at
+func (this *Postgres) SetTrashedDevices(devices []model.Device) (error, int) {
+	timeout := this.getTimeoutContext(context.Background())
+	tx, err := this.db.BeginTx(timeout, nil)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(timeout, setTrashedDeviceQuery)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer stmt.Close()
+	for _, device := range devices {
+		if device.DeletedAt == nil {
+			return fmt.Errorf("trashed device %v without deleted_at", device.LocalId), http.StatusBadRequest
+		}
+		deviceBuf, err := json.Marshal(device)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+		_, err = stmt.ExecContext(timeout, device.LocalId, device.UserId, *device.DeletedAt, deviceBuf)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+func (this *Postgres) RemoveTrashedDevices(localIds []string) (error, int) {
+	_, err := this.db.ExecContext(this.getTimeoutContext(context.Background()), "DELETE FROM deleted_devices WHERE local_id = ANY($1)", pq.Array(localIds))
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// CountAllDevices returns the number of devices of all users
+func (this *Postgres) CountAllDevices() (count int64, err error, errCode int) {
+	err = this.db.QueryRowContext(this.getTimeoutContext(context.Background()), "SELECT COUNT(*) FROM devices").Scan(&count)
+	if err != nil {
+		return count, err, http.StatusInternalServerError
+	}
+	return count, nil, http.StatusOK
+}
+
+// CountDevicesByState returns the number of devices of all users per state
+func (this *Postgres) CountDevicesByState() (result map[string]int64, err error, errCode int) {
+	result = model.DeviceStates()
+	rows, err := this.db.QueryContext(this.getTimeoutContext(context.Background()), "SELECT COALESCE(hidden, FALSE), COUNT(*) FROM devices GROUP BY 1")
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hidden bool
+		var count int64
+		err = rows.Scan(&hidden, &count)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		if hidden {
+			result[model.DeviceStateHidden] += count
+		} else {
+			result[model.DeviceStateVisible] += count
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
@@ -24,60 +24,131 @@ import (
 	"fmt"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/query"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/search"
 	"github.com/SENERGY-Platform/models/go/models"
-	"log"
+	"github.com/lib/pq"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func init() {
-	CreateTables = append(CreateTables, CreateDevicesTable)
+	Migrations = append(Migrations,
+		schema.Migration[*sql.Tx]{Version: 1, Description: "create devices table", Up: createDevicesTable},
+		schema.Migration[*sql.Tx]{Version: 2, Description: "store attributes as jsonb and add filter indexes", Up: addDeviceFilterIndexes},
+		schema.Migration[*sql.Tx]{Version: 3, Description: "add search_text", Up: addSearchText},
+		schema.Migration[*sql.Tx]{Version: 4, Description: "add reported and overrides", Up: addOverrides},
+	)
 }
 
-func CreateDevicesTable(db *Postgres) error {
-	ctx := db.getTimeoutContext()
-	_, err := db.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS devices (
-    	local_id TEXT PRIMARY KEY,
-    	id TEXT, 
-    	name TEXT,
-    	device_type_id TEXT,
-    	attributes JSON,
-    	user_id TEXT, 
-    	hidden BOOL, 
-    	created_at timestamptz,
-    	updated_at timestamptz);
-`)
-	if err != nil {
-		log.Println("ERROR: unable to create table:", err)
-		return err
+func execAll(tx *sql.Tx, statements ...string) error {
+	for _, statement := range statements {
+		_, err := tx.ExecContext(context.Background(), statement)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// Create trigram extension
-	_, err = db.db.ExecContext(ctx, `CREATE EXTENSION IF NOT EXISTS pg_trgm;`)
-	if err != nil {
-		log.Println("ERROR: unable to create extension:", err)
-		return err
-	}
+func createDevicesTable(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS devices (
+			local_id TEXT PRIMARY KEY,
+			id TEXT, 
+			name TEXT,
+			device_type_id TEXT,
+			attributes JSON,
+			user_id TEXT, 
+			hidden BOOL, 
+			created_at timestamptz,
+			updated_at timestamptz);`,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm;`,
+		`CREATE INDEX IF NOT EXISTS devices_name_trgm_idx ON devices USING gin (name gin_trgm_ops);`,
+		`CREATE INDEX IF NOT EXISTS devices_local_id_trgm_idx ON devices USING gin (local_id gin_trgm_ops);`,
+	)
+}
 
-	// Create index for name
-	_, err = db.db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS devices_name_trgm_idx ON devices USING gin (name gin_trgm_ops);`)
+func addDeviceFilterIndexes(tx *sql.Tx) error {
+	return execAll(tx,
+		// jsonb allows containment queries on attributes
+		`DO $$
+		BEGIN
+			IF (SELECT data_type FROM information_schema.columns WHERE table_name = 'devices' AND column_name = 'attributes') = 'json' THEN
+				ALTER TABLE devices ALTER COLUMN attributes TYPE JSONB USING attributes::jsonb;
+			END IF;
+		END $$;`,
+		`CREATE INDEX IF NOT EXISTS devices_attributes_idx ON devices USING gin (attributes jsonb_path_ops);`,
+		`CREATE INDEX IF NOT EXISTS devices_device_type_id_idx ON devices (device_type_id);`,
+	)
+}
+
+func addSearchText(tx *sql.Tx) error {
+	err := execAll(tx,
+		`ALTER TABLE devices ADD COLUMN IF NOT EXISTS search_text TEXT;`,
+		`CREATE INDEX IF NOT EXISTS devices_search_text_trgm_idx ON devices USING gin (search_text gin_trgm_ops);`,
+	)
 	if err != nil {
-		log.Println("ERROR: unable to create index:", err)
 		return err
 	}
+	return fillSearchText(tx)
+}
 
-	// Create index for local_id
-	_, err = db.db.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS devices_local_id_trgm_idx ON devices USING gin (local_id gin_trgm_ops);`)
-	if err != nil {
-		log.Println("ERROR: unable to create index:", err)
-		return err
+// fillSearchText sets the search_text of devices stored before the column existed
+// only columns of the schema at this version are read, because later columns do not exist yet
+func fillSearchText(tx *sql.Tx) error {
+	ctx := context.Background()
+	for {
+		devices := []model.Device{}
+		rows, err := tx.QueryContext(ctx, `SELECT local_id, name, device_type_id, attributes FROM devices WHERE search_text IS NULL LIMIT 1000`)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			element := model.Device{}
+			attrBuf := []byte{}
+			err = rows.Scan(&element.LocalId, &element.Name, &element.DeviceTypeId, &attrBuf)
+			if err == nil {
+				err = json.Unmarshal(attrBuf, &element.Attributes)
+			}
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			devices = append(devices, element)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if len(devices) == 0 {
+			return nil
+		}
+		for _, device := range devices {
+			_, err = tx.ExecContext(ctx, `UPDATE devices SET search_text = $2 WHERE local_id = $1`, device.LocalId, search.DeviceText(device))
+			if err != nil {
+				return err
+			}
+		}
 	}
+}
 
-	return nil
+func addOverrides(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE devices ADD COLUMN IF NOT EXISTS reported JSONB;`,
+		`ALTER TABLE devices ADD COLUMN IF NOT EXISTS overrides JSONB;`,
+	)
+}
+
+// scanner is implemented by *sql.Rows and *sql.Row
+type scanner interface {
+	Scan(dest ...any) error
 }
 
-func getDeviceScanInfo() (selectFields string, scan func(rows *sql.Rows) (model.Device, error)) {
+func getDeviceScanInfo() (selectFields string, scan func(rows scanner) (model.Device, error)) {
 	return `local_id, 
 		id, 
 		name, 
@@ -86,57 +157,40 @@ func getDeviceScanInfo() (selectFields string, scan func(rows *sql.Rows) (model.
 		user_id, 
 		hidden, 
 		created_at, 
-		updated_at`,
-		func(rows *sql.Rows) (device model.Device, err error) {
+		updated_at,
+		reported,
+		overrides`,
+		func(rows scanner) (device model.Device, err error) {
 			attrBuf := []byte{}
-			err = rows.Scan(&device.LocalId, &device.Id, &device.Name, &device.DeviceTypeId, &attrBuf, &device.UserId, &device.Hidden, &device.CreatedAt, &device.LastUpdate)
+			reportedBuf := []byte{}
+			overridesBuf := []byte{}
+			err = rows.Scan(&device.LocalId, &device.Id, &device.Name, &device.DeviceTypeId, &attrBuf, &device.UserId, &device.Hidden, &device.CreatedAt, &device.LastUpdate, &reportedBuf, &overridesBuf)
 			if err != nil {
 				return device, err
 			}
 			err = json.Unmarshal(attrBuf, &device.Attributes)
-			return device, err
+			if err != nil {
+				return device, err
+			}
+			return device, unmarshalOverrides(reportedBuf, overridesBuf, &device)
 		}
 }
 
-func (this *Postgres) MigrateTo(target options.MigrationTarget) error {
-	deviceFields, scan := getDeviceScanInfo()
-	rows, err := this.db.QueryContext(context.Background(), `SELECT `+deviceFields+` FROM devices`)
+func (this *Postgres) ListDevices(ctx context.Context, userId string, options options.List) (result []model.Device, total int64, err error, errCode int) {
+	timeout := this.getTimeoutContext(ctx)
+	where, args, err := this.getDeviceWhere(userId, options)
 	if err != nil {
-		return err
-	}
-	for rows.Next() {
-		element, err := scan(rows)
-		if err != nil {
-			return err
-		}
-		err, _ = target.SetDevice(element)
-		if err != nil {
-			return err
-		}
-	}
-	return rows.Err()
-}
-
-func (this *Postgres) ListDevices(userId string, options options.List) (result []model.Device, total int64, err error, errCode int) {
-	timeout := this.getTimeoutContext()
-	parts := strings.Split(options.Sort, ".")
-	sortby := "name"
-	switch parts[0] {
-	case "local_id", "name", "created_at", "updated_at":
-		sortby = parts[0]
-	default:
-		return result, total, errors.New("unknown sort field"), http.StatusBadRequest
+		return result, total, err, http.StatusBadRequest
 	}
-	direction := "ASC"
-	if len(parts) > 1 && parts[1] == "desc" {
-		direction = "DESC"
+	whereArgCount := len(args)
+	orderBy, args, err := getOrderBy(options, args)
+	if err != nil {
+		return result, total, err, http.StatusBadRequest
 	}
 
-	where, args := this.getDeviceWhere(userId, options)
-
 	deviceFields, scan := getDeviceScanInfo()
 
-	query := fmt.Sprintf(`SELECT `+deviceFields+` FROM devices WHERE %v ORDER BY %v %v LIMIT %v OFFSET %v`, where, sortby, direction, options.Limit, options.Offset)
+	query := fmt.Sprintf(`SELECT `+deviceFields+` FROM devices WHERE %v ORDER BY %v LIMIT %v OFFSET %v`, where, orderBy, options.Limit, options.Offset)
 
 	rows, err := this.db.QueryContext(timeout, query, args...)
 	if err != nil {
@@ -149,15 +203,72 @@ func (this *Postgres) ListDevices(userId string, options options.List) (result [
 		}
 		result = append(result, element)
 	}
-	total, err, errCode = this.listDevicesTotal(where, args)
+	total, err, errCode = this.listDevicesTotal(ctx, where, args[:whereArgCount])
 	if err != nil {
 		return result, total, err, errCode
 	}
 	return result, total, nil, http.StatusOK
 }
 
-func (this *Postgres) listDevicesTotal(where string, args []any) (total int64, err error, errCode int) {
-	timeout := this.getTimeoutContext()
+// ExportDevices calls handler for every device matching the options, ignoring limit and offset.
+// The rows are streamed from the database, so they are never held in memory all at once.
+func (this *Postgres) ExportDevices(ctx context.Context, userId string, options options.List, handler func(device model.Device) error) (err error, errCode int) {
+	where, args, err := this.getDeviceWhere(userId, options)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	orderBy, args, err := getOrderBy(options, args)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	deviceFields, scan := getDeviceScanInfo()
+	query := fmt.Sprintf(`SELECT `+deviceFields+` FROM devices WHERE %v ORDER BY %v`, where, orderBy)
+	rows, err := this.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	for rows.Next() {
+		element, err := scan(rows)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+		err = handler(element)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// getOrderBy returns the ORDER BY expression for options.Sort; args are extended by parameters used in the expression
+func getOrderBy(options options.List, args []any) (orderBy string, resultArgs []any, err error) {
+	parts := strings.Split(options.Sort, ".")
+	switch parts[0] {
+	case "local_id", "name", "created_at", "updated_at":
+		direction := "ASC"
+		if len(parts) > 1 && parts[1] == "desc" {
+			direction = "DESC"
+		}
+		return parts[0] + " " + direction, args, nil
+	case "relevance":
+		searchQuery, _ := options.SearchQuery() //already validated by getDeviceWhere
+		if terms := query.TextTerms(searchQuery); len(terms) > 0 {
+			args = append(args, strings.Join(terms, " "))
+			return "word_similarity($" + strconv.Itoa(len(args)) + ", search_text) DESC, local_id ASC", args, nil
+		}
+		return "local_id ASC", args, nil
+	default:
+		return "", args, errors.New("unknown sort field")
+	}
+}
+
+func (this *Postgres) listDevicesTotal(ctx context.Context, where string, args []any) (total int64, err error, errCode int) {
+	timeout := this.getTimeoutContext(ctx)
 	query := fmt.Sprintf(`SELECT COUNT(local_id) FROM devices WHERE %v`, where)
 	row := this.db.QueryRowContext(timeout, query, args...)
 	if err = row.Err(); err != nil {
@@ -173,50 +284,75 @@ func (this *Postgres) listDevicesTotal(where string, args []any) (total int64, e
 	return total, nil, http.StatusOK
 }
 
-func (this *Postgres) getDeviceWhere(userId string, options options.List) (where string, args []any) {
+func (this *Postgres) getDeviceWhere(userId string, options options.List) (where string, args []any, err error) {
 	and := []string{"user_id = $1"}
 	args = []any{userId}
-	if !options.ShowHidden {
+	searchQuery, err := options.SearchQuery()
+	if err != nil {
+		return where, args, err
+	}
+	if options.HideHidden(searchQuery) {
 		args = append(args, false)
 		and = append(and, "hidden = $"+strconv.Itoa(len(args)))
 	}
-	if options.Search != "" {
-		args = append(args, "%"+options.Search+"%")
-		and = append(and, "(name ILIKE $"+strconv.Itoa(len(args))+" OR local_id ILIKE $"+strconv.Itoa(len(args))+")")
+	if searchQuery != nil {
+		condition, err := compileQuery(searchQuery, &args)
+		if err != nil {
+			return where, args, err
+		}
+		and = append(and, condition)
+	}
+	for _, filter := range options.AttributeFilter {
+		contains, err := getAttributeContainment(filter)
+		if err != nil {
+			return where, args, err
+		}
+		args = append(args, contains)
+		and = append(and, "attributes @> $"+strconv.Itoa(len(args))+"::jsonb")
+	}
+	if len(options.DeviceTypeIds) > 0 {
+		args = append(args, pq.Array(options.DeviceTypeIds))
+		and = append(and, "device_type_id = ANY($"+strconv.Itoa(len(args))+")")
 	}
-	return strings.Join(and, " AND "), args
+	timeRanges := []struct {
+		value    time.Time
+		field    string
+		operator string
+	}{
+		{value: options.CreatedAfter, field: "created_at", operator: ">="},
+		{value: options.CreatedBefore, field: "created_at", operator: "<"},
+		{value: options.UpdatedAfter, field: "updated_at", operator: ">="},
+		{value: options.UpdatedBefore, field: "updated_at", operator: "<"},
+	}
+	for _, r := range timeRanges {
+		if !r.value.IsZero() {
+			args = append(args, r.value)
+			and = append(and, r.field+" "+r.operator+" $"+strconv.Itoa(len(args)))
+		}
+	}
+	return strings.Join(and, " AND "), args, nil
 }
 
-func (this *Postgres) ReadDevice(localId string) (result model.Device, err error, errCode int) {
-	timeout := this.getTimeoutContext()
-	query := `SELECT 
-		local_id, 
-		id, 
-		name, 
-		device_type_id, 
-		attributes, 
-		user_id, 
-		hidden, 
-		created_at, 
-		updated_at 
-	FROM devices WHERE local_id = $1 LIMIT 1`
-
-	rows := this.db.QueryRowContext(timeout, query, localId)
-	if err = rows.Err(); err != nil {
-		return result, err, getErrCode(err)
+func getAttributeContainment(filter options.AttributeFilter) (string, error) {
+	element := map[string]string{"key": filter.Key}
+	switch filter.Operation {
+	case options.AttributeEquals:
+		element["value"] = filter.Value
+	case options.AttributeExists:
+	default:
+		return "", errors.New("unknown attribute filter operation")
 	}
-	attrBuf := []byte{}
-	err = rows.Scan(&result.LocalId, &result.Id, &result.Name, &result.DeviceTypeId, &attrBuf, &result.UserId, &result.Hidden, &result.CreatedAt, &result.LastUpdate)
+	buf, err := json.Marshal([]map[string]string{element})
+	return string(buf), err
+}
+
+func (this *Postgres) ReadDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	deviceFields, scan := getDeviceScanInfo()
+	row := this.db.QueryRowContext(this.getTimeoutContext(ctx), `SELECT `+deviceFields+` FROM devices WHERE local_id = $1 LIMIT 1`, localId)
+	result, err = scan(row)
 	if err != nil {
 		return result, err, getErrCode(err)
 	}
-	if err = rows.Err(); err != nil {
-		return result, err, getErrCode(err)
-	}
-	err = json.Unmarshal(attrBuf, &result.Attributes)
-	if err != nil {
-		return result, err, http.StatusInternalServerError
-	}
 	return result, nil, http.StatusOK
 }
 
@@ -231,8 +367,7 @@ func getErrCode(err error) int {
 	}
 }
 
-func (this *Postgres) SetDevice(device model.Device) (error, int) {
-	query := `INSERT INTO devices(local_id, 
+const insertDeviceQuery = `INSERT INTO devices(local_id, 
 			id, 
 			name, 
 			device_type_id, 
@@ -240,8 +375,13 @@ func (this *Postgres) SetDevice(device model.Device) (error, int) {
 			user_id, 
 			hidden, 
 			created_at, 
-			updated_at) 
-	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			updated_at,
+			search_text,
+			reported,
+			overrides) 
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+const setDeviceQuery = insertDeviceQuery + `
 	ON CONFLICT (local_id) DO UPDATE SET
 		  id = EXCLUDED.id,
 		  name = EXCLUDED.name,
@@ -250,39 +390,114 @@ func (this *Postgres) SetDevice(device model.Device) (error, int) {
 		  user_id = EXCLUDED.user_id, 
 		  hidden = EXCLUDED.hidden,
 		  created_at = EXCLUDED.created_at,
-		  updated_at = EXCLUDED.updated_at;`
+		  updated_at = EXCLUDED.updated_at,
+		  search_text = EXCLUDED.search_text,
+		  reported = EXCLUDED.reported,
+		  overrides = EXCLUDED.overrides;`
 
+func getSetDeviceArgs(device model.Device) ([]any, error) {
 	if device.Attributes == nil {
 		device.Attributes = []models.Attribute{}
 	}
 	attrBuf, err := json.Marshal(device.Attributes)
 	if err != nil {
-		return err, http.StatusInternalServerError
+		return nil, err
+	}
+	reportedBuf, overridesBuf, err := marshalOverrides(device)
+	if err != nil {
+		return nil, err
 	}
+	return []any{
+		device.LocalId,            // $1
+		device.Id,                 // $2
+		device.Name,               // $3
+		device.DeviceTypeId,       // $4
+		attrBuf,                   // $5
+		device.UserId,             // $6
+		device.Hidden,             // $7
+		device.CreatedAt,          // $8
+		device.LastUpdate,         // $9
+		search.DeviceText(device), // $10
+		reportedBuf,               // $11
+		overridesBuf,              // $12
+	}, nil
+}
 
-	timeout := this.getTimeoutContext()
+// marshalOverrides returns NULL for devices without overrides
+func marshalOverrides(device model.Device) (reported []byte, overrides []byte, err error) {
+	if device.Reported == nil || len(device.Overrides) == 0 {
+		return nil, nil, nil
+	}
+	reported, err = json.Marshal(device.Reported)
+	if err != nil {
+		return nil, nil, err
+	}
+	overrides, err = json.Marshal(device.Overrides)
+	return reported, overrides, err
+}
 
-	_, err = this.db.ExecContext(timeout, query,
-		device.LocalId,      // $1
-		device.Id,           // $2
-		device.Name,         // $3
-		device.DeviceTypeId, // $4
-		attrBuf,             // $5
-		device.UserId,       // $6
-		device.Hidden,       // $7
-		device.CreatedAt,    // $8
-		device.LastUpdate,   // $9
-	)
+func unmarshalOverrides(reported []byte, overrides []byte, device *model.Device) (err error) {
+	if len(reported) == 0 || len(overrides) == 0 {
+		return nil
+	}
+	device.Reported = &model.Reported{}
+	err = json.Unmarshal(reported, device.Reported)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(overrides, &device.Overrides)
+}
 
+func (this *Postgres) SetDevice(ctx context.Context, device model.Device) (error, int) {
+	args, err := getSetDeviceArgs(device)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	timeout := this.getTimeoutContext(ctx)
+	_, err = this.db.ExecContext(timeout, setDeviceQuery, args...)
 	if err != nil {
 		return err, http.StatusInternalServerError
 	}
 	return nil, http.StatusOK
 }
 
-func (this *Postgres) RemoveDevice(localId string) (error, int) {
+const setDeviceIfUnchangedQuery = `UPDATE devices SET
+		  id = $2,
+		  name = $3,
+		  device_type_id = $4,
+		  attributes = $5,
+		  user_id = $6,
+		  hidden = $7,
+		  created_at = $8,
+		  updated_at = $9,
+		  search_text = $10,
+		  reported = $11,
+		  overrides = $12
+	WHERE local_id = $1 AND updated_at = $13;`
+
+func (this *Postgres) SetDeviceIfUnchanged(ctx context.Context, device model.Device, lastUpdate time.Time) (error, int) {
+	args, err := getSetDeviceArgs(device)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	timeout := this.getTimeoutContext(ctx)
+	result, err := this.db.ExecContext(timeout, setDeviceIfUnchangedQuery, append(args, lastUpdate)...)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	if count == 0 {
+		return errors.New("device has been changed concurrently"), http.StatusConflict
+	}
+	return nil, http.StatusOK
+}
+
+func (this *Postgres) RemoveDevice(ctx context.Context, localId string) (error, int) {
 	query := "DELETE FROM devices WHERE local_id = $1"
-	timeout := this.getTimeoutContext()
+	timeout := this.getTimeoutContext(ctx)
 	_, err := this.db.ExecContext(timeout, query, localId)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"github.com/lib/pq"
+	"net/http"
+)
+
+func init() {
+	Migrations = append(Migrations,
+		schema.Migration[*sql.Tx]{Version: 5, Description: "create device_history table", Up: createDeviceHistoryTable},
+	)
+}
+
+// uniqueViolation is the postgres error code of a duplicate key
+const uniqueViolation = "23505"
+
+func createDeviceHistoryTable(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS device_history (
+			local_id TEXT,
+			version BIGINT,
+			change TEXT,
+			time timestamptz,
+			user_id TEXT,
+			device JSONB,
+			PRIMARY KEY (local_id, version));`,
+	)
+}
+
+const addDeviceVersionQuery = `INSERT INTO device_history(local_id, version, change, time, user_id, device)
+	SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, $5 FROM device_history WHERE local_id = $1
+	RETURNING version;`
+
+func (this *Postgres) AddDeviceVersion(ctx context.Context, version model.DeviceVersion, keep int64) (result model.DeviceVersion, err error, errCode int) {
+	version.Diff = nil
+	deviceBuf, err := json.Marshal(version.Device)
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	timeout := this.getTimeoutContext(ctx)
+	err = this.db.QueryRowContext(timeout, addDeviceVersionQuery, version.LocalId, version.Change, version.Time, version.Device.UserId, deviceBuf).Scan(&version.Version)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return result, err, http.StatusConflict
+	}
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	_, err = this.db.ExecContext(timeout, `DELETE FROM device_history WHERE local_id = $1 AND version <= $2`, version.LocalId, version.Version-keep)
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return version, nil, http.StatusOK
+}
+
+const deviceVersionFields = `local_id, version, change, time, device`
+
+func scanDeviceVersion(row scanner) (version model.DeviceVersion, err error) {
+	deviceBuf := []byte{}
+	err = row.Scan(&version.LocalId, &version.Version, &version.Change, &version.Time, &deviceBuf)
+	if err != nil {
+		return version, err
+	}
+	err = json.Unmarshal(deviceBuf, &version.Device)
+	return version, err
+}
+
+func (this *Postgres) ListDeviceVersions(ctx context.Context, userId string, localId string) (result []model.DeviceVersion, err error, errCode int) {
+	rows, err := this.db.QueryContext(this.getTimeoutContext(ctx), `SELECT `+deviceVersionFields+` FROM device_history WHERE local_id = $1 AND user_id = $2 ORDER BY version DESC`, localId, userId)
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	result = []model.DeviceVersion{}
+	for rows.Next() {
+		element, err := scanDeviceVersion(rows)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+		result = append(result, element)
+	}
+	if err = rows.Err(); err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *Postgres) ReadDeviceVersion(ctx context.Context, userId string, localId string, version int64) (result model.DeviceVersion, err error, errCode int) {
+	row := this.db.QueryRowContext(this.getTimeoutContext(ctx), `SELECT `+deviceVersionFields+` FROM device_history WHERE local_id = $1 AND user_id = $2 AND version = $3`, localId, userId, version)
+	result, err = scanDeviceVersion(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, errors.New("version not found"), http.StatusNotFound
+	}
+	if err != nil {
+		return result, err, http.StatusInternalServerError
+	}
+	return result, nil, http.StatusOK
+}
@@ -21,7 +21,7 @@ import (
 	"database/sql"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
 	_ "github.com/lib/pq"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -30,8 +30,6 @@ type Postgres struct {
 	db *sql.DB
 }
 
-var CreateTables = []func(db *Postgres) error{}
-
 func New(ctx context.Context, wg *sync.WaitGroup, conf configuration.Config) (*Postgres, error) {
 	db, err := sql.Open("postgres", conf.PostgresConnStr)
 	if err != nil {
@@ -39,16 +37,15 @@ func New(ctx context.Context, wg *sync.WaitGroup, conf configuration.Config) (*P
 	}
 	err = db.Ping()
 	if err != nil {
-		log.Println("ERROR: ping=", err)
+		slog.Error("unable to ping postgres", "error", err)
 		return nil, err
 	}
 	client := &Postgres{db: db}
-	for _, creators := range CreateTables {
-		err = creators(client)
-		if err != nil {
-			client.disconnect()
-			return nil, err
-		}
+	err = client.migrateSchema()
+	if err != nil {
+		slog.Error("unable to migrate schema", "error", err)
+		client.disconnect()
+		return nil, err
 	}
 	if wg != nil {
 		wg.Add(1)
@@ -63,11 +60,20 @@ func New(ctx context.Context, wg *sync.WaitGroup, conf configuration.Config) (*P
 	return client, nil
 }
 
+func (this *Postgres) Ping(ctx context.Context) error {
+	return this.db.PingContext(this.getTimeoutContext(ctx))
+}
+
 func (this *Postgres) disconnect() {
-	log.Println("disconnect postgres:", this.db.Close())
+	err := this.db.Close()
+	if err != nil {
+		slog.Error("unable to disconnect postgres", "error", err)
+		return
+	}
+	slog.Info("disconnected postgres")
 }
 
-func (this *Postgres) getTimeoutContext() context.Context {
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second)
+func (this *Postgres) getTimeoutContext(ctx context.Context) context.Context {
+	ctx, _ = context.WithTimeout(ctx, 10*time.Second)
 	return ctx
 }
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/query"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/search"
+	"strconv"
+	"strings"
+)
+
+var queryFieldColumns = map[query.Field]string{
+	query.FieldName:         "name",
+	query.FieldLocalId:      "local_id",
+	query.FieldId:           "id",
+	query.FieldDeviceTypeId: "device_type_id",
+}
+
+// compileQuery returns a sql condition for the node; values are appended to args and referenced as positional parameters
+func compileQuery(node query.Node, args *[]any) (string, error) {
+	param := func(value any) string {
+		*args = append(*args, value)
+		return "$" + strconv.Itoa(len(*args))
+	}
+	switch n := node.(type) {
+	case query.And:
+		return compileQueryList(n.Children, " AND ", args)
+	case query.Or:
+		return compileQueryList(n.Children, " OR ", args)
+	case query.Not:
+		child, err := compileQuery(n.Child, args)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + child + ")", nil
+	case query.Text:
+		terms := search.Terms(n.Value)
+		if len(terms) == 0 {
+			return "TRUE", nil
+		}
+		conditions := []string{}
+		//terms consist only of lower case letters and digits and need no escaping in like patterns
+		for _, term := range terms {
+			conditions = append(conditions, "search_text LIKE "+param("%"+term+"%"))
+		}
+		return "(" + strings.Join(conditions, " AND ") + ")", nil
+	case query.Match:
+		column, ok := queryFieldColumns[n.Field]
+		if !ok {
+			return "", errors.New("unknown search field " + string(n.Field))
+		}
+		return column + " ILIKE " + param(getLikePattern(n.Pattern)), nil
+	case query.AttributeMatch:
+		return `EXISTS (SELECT 1 FROM jsonb_array_elements(CASE WHEN jsonb_typeof(attributes) = 'array' THEN attributes ELSE '[]'::jsonb END) AS attr 
+			WHERE attr->>'key' = ` + param(n.Key) + ` AND attr->>'value' ILIKE ` + param(getLikePattern(n.Pattern)) + `)`, nil
+	case query.AttributeExists:
+		contains, err := getAttributeContainment(options.AttributeFilter{Key: n.Key, Operation: options.AttributeExists})
+		if err != nil {
+			return "", err
+		}
+		return "attributes @> " + param(contains) + "::jsonb", nil
+	case query.Hidden:
+		return "hidden = " + param(n.Value), nil
+	default:
+		return "", errors.New("unknown search expression")
+	}
+}
+
+func compileQueryList(nodes []query.Node, operator string, args *[]any) (string, error) {
+	conditions := []string{}
+	for _, node := range nodes {
+		condition, err := compileQuery(node, args)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+	return "(" + strings.Join(conditions, operator) + ")", nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func getLikePattern(pattern query.Pattern) string {
+	parts := []string{}
+	for _, part := range pattern.Parts() {
+		parts = append(parts, likeEscaper.Replace(part))
+	}
+	return strings.Join(parts, "%")
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"log/slog"
+	"time"
+)
+
+// Migrations are applied in order of their version, each in its own transaction
+var Migrations = []schema.Migration[*sql.Tx]{}
+
+// schemaLockId identifies the advisory lock, which prevents concurrently starting instances from migrating at the same time
+const schemaLockId int64 = 0x6477_7273_6368 //"dwrsch"
+
+func (this *Postgres) migrateSchema() error {
+	migrations, err := schema.Sorted(Migrations)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	//advisory locks belong to a session, so lock, migrations and unlock have to use the same connection
+	conn, err := this.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, schemaLockId)
+	if err != nil {
+		return fmt.Errorf("unable to lock schema: %w", err)
+	}
+	defer func() {
+		_, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, schemaLockId)
+		if err != nil {
+			slog.Error("unable to unlock schema", "error", err)
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		description TEXT,
+		applied_at timestamptz);`)
+	if err != nil {
+		return fmt.Errorf("unable to create schema_migrations table: %w", err)
+	}
+	current := 0
+	err = conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("unable to read schema version: %w", err)
+	}
+	if latest := schema.Latest(migrations); current > latest {
+		slog.Warn("postgres schema version is newer than the latest known version", "version", current, "latest", latest)
+	}
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+		slog.Info("apply postgres schema migration", "version", migration.Version, "description", migration.Description)
+		err = applyMigration(ctx, conn, migration)
+		if err != nil {
+			return fmt.Errorf("unable to apply schema migration %v (%v): %w", migration.Version, migration.Description, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, migration schema.Migration[*sql.Tx]) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = migration.Up(tx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations(version, description, applied_at) VALUES ($1, $2, $3)`, migration.Version, migration.Description, time.Now())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SchemaStatus lists the known migrations and if they have been applied, without applying pending migrations
+func SchemaStatus(conf configuration.Config) (result []schema.Status, err error) {
+	db, err := sql.Open("postgres", conf.PostgresConnStr)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	applied := map[int]time.Time{}
+	exists := false
+	err = db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		rows, err := db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var version int
+			var appliedAt time.Time
+			err = rows.Scan(&version, &appliedAt)
+			if err != nil {
+				return nil, err
+			}
+			applied[version] = appliedAt
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return schema.GetStatus(Migrations, applied)
+}
@@ -0,0 +1,307 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package query
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parse parses a search query. An empty query results in a nil Node.
+// Invalid queries result in a *SyntaxError.
+func Parse(input string) (Node, error) {
+	tokens, end, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	p := &parser{tokens: tokens, end: end}
+	result, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, &SyntaxError{Position: t.pos, Message: "unexpected ')'"}
+	}
+	return result, nil
+}
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenWord
+	tokenOpen
+	tokenClose
+	tokenMinus
+)
+
+type token struct {
+	typ         tokenType
+	pos         int
+	text        []rune
+	quoted      bool //word contains quoted parts
+	fullyQuoted bool //word consists of a single quoted part
+	sep         int  //index of the first unquoted ':' in text, -1 if none
+	eq          int  //index of the first unquoted '=' in text, -1 if none
+}
+
+func isWordEnd(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')'
+}
+
+func lex(input string) (tokens []token, end int, err error) {
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{typ: tokenOpen, pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{typ: tokenClose, pos: i})
+			i++
+		case r == '-':
+			tokens = append(tokens, token{typ: tokenMinus, pos: i})
+			i++
+		default:
+			word := token{typ: tokenWord, pos: i, sep: -1, eq: -1, fullyQuoted: r == '"'}
+			for i < len(runes) && !isWordEnd(runes[i]) {
+				if runes[i] != '"' {
+					word.fullyQuoted = false
+					if runes[i] == ':' && word.sep < 0 {
+						word.sep = len(word.text)
+					}
+					if runes[i] == '=' && word.eq < 0 {
+						word.eq = len(word.text)
+					}
+					word.text = append(word.text, runes[i])
+					i++
+					continue
+				}
+				quoteStart := i
+				if word.quoted {
+					word.fullyQuoted = false
+				}
+				word.quoted = true
+				closed := false
+				for i++; i < len(runes); i++ {
+					if runes[i] == '\\' && i+1 < len(runes) {
+						i++
+						word.text = append(word.text, runes[i])
+						continue
+					}
+					if runes[i] == '"' {
+						closed = true
+						i++
+						break
+					}
+					word.text = append(word.text, runes[i])
+				}
+				if !closed {
+					return nil, len(runes), &SyntaxError{Position: quoteStart, Message: "missing closing quote"}
+				}
+			}
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens, len(runes), nil
+}
+
+type parser struct {
+	tokens []token
+	index  int
+	end    int
+}
+
+func (this *parser) peek() token {
+	if this.index >= len(this.tokens) {
+		return token{typ: tokenEOF, pos: this.end}
+	}
+	return this.tokens[this.index]
+}
+
+func (this *parser) next() token {
+	result := this.peek()
+	if this.index < len(this.tokens) {
+		this.index++
+	}
+	return result
+}
+
+func isKeyword(t token, keyword string) bool {
+	return t.typ == tokenWord && !t.quoted && string(t.text) == keyword
+}
+
+func isExpressionEnd(t token) bool {
+	return t.typ == tokenEOF || t.typ == tokenClose || isKeyword(t, "OR") || isKeyword(t, "AND")
+}
+
+func expectedExpressionError(t token) error {
+	switch {
+	case t.typ == tokenEOF:
+		return &SyntaxError{Position: t.pos, Message: "unexpected end of search, expected expression"}
+	case t.typ == tokenClose:
+		return &SyntaxError{Position: t.pos, Message: "unexpected ')', expected expression"}
+	default:
+		return &SyntaxError{Position: t.pos, Message: "unexpected " + string(t.text) + ", expected expression"}
+	}
+}
+
+func (this *parser) parseOr() (Node, error) {
+	first, err := this.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []Node{first}
+	for isKeyword(this.peek(), "OR") {
+		this.next()
+		child, err := this.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return Or{Children: children}, nil
+}
+
+func (this *parser) parseAnd() (Node, error) {
+	children := []Node{}
+	for {
+		t := this.peek()
+		if t.typ == tokenEOF || t.typ == tokenClose || isKeyword(t, "OR") {
+			break
+		}
+		if isKeyword(t, "AND") {
+			if len(children) == 0 {
+				return nil, expectedExpressionError(t)
+			}
+			this.next()
+			if next := this.peek(); isExpressionEnd(next) {
+				return nil, expectedExpressionError(next)
+			}
+			continue
+		}
+		child, err := this.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	switch len(children) {
+	case 0:
+		return nil, expectedExpressionError(this.peek())
+	case 1:
+		return children[0], nil
+	default:
+		return And{Children: children}, nil
+	}
+}
+
+func (this *parser) parseUnary() (Node, error) {
+	t := this.peek()
+	if t.typ == tokenMinus || isKeyword(t, "NOT") {
+		this.next()
+		if next := this.peek(); isExpressionEnd(next) {
+			return nil, expectedExpressionError(next)
+		}
+		child, err := this.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Child: child}, nil
+	}
+	return this.parsePrimary()
+}
+
+func (this *parser) parsePrimary() (Node, error) {
+	t := this.next()
+	switch t.typ {
+	case tokenOpen:
+		if next := this.peek(); next.typ == tokenClose {
+			return nil, &SyntaxError{Position: t.pos, Message: "empty parentheses"}
+		}
+		result, err := this.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if this.peek().typ != tokenClose {
+			return nil, &SyntaxError{Position: t.pos, Message: "missing closing parenthesis"}
+		}
+		this.next()
+		return result, nil
+	case tokenWord:
+		return parseWord(t)
+	default:
+		return nil, expectedExpressionError(t)
+	}
+}
+
+func parseWord(t token) (Node, error) {
+	text := string(t.text)
+	if t.fullyQuoted {
+		return Text{Value: text}, nil
+	}
+	prefixLen := len([]rune(attributePrefix))
+	if strings.HasPrefix(text, attributePrefix) && (t.eq < 0 || t.eq >= prefixLen) {
+		if t.eq < 0 {
+			key := string(t.text[prefixLen:])
+			if key == "" {
+				return nil, &SyntaxError{Position: t.pos, Message: "missing attribute key after " + attributePrefix}
+			}
+			return AttributeExists{Key: key}, nil
+		}
+		key := string(t.text[prefixLen:t.eq])
+		value := string(t.text[t.eq+1:])
+		if key == "" {
+			return nil, &SyntaxError{Position: t.pos, Message: "missing attribute key after " + attributePrefix}
+		}
+		if value == "" {
+			return nil, &SyntaxError{Position: t.pos + t.eq + 1, Message: "missing value for attribute " + key}
+		}
+		return AttributeMatch{Key: key, Pattern: Pattern(value)}, nil
+	}
+	if t.sep >= 0 {
+		name := strings.ToLower(string(t.text[:t.sep]))
+		value := string(t.text[t.sep+1:])
+		if name == hiddenFieldName {
+			hidden, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, &SyntaxError{Position: t.pos + t.sep + 1, Message: "expected true or false for hidden"}
+			}
+			return Hidden{Value: hidden}, nil
+		}
+		if field, ok := fieldNames[name]; ok {
+			if value == "" {
+				return nil, &SyntaxError{Position: t.pos + t.sep + 1, Message: "missing value for field " + name}
+			}
+			return Match{Field: field, Pattern: Pattern(value)}, nil
+		}
+	}
+	if !t.quoted && strings.ToLower(text) == hiddenFieldName {
+		return Hidden{Value: true}, nil
+	}
+	return Text{Value: text}, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected Node
+	}{
+		{input: "", expected: nil},
+		{input: "   ", expected: nil},
+		{input: "therm", expected: Text{Value: "therm"}},
+		{input: "HEAT_COST_ALLOCATOR", expected: Text{Value: "HEAT_COST_ALLOCATOR"}},
+		{input: `"living room"`, expected: Text{Value: "living room"}},
+		{input: `"say \"hi\""`, expected: Text{Value: `say "hi"`}},
+		{input: "urn:infai:ses:device:1", expected: Text{Value: "urn:infai:ses:device:1"}},
+		{input: "name:therm*", expected: Match{Field: FieldName, Pattern: "therm*"}},
+		{input: `name:"living room*"`, expected: Match{Field: FieldName, Pattern: "living room*"}},
+		{input: "type:urn:infai:ses:device-type:1", expected: Match{Field: FieldDeviceTypeId, Pattern: "urn:infai:ses:device-type:1"}},
+		{input: "local_id:foo", expected: Match{Field: FieldLocalId, Pattern: "foo"}},
+		{input: "attr.gateway=gw-12", expected: AttributeMatch{Key: "gateway", Pattern: "gw-12"}},
+		{input: "attr.a=b=c", expected: AttributeMatch{Key: "a", Pattern: "b=c"}},
+		{input: "attr.gateway", expected: AttributeExists{Key: "gateway"}},
+		{input: "hidden", expected: Hidden{Value: true}},
+		{input: "hidden:false", expected: Hidden{Value: false}},
+		{input: `"hidden"`, expected: Text{Value: "hidden"}},
+		{input: "-hidden", expected: Not{Child: Hidden{Value: true}}},
+		{input: "NOT therm", expected: Not{Child: Text{Value: "therm"}}},
+		{input: "gw-12", expected: Text{Value: "gw-12"}},
+		{
+			input: "name:therm* type:urn:x attr.gateway=gw-12 -hidden",
+			expected: And{Children: []Node{
+				Match{Field: FieldName, Pattern: "therm*"},
+				Match{Field: FieldDeviceTypeId, Pattern: "urn:x"},
+				AttributeMatch{Key: "gateway", Pattern: "gw-12"},
+				Not{Child: Hidden{Value: true}},
+			}},
+		},
+		{
+			input: "a b OR c AND d",
+			expected: Or{Children: []Node{
+				And{Children: []Node{Text{Value: "a"}, Text{Value: "b"}}},
+				And{Children: []Node{Text{Value: "c"}, Text{Value: "d"}}},
+			}},
+		},
+		{
+			input: "a (b OR -(c d))",
+			expected: And{Children: []Node{
+				Text{Value: "a"},
+				Or{Children: []Node{
+					Text{Value: "b"},
+					Not{Child: And{Children: []Node{Text{Value: "c"}, Text{Value: "d"}}}},
+				}},
+			}},
+		},
+	}
+	for _, c := range cases {
+		actual, err := Parse(c.input)
+		if err != nil {
+			t.Error(c.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%v:\n%#v\n%#v\n", c.input, actual, c.expected)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		input    string
+		position int
+	}{
+		{input: "(therm", position: 0},
+		{input: "therm)", position: 5},
+		{input: "a OR", position: 4},
+		{input: "OR a", position: 0},
+		{input: "a AND", position: 5},
+		{input: "a -", position: 3},
+		{input: "a ()", position: 2},
+		{input: `name:"therm`, position: 5},
+		{input: "name:", position: 5},
+		{input: "attr.=x", position: 0},
+		{input: "attr.gateway=", position: 13},
+		{input: "hidden:maybe", position: 7},
+	}
+	for _, c := range cases {
+		_, err := Parse(c.input)
+		syntaxErr := &SyntaxError{}
+		if !errors.As(err, &syntaxErr) {
+			t.Error(c.input, err)
+			continue
+		}
+		if syntaxErr.Position != c.position {
+			t.Error(c.input, syntaxErr)
+		}
+	}
+}
+
+func TestReferencesHidden(t *testing.T) {
+	node, err := Parse("a (b OR -hidden)")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !ReferencesHidden(node) {
+		t.Error("expected hidden reference")
+	}
+	node, err = Parse("a (b OR -c)")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ReferencesHidden(node) {
+		t.Error("unexpected hidden reference")
+	}
+}
+
+func TestTextTerms(t *testing.T) {
+	node, err := Parse("Living-Room (therm OR name:x) -lamp")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	actual := TextTerms(node)
+	expected := []string{"living", "room", "therm"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error(actual, expected)
+	}
+}
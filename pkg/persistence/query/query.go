@@ -0,0 +1,164 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package query parses the search parameter into a backend-neutral filter tree.
+//
+// A query is a list of expressions, which all have to match (implicit AND):
+//
+//	therm                  free text, matched like a plain search (see package search)
+//	"living room"          quoted free text
+//	name:therm*            case-insensitive match of name, local_id, id or type (device_type_id); '*' matches any text
+//	attr.gateway=gw-12     case-insensitive match of the value of the attribute with the key gateway; '*' matches any text
+//	attr.gateway           device has an attribute with the key gateway
+//	hidden, hidden:false   hidden state of the device
+//	-expr, NOT expr        negation
+//	a OR b, a AND b, (a b) boolean operators and grouping
+//
+// Words with an unknown field prefix (e.g. urn:infai:ses) are handled as free text.
+package query
+
+import (
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/search"
+	"strings"
+)
+
+type Node interface {
+	node()
+}
+
+type And struct {
+	Children []Node
+}
+
+type Or struct {
+	Children []Node
+}
+
+type Not struct {
+	Child Node
+}
+
+// Text matches devices like a plain search for Value
+type Text struct {
+	Value string
+}
+
+// Match matches a device field against Pattern
+type Match struct {
+	Field   Field
+	Pattern Pattern
+}
+
+// AttributeMatch matches devices with an attribute of the given Key and a value matching Pattern
+type AttributeMatch struct {
+	Key     string
+	Pattern Pattern
+}
+
+// AttributeExists matches devices with an attribute of the given Key
+type AttributeExists struct {
+	Key string
+}
+
+// Hidden matches devices with the given hidden state
+type Hidden struct {
+	Value bool
+}
+
+func (And) node()             {}
+func (Or) node()              {}
+func (Not) node()             {}
+func (Text) node()            {}
+func (Match) node()           {}
+func (AttributeMatch) node()  {}
+func (AttributeExists) node() {}
+func (Hidden) node()          {}
+
+type Field string
+
+const FieldName Field = "name"
+const FieldLocalId Field = "local_id"
+const FieldId Field = "id"
+const FieldDeviceTypeId Field = "device_type_id"
+
+var fieldNames = map[string]Field{
+	"name":           FieldName,
+	"local_id":       FieldLocalId,
+	"id":             FieldId,
+	"device_type_id": FieldDeviceTypeId,
+	"type":           FieldDeviceTypeId,
+}
+
+const hiddenFieldName = "hidden"
+const attributePrefix = "attr."
+
+// Pattern is a case-insensitive pattern, where '*' matches any text and every other character matches itself
+type Pattern string
+
+// Parts returns the literal parts of the pattern between the wildcards
+func (this Pattern) Parts() []string {
+	return strings.Split(string(this), "*")
+}
+
+type SyntaxError struct {
+	Position int //position of the error in characters, starting with 0
+	Message  string
+}
+
+func (this *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid search at position %v: %v", this.Position, this.Message)
+}
+
+// ReferencesHidden checks if the query filters by the hidden state, in which case the default hiding of hidden devices should not be applied
+func ReferencesHidden(node Node) bool {
+	switch n := node.(type) {
+	case Hidden:
+		return true
+	case Not:
+		return ReferencesHidden(n.Child)
+	case And:
+		for _, child := range n.Children {
+			if ReferencesHidden(child) {
+				return true
+			}
+		}
+	case Or:
+		for _, child := range n.Children {
+			if ReferencesHidden(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TextTerms returns the search terms of all not negated Text nodes, to rank results by relevance
+func TextTerms(node Node) (result []string) {
+	switch n := node.(type) {
+	case Text:
+		return search.Terms(n.Value)
+	case And:
+		for _, child := range n.Children {
+			result = append(result, TextTerms(child)...)
+		}
+	case Or:
+		for _, child := range n.Children {
+			result = append(result, TextTerms(child)...)
+		}
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package schema describes the versioned schema migrations of the persistence implementations.
+//
+// Every implementation keeps an ordered list of up migrations. On startup, all migrations with a version
+// greater than the stored schema version are applied in order, and the version is stored with every applied migration.
+// Migrations have to be idempotent, because deployments from before the schema version existed already contain parts of the schema.
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Status describes a migration known to the service and if it has been applied to the database
+type Status struct {
+	Version     int       `json:"version"`
+	Description string    `json:"description"`
+	AppliedAt   time.Time `json:"applied_at,omitempty"` //zero if the migration is pending
+}
+
+func (this Status) Applied() bool {
+	return !this.AppliedAt.IsZero()
+}
+
+// Migration is an up migration for a database handle of type T
+type Migration[T any] struct {
+	Version     int
+	Description string
+	Up          func(db T) error
+}
+
+// Sorted returns the migrations ordered by version and checks that versions are positive and unique
+func Sorted[T any](migrations []Migration[T]) ([]Migration[T], error) {
+	result := append([]Migration[T]{}, migrations...)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	for i, migration := range result {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("invalid schema migration version %v", migration.Version)
+		}
+		if i > 0 && result[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("duplicate schema migration version %v", migration.Version)
+		}
+	}
+	return result, nil
+}
+
+// GetStatus combines the known migrations with the application times stored in the database
+func GetStatus[T any](migrations []Migration[T], applied map[int]time.Time) (result []Status, err error) {
+	migrations, err = Sorted(migrations)
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range migrations {
+		result = append(result, Status{Version: migration.Version, Description: migration.Description, AppliedAt: applied[migration.Version]})
+	}
+	//versions applied by a newer release of the service
+	for version, appliedAt := range applied {
+		if !containsVersion(migrations, version) {
+			result = append(result, Status{Version: version, Description: "unknown", AppliedAt: appliedAt})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+func containsVersion[T any](migrations []Migration[T], version int) bool {
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Latest returns the highest known version
+func Latest[T any](migrations []Migration[T]) (result int) {
+	for _, migration := range migrations {
+		if migration.Version > result {
+			result = migration.Version
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package schema
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSorted(t *testing.T) {
+	migrations := []Migration[int]{{Version: 3}, {Version: 1}, {Version: 2}}
+	sorted, err := Sorted(migrations)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i, migration := range sorted {
+		if migration.Version != i+1 {
+			t.Error(sorted)
+		}
+	}
+	if migrations[0].Version != 3 {
+		t.Error("input should not be modified")
+	}
+	_, err = Sorted([]Migration[int]{{Version: 1}, {Version: 1}})
+	if err == nil {
+		t.Error("expected error for duplicate version")
+	}
+	_, err = Sorted([]Migration[int]{{Version: 0}})
+	if err == nil {
+		t.Error("expected error for version 0")
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	appliedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	status, err := GetStatus([]Migration[int]{{Version: 2, Description: "b"}, {Version: 1, Description: "a"}}, map[int]time.Time{1: appliedAt, 3: appliedAt})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expected := []Status{
+		{Version: 1, Description: "a", AppliedAt: appliedAt},
+		{Version: 2, Description: "b"},
+		{Version: 3, Description: "unknown", AppliedAt: appliedAt},
+	}
+	if !reflect.DeepEqual(status, expected) {
+		t.Errorf("\n%#v\n%#v\n", status, expected)
+	}
+	if !status[0].Applied() || status[1].Applied() {
+		t.Error(status)
+	}
+}
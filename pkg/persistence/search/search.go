@@ -0,0 +1,95 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package search defines the search semantics shared by all persistence implementations:
+// the searchable text of a device and the search term are split into lower case tokens of letters and digits,
+// and a device matches if every search term is a substring of one of its tokens.
+package search
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"strings"
+	"unicode"
+)
+
+// MaxNgramLength limits the length of the n-grams returned by Ngrams.
+// Implementations using n-grams have to check longer terms against the device text.
+const MaxNgramLength = 12
+
+// Tokenize splits text into lower case tokens of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Terms returns the tokens of a search text. A device matches if every term is contained in its DeviceText.
+func Terms(search string) []string {
+	return Tokenize(search)
+}
+
+// DeviceTokens returns the distinct tokens of the searchable device fields (local_id, name, device_type_id and attribute values).
+func DeviceTokens(device model.Device) (result []string) {
+	fields := []string{device.LocalId, device.Name, device.DeviceTypeId}
+	for _, attr := range device.Attributes {
+		fields = append(fields, attr.Value)
+	}
+	known := map[string]bool{}
+	for _, field := range fields {
+		for _, token := range Tokenize(field) {
+			if !known[token] {
+				known[token] = true
+				result = append(result, token)
+			}
+		}
+	}
+	return result
+}
+
+// DeviceText returns the tokens of DeviceTokens separated by spaces.
+// Because terms never contain spaces, a term is a substring of DeviceText exactly if it is a substring of one of the tokens.
+func DeviceText(device model.Device) string {
+	return strings.Join(DeviceTokens(device), " ")
+}
+
+// Ngrams returns all distinct substrings of the tokens with a length up to MaxNgramLength.
+// A term with up to MaxNgramLength characters is contained in a token exactly if it is one of its n-grams.
+func Ngrams(tokens []string) (result []string) {
+	known := map[string]bool{}
+	for _, token := range tokens {
+		runes := []rune(token)
+		for start := range runes {
+			for end := start + 1; end <= len(runes) && end-start <= MaxNgramLength; end++ {
+				ngram := string(runes[start:end])
+				if !known[ngram] {
+					known[ngram] = true
+					result = append(result, ngram)
+				}
+			}
+		}
+	}
+	return result
+}
+
+// NgramOf returns the n-gram that has to be contained in the Ngrams of a device matching the term.
+// The second return value is false if the term is longer than MaxNgramLength and has to be checked additionally against the device text.
+func NgramOf(term string) (ngram string, complete bool) {
+	runes := []rune(term)
+	if len(runes) <= MaxNgramLength {
+		return term, true
+	}
+	return string(runes[:MaxNgramLength]), false
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package search
+
+import (
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestDeviceText(t *testing.T) {
+	device := model.Device{
+		Device: models.Device{
+			LocalId:      "HEAT_COST_ALLOCATOR",
+			Name:         "Heat Thermostat",
+			DeviceTypeId: "urn:infai:ses:device-type:42",
+			Attributes:   []models.Attribute{{Key: "gateway", Value: "gw-12"}},
+		},
+	}
+	actual := DeviceText(device)
+	expected := "heat cost allocator thermostat urn infai ses device type 42 gw 12"
+	if actual != expected {
+		t.Errorf("\n%#v\n%#v\n", actual, expected)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	actual := Terms(" Therm  HEAT_cost-42 ")
+	expected := []string{"therm", "heat", "cost", "42"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("\n%#v\n%#v\n", actual, expected)
+	}
+}
+
+func TestNgrams(t *testing.T) {
+	ngrams := Ngrams([]string{"thermostat", "2520611"})
+	for _, expected := range []string{"t", "therm", "mostat", "thermostat", "520", "2520611"} {
+		if !slices.Contains(ngrams, expected) {
+			t.Error("missing", expected)
+		}
+	}
+	if slices.Contains(ngrams, "tat2") {
+		t.Error("unexpected n-gram across tokens")
+	}
+	long := Ngrams([]string{"abcdefghijklmnopqrstuvwxyz"})
+	for _, ngram := range long {
+		if len(ngram) > MaxNgramLength {
+			t.Error("unexpected long n-gram", ngram)
+		}
+	}
+	ngram, complete := NgramOf("abcdefghijklmnopqrstuvwxyz")
+	if complete || ngram != "abcdefghijkl" {
+		t.Error(ngram, complete)
+	}
+	ngram, complete = NgramOf("therm")
+	if !complete || ngram != "therm" {
+		t.Error(ngram, complete)
+	}
+}
@@ -0,0 +1,484 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/query"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/search"
+	"github.com/SENERGY-Platform/models/go/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Migrations = append(Migrations,
+		schema.Migration[*sql.Tx]{Version: 1, Description: "create devices table with full text search", Up: createDevicesTable},
+		schema.Migration[*sql.Tx]{Version: 2, Description: "add reported and overrides", Up: addOverrides},
+	)
+}
+
+func execAll(tx *sql.Tx, statements ...string) error {
+	for _, statement := range statements {
+		_, err := tx.ExecContext(context.Background(), statement)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createDevicesTable creates the devices table and the fts5 index of its search_text.
+// The trigram tokenizer allows substring matches of terms with at least 3 characters.
+// row_id is an explicit alias of the rowid, which is referenced by the index and would otherwise be changed by VACUUM.
+func createDevicesTable(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS devices (
+			row_id INTEGER PRIMARY KEY,
+			local_id TEXT NOT NULL UNIQUE,
+			id TEXT,
+			name TEXT,
+			device_type_id TEXT,
+			attributes TEXT,
+			user_id TEXT,
+			hidden INTEGER,
+			created_at TEXT,
+			updated_at TEXT,
+			search_text TEXT);`,
+		`CREATE INDEX IF NOT EXISTS devices_user_id_idx ON devices (user_id, local_id);`,
+		`CREATE INDEX IF NOT EXISTS devices_device_type_id_idx ON devices (device_type_id);`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS devices_fts USING fts5(search_text, content='devices', content_rowid='row_id', tokenize='trigram');`,
+		`CREATE TRIGGER IF NOT EXISTS devices_fts_insert AFTER INSERT ON devices BEGIN
+			INSERT INTO devices_fts(rowid, search_text) VALUES (new.row_id, new.search_text);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS devices_fts_delete AFTER DELETE ON devices BEGIN
+			INSERT INTO devices_fts(devices_fts, rowid, search_text) VALUES ('delete', old.row_id, old.search_text);
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS devices_fts_update AFTER UPDATE ON devices BEGIN
+			INSERT INTO devices_fts(devices_fts, rowid, search_text) VALUES ('delete', old.row_id, old.search_text);
+			INSERT INTO devices_fts(rowid, search_text) VALUES (new.row_id, new.search_text);
+		END;`,
+	)
+}
+
+func addOverrides(tx *sql.Tx) error {
+	return execAll(tx,
+		`ALTER TABLE devices ADD COLUMN reported TEXT;`,
+		`ALTER TABLE devices ADD COLUMN overrides TEXT;`,
+	)
+}
+
+// timeLayout has a fixed width, so that stored times are ordered like the text
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(timeLayout, value)
+}
+
+// scanner is implemented by *sql.Rows and *sql.Row
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func getDeviceScanInfo() (selectFields string, scan func(rows scanner) (model.Device, error)) {
+	return `local_id, 
+		id, 
+		name, 
+		device_type_id, 
+		attributes, 
+		user_id, 
+		hidden, 
+		created_at, 
+		updated_at,
+		reported,
+		overrides`,
+		func(rows scanner) (device model.Device, err error) {
+			attrBuf := []byte{}
+			createdAt := ""
+			updatedAt := ""
+			reported := sql.NullString{}
+			overrides := sql.NullString{}
+			err = rows.Scan(&device.LocalId, &device.Id, &device.Name, &device.DeviceTypeId, &attrBuf, &device.UserId, &device.Hidden, &createdAt, &updatedAt, &reported, &overrides)
+			if err != nil {
+				return device, err
+			}
+			device.CreatedAt, err = parseTime(createdAt)
+			if err != nil {
+				return device, err
+			}
+			device.LastUpdate, err = parseTime(updatedAt)
+			if err != nil {
+				return device, err
+			}
+			err = json.Unmarshal(attrBuf, &device.Attributes)
+			if err != nil {
+				return device, err
+			}
+			return device, unmarshalOverrides(reported, overrides, &device)
+		}
+}
+
+func (this *Sqlite) ListDevices(ctx context.Context, userId string, options options.List) (result []model.Device, total int64, err error, errCode int) {
+	timeout := this.getTimeoutContext(ctx)
+	where, args, err := getDeviceWhere(userId, options)
+	if err != nil {
+		return result, total, err, http.StatusBadRequest
+	}
+	whereArgCount := len(args)
+	orderBy, args, err := getOrderBy(options, args)
+	if err != nil {
+		return result, total, err, http.StatusBadRequest
+	}
+
+	deviceFields, scan := getDeviceScanInfo()
+
+	query := fmt.Sprintf(`SELECT `+deviceFields+` FROM devices WHERE %v ORDER BY %v LIMIT %v OFFSET %v`, where, orderBy, options.Limit, options.Offset)
+
+	rows, err := this.db.QueryContext(timeout, query, args...)
+	if err != nil {
+		return result, total, err, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	for rows.Next() {
+		element, err := scan(rows)
+		if err != nil {
+			return result, total, err, http.StatusInternalServerError
+		}
+		result = append(result, element)
+	}
+	if err = rows.Err(); err != nil {
+		return result, total, err, http.StatusInternalServerError
+	}
+	total, err, errCode = this.listDevicesTotal(ctx, where, args[:whereArgCount])
+	if err != nil {
+		return result, total, err, errCode
+	}
+	return result, total, nil, http.StatusOK
+}
+
+// ExportDevices calls handler for every device matching the options, ignoring limit and offset.
+// The rows are streamed from the database, so they are never held in memory all at once.
+func (this *Sqlite) ExportDevices(ctx context.Context, userId string, options options.List, handler func(device model.Device) error) (err error, errCode int) {
+	where, args, err := getDeviceWhere(userId, options)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	orderBy, args, err := getOrderBy(options, args)
+	if err != nil {
+		return err, http.StatusBadRequest
+	}
+	deviceFields, scan := getDeviceScanInfo()
+	query := fmt.Sprintf(`SELECT `+deviceFields+` FROM devices WHERE %v ORDER BY %v`, where, orderBy)
+	rows, err := this.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	for rows.Next() {
+		element, err := scan(rows)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+		err = handler(element)
+		if err != nil {
+			return err, http.StatusInternalServerError
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+// getOrderBy returns the ORDER BY expression for options.Sort; args are extended by parameters used in the expression
+func getOrderBy(options options.List, args []any) (orderBy string, resultArgs []any, err error) {
+	parts := strings.Split(options.Sort, ".")
+	switch parts[0] {
+	case "local_id", "name", "created_at", "updated_at":
+		direction := "ASC"
+		if len(parts) > 1 && parts[1] == "desc" {
+			direction = "DESC"
+		}
+		return parts[0] + " " + direction, args, nil
+	case "relevance":
+		searchQuery, _ := options.SearchQuery() //already validated by getDeviceWhere
+		if terms := query.TextTerms(searchQuery); len(terms) > 0 {
+			return getRelevanceExpression(terms, &args) + " DESC, local_id ASC", args, nil
+		}
+		return "local_id ASC", args, nil
+	default:
+		return "", args, errors.New("unknown sort field")
+	}
+}
+
+// getRelevanceExpression scores each term like the mongo implementation:
+// 3 if a token of the device equals the term, 2 if a token starts with the term and 1 otherwise
+func getRelevanceExpression(terms []string, args *[]any) string {
+	scores := []string{}
+	//terms consist only of lower case letters and digits and need no escaping in like patterns
+	for _, term := range terms {
+		*args = append(*args, "% "+term+" %")
+		exact := strconv.Itoa(len(*args))
+		*args = append(*args, "% "+term+"%")
+		prefix := strconv.Itoa(len(*args))
+		scores = append(scores, `CASE WHEN ' ' || search_text || ' ' LIKE ?`+exact+` THEN 3 WHEN ' ' || search_text LIKE ?`+prefix+` THEN 2 ELSE 1 END`)
+	}
+	return "(" + strings.Join(scores, " + ") + ")"
+}
+
+func (this *Sqlite) listDevicesTotal(ctx context.Context, where string, args []any) (total int64, err error, errCode int) {
+	timeout := this.getTimeoutContext(ctx)
+	query := fmt.Sprintf(`SELECT COUNT(local_id) FROM devices WHERE %v`, where)
+	err = this.db.QueryRowContext(timeout, query, args...).Scan(&total)
+	if err != nil {
+		return total, err, http.StatusInternalServerError
+	}
+	return total, nil, http.StatusOK
+}
+
+func getDeviceWhere(userId string, o options.List) (where string, args []any, err error) {
+	and := []string{"user_id = ?1"}
+	args = []any{userId}
+	param := func(value any) string {
+		args = append(args, value)
+		return "?" + strconv.Itoa(len(args))
+	}
+	searchQuery, err := o.SearchQuery()
+	if err != nil {
+		return where, args, err
+	}
+	if o.HideHidden(searchQuery) {
+		and = append(and, "hidden = "+param(false))
+	}
+	if searchQuery != nil {
+		condition, err := compileQuery(searchQuery, &args)
+		if err != nil {
+			return where, args, err
+		}
+		and = append(and, condition)
+	}
+	for _, filter := range o.AttributeFilter {
+		switch filter.Operation {
+		case options.AttributeEquals:
+			and = append(and, `EXISTS (SELECT 1 FROM json_each(devices.attributes) AS attr 
+				WHERE json_extract(attr.value, '$.key') = `+param(filter.Key)+` AND json_extract(attr.value, '$.value') = `+param(filter.Value)+`)`)
+		case options.AttributeExists:
+			and = append(and, getAttributeExists(param(filter.Key)))
+		default:
+			return where, args, errors.New("unknown attribute filter operation")
+		}
+	}
+	if len(o.DeviceTypeIds) > 0 {
+		params := []string{}
+		for _, id := range o.DeviceTypeIds {
+			params = append(params, param(id))
+		}
+		and = append(and, "device_type_id IN ("+strings.Join(params, ", ")+")")
+	}
+	timeRanges := []struct {
+		value    time.Time
+		field    string
+		operator string
+	}{
+		{value: o.CreatedAfter, field: "created_at", operator: ">="},
+		{value: o.CreatedBefore, field: "created_at", operator: "<"},
+		{value: o.UpdatedAfter, field: "updated_at", operator: ">="},
+		{value: o.UpdatedBefore, field: "updated_at", operator: "<"},
+	}
+	for _, r := range timeRanges {
+		if !r.value.IsZero() {
+			and = append(and, r.field+" "+r.operator+" "+param(formatTime(r.value)))
+		}
+	}
+	return strings.Join(and, " AND "), args, nil
+}
+
+func getAttributeExists(keyParam string) string {
+	return `EXISTS (SELECT 1 FROM json_each(devices.attributes) AS attr WHERE json_extract(attr.value, '$.key') = ` + keyParam + `)`
+}
+
+func (this *Sqlite) ReadDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	deviceFields, scan := getDeviceScanInfo()
+	row := this.db.QueryRowContext(this.getTimeoutContext(ctx), `SELECT `+deviceFields+` FROM devices WHERE local_id = ?1 LIMIT 1`, localId)
+	result, err = scan(row)
+	if err != nil {
+		return result, err, getErrCode(err)
+	}
+	return result, nil, http.StatusOK
+}
+
+func getErrCode(err error) int {
+	switch err {
+	case nil:
+		return http.StatusOK
+	case sql.ErrNoRows:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+const insertDeviceQuery = `INSERT INTO devices(local_id, 
+			id, 
+			name, 
+			device_type_id, 
+			attributes, 
+			user_id, 
+			hidden, 
+			created_at, 
+			updated_at,
+			search_text,
+			reported,
+			overrides) 
+	VALUES(?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12)`
+
+const setDeviceQuery = insertDeviceQuery + `
+	ON CONFLICT (local_id) DO UPDATE SET
+		  id = excluded.id,
+		  name = excluded.name,
+		  device_type_id = excluded.device_type_id,
+		  attributes = excluded.attributes,
+		  user_id = excluded.user_id, 
+		  hidden = excluded.hidden,
+		  created_at = excluded.created_at,
+		  updated_at = excluded.updated_at,
+		  search_text = excluded.search_text,
+		  reported = excluded.reported,
+		  overrides = excluded.overrides;`
+
+func getSetDeviceArgs(device model.Device) ([]any, error) {
+	if device.Attributes == nil {
+		device.Attributes = []models.Attribute{}
+	}
+	attrBuf, err := json.Marshal(device.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	reported, overrides, err := marshalOverrides(device)
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		device.LocalId,                // ?1
+		device.Id,                     // ?2
+		device.Name,                   // ?3
+		device.DeviceTypeId,           // ?4
+		string(attrBuf),               // ?5
+		device.UserId,                 // ?6
+		device.Hidden,                 // ?7
+		formatTime(device.CreatedAt),  // ?8
+		formatTime(device.LastUpdate), // ?9
+		search.DeviceText(device),     // ?10
+		reported,                      // ?11
+		overrides,                     // ?12
+	}, nil
+}
+
+// marshalOverrides returns NULL for devices without overrides
+func marshalOverrides(device model.Device) (reported sql.NullString, overrides sql.NullString, err error) {
+	if device.Reported == nil || len(device.Overrides) == 0 {
+		return reported, overrides, nil
+	}
+	reportedBuf, err := json.Marshal(device.Reported)
+	if err != nil {
+		return reported, overrides, err
+	}
+	overridesBuf, err := json.Marshal(device.Overrides)
+	if err != nil {
+		return reported, overrides, err
+	}
+	return sql.NullString{String: string(reportedBuf), Valid: true}, sql.NullString{String: string(overridesBuf), Valid: true}, nil
+}
+
+func unmarshalOverrides(reported sql.NullString, overrides sql.NullString, device *model.Device) (err error) {
+	if !reported.Valid || !overrides.Valid {
+		return nil
+	}
+	device.Reported = &model.Reported{}
+	err = json.Unmarshal([]byte(reported.String), device.Reported)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(overrides.String), &device.Overrides)
+}
+
+func (this *Sqlite) SetDevice(ctx context.Context, device model.Device) (error, int) {
+	args, err := getSetDeviceArgs(device)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	_, err = this.db.ExecContext(this.getTimeoutContext(ctx), setDeviceQuery, args...)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+const setDeviceIfUnchangedQuery = `UPDATE devices SET
+		  id = ?2,
+		  name = ?3,
+		  device_type_id = ?4,
+		  attributes = ?5,
+		  user_id = ?6,
+		  hidden = ?7,
+		  created_at = ?8,
+		  updated_at = ?9,
+		  search_text = ?10,
+		  reported = ?11,
+		  overrides = ?12
+	WHERE local_id = ?1 AND updated_at = ?13;`
+
+func (this *Sqlite) SetDeviceIfUnchanged(ctx context.Context, device model.Device, lastUpdate time.Time) (error, int) {
+	args, err := getSetDeviceArgs(device)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	result, err := this.db.ExecContext(this.getTimeoutContext(ctx), setDeviceIfUnchangedQuery, append(args, formatTime(lastUpdate))...)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	if count == 0 {
+		return errors.New("device has been changed concurrently"), http.StatusConflict
+	}
+	return nil, http.StatusOK
+}
+
+func (this *Sqlite) RemoveDevice(ctx context.Context, localId string) (error, int) {
+	_, err := this.db.ExecContext(this.getTimeoutContext(ctx), "DELETE FROM devices WHERE local_id = ?1", localId)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
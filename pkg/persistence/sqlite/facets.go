@@ -0,0 +1,86 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"net/http"
+	"strconv"
+)
+
+func (this *Sqlite) CountFacets(ctx context.Context, userId string, o options.List) (result model.DeviceFacets, err error, errCode int) {
+	where, args, err := getDeviceWhere(userId, o)
+	if err != nil {
+		return result, err, http.StatusBadRequest
+	}
+	if o.Facets.DeviceTypeId {
+		query := fmt.Sprintf(`SELECT COALESCE(device_type_id, ''), COUNT(local_id) AS count FROM devices WHERE %v GROUP BY device_type_id ORDER BY count DESC, device_type_id ASC`, where)
+		result.DeviceTypeId, err = this.queryFacetCounts(ctx, query, args)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+	}
+	if o.Facets.Hidden {
+		withHidden := o
+		withHidden.ShowHidden = true
+		hiddenWhere, hiddenArgs, err := getDeviceWhere(userId, withHidden)
+		if err != nil {
+			return result, err, http.StatusBadRequest
+		}
+		query := fmt.Sprintf(`SELECT CASE WHEN hidden THEN 'true' ELSE 'false' END, COUNT(local_id) AS count FROM devices WHERE %v GROUP BY hidden ORDER BY count DESC, hidden ASC`, hiddenWhere)
+		result.Hidden, err = this.queryFacetCounts(ctx, query, hiddenArgs)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+	}
+	for _, key := range o.Facets.AttributeKeys {
+		if result.Attributes == nil {
+			result.Attributes = map[string][]model.FacetCount{}
+		}
+		attrArgs := append(append([]any{}, args...), key)
+		query := fmt.Sprintf(`SELECT COALESCE(json_extract(attr.value, '$.value'), '') AS value, COUNT(DISTINCT local_id) AS count 
+			FROM devices, json_each(devices.attributes) AS attr 
+			WHERE %v AND json_extract(attr.value, '$.key') = ?%v 
+			GROUP BY 1 ORDER BY count DESC, value ASC`, where, strconv.Itoa(len(attrArgs)))
+		result.Attributes[key], err = this.queryFacetCounts(ctx, query, attrArgs)
+		if err != nil {
+			return result, err, http.StatusInternalServerError
+		}
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *Sqlite) queryFacetCounts(ctx context.Context, query string, args []any) (result []model.FacetCount, err error) {
+	result = []model.FacetCount{}
+	rows, err := this.db.QueryContext(this.getTimeoutContext(ctx), query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		element := model.FacetCount{}
+		err = rows.Scan(&element.Value, &element.Count)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, element)
+	}
+	return result, rows.Err()
+}
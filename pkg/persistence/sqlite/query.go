@@ -0,0 +1,109 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlite
+
+import (
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/query"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/search"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var queryFieldColumns = map[query.Field]string{
+	query.FieldName:         "name",
+	query.FieldLocalId:      "local_id",
+	query.FieldId:           "id",
+	query.FieldDeviceTypeId: "device_type_id",
+}
+
+// minFtsTermLength is the shortest term the trigram index can match
+const minFtsTermLength = 3
+
+// compileQuery returns a sql condition for the node; values are appended to args and referenced as numbered parameters
+func compileQuery(node query.Node, args *[]any) (string, error) {
+	param := func(value any) string {
+		*args = append(*args, value)
+		return "?" + strconv.Itoa(len(*args))
+	}
+	switch n := node.(type) {
+	case query.And:
+		return compileQueryList(n.Children, " AND ", args)
+	case query.Or:
+		return compileQueryList(n.Children, " OR ", args)
+	case query.Not:
+		child, err := compileQuery(n.Child, args)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + child + ")", nil
+	case query.Text:
+		//terms consist only of lower case letters and digits and need no escaping in fts5 strings or like patterns
+		terms := search.Terms(n.Value)
+		if len(terms) == 0 {
+			return "TRUE", nil
+		}
+		conditions := []string{}
+		for _, term := range terms {
+			if utf8.RuneCountInString(term) >= minFtsTermLength {
+				conditions = append(conditions, "row_id IN (SELECT rowid FROM devices_fts WHERE devices_fts MATCH "+param(`"`+term+`"`)+")")
+			} else {
+				conditions = append(conditions, "search_text LIKE "+param("%"+term+"%"))
+			}
+		}
+		return "(" + strings.Join(conditions, " AND ") + ")", nil
+	case query.Match:
+		column, ok := queryFieldColumns[n.Field]
+		if !ok {
+			return "", errors.New("unknown search field " + string(n.Field))
+		}
+		return lowerFunction + "(" + column + ") LIKE " + param(getLikePattern(n.Pattern)) + ` ESCAPE '\'`, nil
+	case query.AttributeMatch:
+		return `EXISTS (SELECT 1 FROM json_each(devices.attributes) AS attr 
+			WHERE json_extract(attr.value, '$.key') = ` + param(n.Key) + ` AND ` + lowerFunction + `(json_extract(attr.value, '$.value')) LIKE ` + param(getLikePattern(n.Pattern)) + ` ESCAPE '\')`, nil
+	case query.AttributeExists:
+		return getAttributeExists(param(n.Key)), nil
+	case query.Hidden:
+		return "hidden = " + param(n.Value), nil
+	default:
+		return "", errors.New("unknown search expression")
+	}
+}
+
+func compileQueryList(nodes []query.Node, operator string, args *[]any) (string, error) {
+	conditions := []string{}
+	for _, node := range nodes {
+		condition, err := compileQuery(node, args)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+	return "(" + strings.Join(conditions, operator) + ")", nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// getLikePattern returns a lower case like pattern, to be matched against values converted by lowerFunction
+func getLikePattern(pattern query.Pattern) string {
+	parts := []string{}
+	for _, part := range pattern.Parts() {
+		parts = append(parts, likeEscaper.Replace(strings.ToLower(part)))
+	}
+	return strings.Join(parts, "%")
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Migrations are applied in order of their version, each in its own transaction
+var Migrations = []schema.Migration[*sql.Tx]{}
+
+// migrateSchema needs no separate lock: transactions take the write lock of the database file when they begin,
+// so that the current version is read again by each migration and concurrently starting instances wait for each other
+func (this *Sqlite) migrateSchema() error {
+	migrations, err := schema.Sorted(Migrations)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	_, err = this.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		description TEXT,
+		applied_at TEXT);`)
+	if err != nil {
+		return fmt.Errorf("unable to create schema_migrations table: %w", err)
+	}
+	current, err := readSchemaVersion(ctx, this.db)
+	if err != nil {
+		return fmt.Errorf("unable to read schema version: %w", err)
+	}
+	if latest := schema.Latest(migrations); current > latest {
+		slog.Warn("sqlite schema version is newer than the latest known version", "version", current, "latest", latest)
+	}
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+		err = this.applyMigration(ctx, migration)
+		if err != nil {
+			return fmt.Errorf("unable to apply schema migration %v (%v): %w", migration.Version, migration.Description, err)
+		}
+	}
+	return nil
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func readSchemaVersion(ctx context.Context, db queryer) (current int, err error) {
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	return current, err
+}
+
+func (this *Sqlite) applyMigration(ctx context.Context, migration schema.Migration[*sql.Tx]) error {
+	tx, err := this.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	current, err := readSchemaVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if migration.Version <= current {
+		return nil //applied by a concurrently starting instance
+	}
+	slog.Info("apply sqlite schema migration", "version", migration.Version, "description", migration.Description)
+	err = migration.Up(tx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations(version, description, applied_at) VALUES (?, ?, ?)`, migration.Version, migration.Description, formatTime(time.Now()))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SchemaStatus lists the known migrations and if they have been applied, without applying pending migrations
+func SchemaStatus(conf configuration.Config) (result []schema.Status, err error) {
+	applied := map[int]time.Time{}
+	_, err = os.Stat(conf.SqlitePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return schema.GetStatus(Migrations, applied)
+	}
+	if err != nil {
+		return nil, err
+	}
+	db, err := open(conf.SqlitePath, true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exists := false
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		rows, err := db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var version int
+			var appliedAt string
+			err = rows.Scan(&version, &appliedAt)
+			if err != nil {
+				return nil, err
+			}
+			applied[version], err = parseTime(appliedAt)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return schema.GetStatus(Migrations, applied)
+}
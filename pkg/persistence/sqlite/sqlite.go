@@ -0,0 +1,121 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"log/slog"
+	"modernc.org/sqlite"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lowerFunction is registered as sql function, because the lower() and LIKE of sqlite only fold ascii characters
+const lowerFunction = "unicode_lower"
+
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction(lowerFunction, 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch value := args[0].(type) {
+		case string:
+			return strings.ToLower(value), nil
+		case nil:
+			return nil, nil
+		default:
+			return nil, errors.New(lowerFunction + " expects text")
+		}
+	})
+}
+
+type Sqlite struct {
+	db *sql.DB
+}
+
+func New(ctx context.Context, wg *sync.WaitGroup, conf configuration.Config) (*Sqlite, error) {
+	db, err := open(conf.SqlitePath, false)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Ping()
+	if err != nil {
+		slog.Error("unable to ping sqlite", "error", err)
+		db.Close()
+		return nil, err
+	}
+	client := &Sqlite{db: db}
+	err = client.migrateSchema()
+	if err != nil {
+		slog.Error("unable to migrate schema", "error", err)
+		client.disconnect()
+		return nil, err
+	}
+	if wg != nil {
+		wg.Add(1)
+	}
+	go func() {
+		<-ctx.Done()
+		client.disconnect()
+		if wg != nil {
+			wg.Done()
+		}
+	}()
+	return client, nil
+}
+
+// open uses the write-ahead log, so that reads are not blocked by writes,
+// and lets every transaction take the write lock immediately, so that concurrent writers wait for each other instead of failing
+func open(location string, readOnly bool) (*sql.DB, error) {
+	if location == "" {
+		return nil, errors.New("missing sqlite_path")
+	}
+	query := url.Values{}
+	query.Add("_pragma", "busy_timeout(10000)")
+	query.Add("_pragma", "foreign_keys(1)")
+	if readOnly {
+		query.Set("mode", "ro")
+	} else {
+		query.Add("_pragma", "journal_mode(WAL)")
+		query.Add("_pragma", "synchronous(NORMAL)")
+		query.Set("_txlock", "immediate")
+	}
+	return sql.Open("sqlite", "file:"+location+"?"+query.Encode())
+}
+
+// Ping checks that the database file is still readable; opening a connection alone does not access the file
+func (this *Sqlite) Ping(ctx context.Context) error {
+	var result int
+	return this.db.QueryRowContext(this.getTimeoutContext(ctx), "SELECT COUNT(*) FROM schema_migrations").Scan(&result)
+}
+
+func (this *Sqlite) disconnect() {
+	err := this.db.Close()
+	if err != nil {
+		slog.Error("unable to disconnect sqlite", "error", err)
+		return
+	}
+	slog.Info("disconnected sqlite")
+}
+
+func (this *Sqlite) getTimeoutContext(ctx context.Context) context.Context {
+	ctx, _ = context.WithTimeout(ctx, 10*time.Second)
+	return ctx
+}
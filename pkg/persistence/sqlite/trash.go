@@ -0,0 +1,176 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/schema"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"net/http"
+	"time"
+)
+
+func init() {
+	Migrations = append(Migrations,
+		schema.Migration[*sql.Tx]{Version: 4, Description: "create deleted_devices table", Up: createDeletedDevicesTable},
+	)
+}
+
+func createDeletedDevicesTable(tx *sql.Tx) error {
+	return execAll(tx,
+		`CREATE TABLE IF NOT EXISTS deleted_devices (
+			local_id TEXT PRIMARY KEY,
+			user_id TEXT,
+			deleted_at TEXT,
+			device TEXT);`,
+		`CREATE INDEX IF NOT EXISTS deleted_devices_user_id_idx ON deleted_devices (user_id, deleted_at);`,
+		`CREATE INDEX IF NOT EXISTS deleted_devices_deleted_at_idx ON deleted_devices (deleted_at);`,
+	)
+}
+
+const setTrashedDeviceQuery = `INSERT INTO deleted_devices(local_id, user_id, deleted_at, device) VALUES (?1, ?2, ?3, ?4)
+	ON CONFLICT (local_id) DO UPDATE SET user_id = EXCLUDED.user_id, deleted_at = EXCLUDED.deleted_at, device = EXCLUDED.device`
+
+func (this *Sqlite) TrashDevice(ctx context.Context, localId string, deletedAt time.Time) (error, int) {
+	timeout := this.getTimeoutContext(ctx)
+	tx, err := this.db.BeginTx(timeout, nil)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer tx.Rollback()
+	deviceFields, scan := getDeviceScanInfo()
+	device, err := scan(tx.QueryRowContext(timeout, `SELECT `+deviceFields+` FROM devices WHERE local_id = ?1`, localId))
+	if err != nil {
+		return err, getErrCode(err)
+	}
+	device.DeletedAt = &deletedAt
+	deviceBuf, err := json.Marshal(device)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	_, err = tx.ExecContext(timeout, setTrashedDeviceQuery, device.LocalId, device.UserId, formatTime(deletedAt), string(deviceBuf))
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	_, err = tx.ExecContext(timeout, `DELETE FROM devices WHERE local_id = ?1`, localId)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+func scanTrashedDevice(row scanner) (device model.Device, err error) {
+	deviceBuf := []byte{}
+	err = row.Scan(&deviceBuf)
+	if err != nil {
+		return device, err
+	}
+	err = json.Unmarshal(deviceBuf, &device)
+	return device, err
+}
+
+func (this *Sqlite) ListTrashedDevices(ctx context.Context, userId string, limit int, offset int) (result []model.Device, total int64, err error, errCode int) {
+	timeout := this.getTimeoutContext(ctx)
+	err = this.db.QueryRowContext(timeout, `SELECT COUNT(*) FROM deleted_devices WHERE user_id = ?1`, userId).Scan(&total)
+	if err != nil {
+		return result, total, err, http.StatusInternalServerError
+	}
+	rows, err := this.db.QueryContext(timeout, `SELECT device FROM deleted_devices WHERE user_id = ?1 ORDER BY deleted_at DESC, local_id ASC LIMIT ?2 OFFSET ?3`, userId, limit, offset)
+	if err != nil {
+		return result, total, err, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	result = []model.Device{}
+	for rows.Next() {
+		element, err := scanTrashedDevice(rows)
+		if err != nil {
+			return result, total, err, http.StatusInternalServerError
+		}
+		result = append(result, element)
+	}
+	if err = rows.Err(); err != nil {
+		return result, total, err, http.StatusInternalServerError
+	}
+	return result, total, nil, http.StatusOK
+}
+
+func (this *Sqlite) ReadTrashedDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	result, err = scanTrashedDevice(this.db.QueryRowContext(this.getTimeoutContext(ctx), `SELECT device FROM deleted_devices WHERE local_id = ?1`, localId))
+	if err != nil {
+		return result, err, getErrCode(err)
+	}
+	return result, nil, http.StatusOK
+}
+
+func (this *Sqlite) RestoreTrashedDevice(ctx context.Context, device model.Device) (error, int) {
+	timeout := this.getTimeoutContext(ctx)
+	tx, err := this.db.BeginTx(timeout, nil)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(timeout, `DELETE FROM deleted_devices WHERE local_id = ?1`, device.LocalId)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	if count == 0 {
+		return errors.New("device not found in trash"), http.StatusNotFound
+	}
+	device.DeletedAt = nil
+	args, err := getSetDeviceArgs(device)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	_, err = tx.ExecContext(timeout, insertDeviceQuery, args...)
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return errors.New("device with the same local_id exists"), http.StatusConflict
+	}
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	}
+	return nil, http.StatusOK
+}
+
+func (this *Sqlite) PurgeTrash(ctx context.Context, deletedBefore time.Time) (count int64, err error, errCode int) {
+	result, err := this.db.ExecContext(this.getTimeoutContext(ctx), `DELETE FROM deleted_devices WHERE deleted_at < ?1`, formatTime(deletedBefore))
+	if err != nil {
+		return count, err, http.StatusInternalServerError
+	}
+	count, err = result.RowsAffected()
+	if err != nil {
+		return count, err, http.StatusInternalServerError
+	}
+	return count, nil, http.StatusOK
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package persistence
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// WithTracing creates a span for every device access of a request; health checks and batch methods are not traced
+func WithTracing(db Persistence, backend string) Persistence {
+	return &traced{db: db, backend: backend}
+}
+
+type traced struct {
+	db      Persistence
+	backend string
+}
+
+func (this *traced) start(ctx context.Context, method string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	attributes = append(attributes, semconv.DBSystemKey.String(this.backend), semconv.DBOperationName(method))
+	return tracing.Start(ctx, "Persistence."+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+}
+
+func (this *traced) ListDevices(ctx context.Context, userId string, options options.List) (result []model.Device, total int64, err error, errCode int) {
+	ctx, span := this.start(ctx, "ListDevices")
+	defer tracing.End(span, &err, &errCode)
+	return this.db.ListDevices(ctx, userId, options)
+}
+
+func (this *traced) CountFacets(ctx context.Context, userId string, options options.List) (result model.DeviceFacets, err error, errCode int) {
+	ctx, span := this.start(ctx, "CountFacets")
+	defer tracing.End(span, &err, &errCode)
+	return this.db.CountFacets(ctx, userId, options)
+}
+
+func (this *traced) ExportDevices(ctx context.Context, userId string, options options.List, handler func(device model.Device) error) (err error, errCode int) {
+	ctx, span := this.start(ctx, "ExportDevices")
+	defer tracing.End(span, &err, &errCode)
+	return this.db.ExportDevices(ctx, userId, options, handler)
+}
+
+func (this *traced) ReadDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	ctx, span := this.start(ctx, "ReadDevice", attribute.String(logging.LocalIdKey, localId))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.ReadDevice(ctx, localId)
+}
+
+func (this *traced) SetDevice(ctx context.Context, device model.Device) (err error, errCode int) {
+	ctx, span := this.start(ctx, "SetDevice", attribute.String(logging.LocalIdKey, device.LocalId))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.SetDevice(ctx, device)
+}
+
+func (this *traced) SetDeviceIfUnchanged(ctx context.Context, device model.Device, lastUpdate time.Time) (err error, errCode int) {
+	ctx, span := this.start(ctx, "SetDeviceIfUnchanged", attribute.String(logging.LocalIdKey, device.LocalId))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.SetDeviceIfUnchanged(ctx, device, lastUpdate)
+}
+
+func (this *traced) RemoveDevice(ctx context.Context, localId string) (err error, errCode int) {
+	ctx, span := this.start(ctx, "RemoveDevice", attribute.String(logging.LocalIdKey, localId))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.RemoveDevice(ctx, localId)
+}
+
+func (this *traced) AddDeviceVersion(ctx context.Context, version model.DeviceVersion, keep int64) (result model.DeviceVersion, err error, errCode int) {
+	ctx, span := this.start(ctx, "AddDeviceVersion", attribute.String(logging.LocalIdKey, version.LocalId))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.AddDeviceVersion(ctx, version, keep)
+}
+
+func (this *traced) ListDeviceVersions(ctx context.Context, userId string, localId string) (result []model.DeviceVersion, err error, errCode int) {
+	ctx, span := this.start(ctx, "ListDeviceVersions", attribute.String(logging.LocalIdKey, localId))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.ListDeviceVersions(ctx, userId, localId)
+}
+
+func (this *traced) ReadDeviceVersion(ctx context.Context, userId string, localId string, version int64) (result model.DeviceVersion, err error, errCode int) {
+	ctx, span := this.start(ctx, "ReadDeviceVersion", attribute.String(logging.LocalIdKey, localId), attribute.Int64("version", version))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.ReadDeviceVersion(ctx, userId, localId, version)
+}
+
+func (this *traced) TrashDevice(ctx context.Context, localId string, deletedAt time.Time) (err error, errCode int) {
+	ctx, span := this.start(ctx, "TrashDevice", attribute.String(logging.LocalIdKey, localId))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.TrashDevice(ctx, localId, deletedAt)
+}
+
+func (this *traced) ListTrashedDevices(ctx context.Context, userId string, limit int, offset int) (result []model.Device, total int64, err error, errCode int) {
+	ctx, span := this.start(ctx, "ListTrashedDevices")
+	defer tracing.End(span, &err, &errCode)
+	return this.db.ListTrashedDevices(ctx, userId, limit, offset)
+}
+
+func (this *traced) ReadTrashedDevice(ctx context.Context, localId string) (result model.Device, err error, errCode int) {
+	ctx, span := this.start(ctx, "ReadTrashedDevice", attribute.String(logging.LocalIdKey, localId))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.ReadTrashedDevice(ctx, localId)
+}
+
+func (this *traced) RestoreTrashedDevice(ctx context.Context, device model.Device) (err error, errCode int) {
+	ctx, span := this.start(ctx, "RestoreTrashedDevice", attribute.String(logging.LocalIdKey, device.LocalId))
+	defer tracing.End(span, &err, &errCode)
+	return this.db.RestoreTrashedDevice(ctx, device)
+}
+
+func (this *traced) PurgeTrash(ctx context.Context, deletedBefore time.Time) (count int64, err error, errCode int) {
+	ctx, span := this.start(ctx, "PurgeTrash")
+	defer tracing.End(span, &err, &errCode)
+	return this.db.PurgeTrash(ctx, deletedBefore)
+}
+
+func (this *traced) Ping(ctx context.Context) error {
+	return this.db.Ping(ctx)
+}
+
+func (this *traced) CountDevicesByState() (result map[string]int64, err error, errCode int) {
+	return this.db.CountDevicesByState()
+}
+
+func (this *traced) ReadDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	return this.db.ReadDevicesAfter(lastLocalId, limit)
+}
+
+func (this *traced) CountAllDevices() (count int64, err error, errCode int) {
+	return this.db.CountAllDevices()
+}
+
+func (this *traced) SetDevices(devices []model.Device) (error, int) {
+	return this.db.SetDevices(devices)
+}
+
+func (this *traced) RemoveDevices(localIds []string) (error, int) {
+	return this.db.RemoveDevices(localIds)
+}
+
+func (this *traced) ReadDeviceVersionsAfter(lastLocalId string, lastVersion int64, limit int) (result []model.DeviceVersion, err error, errCode int) {
+	return this.db.ReadDeviceVersionsAfter(lastLocalId, lastVersion, limit)
+}
+
+func (this *traced) SetDeviceVersions(versions []model.DeviceVersion) (error, int) {
+	return this.db.SetDeviceVersions(versions)
+}
+
+func (this *traced) RemoveDeviceVersions(localIds []string) (error, int) {
+	return this.db.RemoveDeviceVersions(localIds)
+}
+
+func (this *traced) ReadTrashedDevicesAfter(lastLocalId string, limit int) (result []model.Device, err error, errCode int) {
+	return this.db.ReadTrashedDevicesAfter(lastLocalId, limit)
+}
+
+func (this *traced) SetTrashedDevices(devices []model.Device) (error, int) {
+	return this.db.SetTrashedDevices(devices)
+}
+
+func (this *traced) RemoveTrashedDevices(localIds []string) (error, int) {
+	return this.db.RemoveTrashedDevices(localIds)
+}
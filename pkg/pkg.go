@@ -5,21 +5,60 @@ import (
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/api"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/controller"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/metrics"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/backup"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/migration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/rpc"
+	"io"
+	"os"
+	"path/filepath"
 	"sync"
 )
 
 func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config) (err error) {
-	db, err := persistence.New(ctx, wg, config)
+	_, err = StartService(ctx, wg, config)
+	return err
+}
+
+// StartService starts the service like Start and returns it, so that its config can be reloaded.
+// The persistence is disconnected only after the api servers have drained their requests and the trash purge has stopped.
+func StartService(ctx context.Context, wg *sync.WaitGroup, config configuration.Config) (service *Service, err error) {
+	dbCtx, dbCancel := context.WithCancel(context.Background())
+	dbUsers := &sync.WaitGroup{}
+	defer func() {
+		if err != nil {
+			dbCancel()
+		}
+	}()
+	db, err := persistence.New(dbCtx, wg, config)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	m := metrics.New()
+	db = persistence.WithMetrics(db, config.DbImpl, m)
+	db = persistence.WithLogging(db, config.DbImpl)
+	db = persistence.WithTracing(db, config.DbImpl)
+	m.RegisterDeviceStates(func() (map[string]int64, error) {
+		result, err, _ := db.CountDevicesByState()
+		return result, err
+	})
+	ctrl := controller.New(config, db, m)
+	ctrl.StartTrashPurge(ctx, dbUsers)
+	err = rpc.Start(ctx, dbUsers, config, ctrl)
+	if err != nil {
+		return nil, err
 	}
-	ctrl := controller.New(config, db)
-	api.Start(ctx, wg, config, ctrl)
-	return nil
+	restApi := api.Start(ctx, dbUsers, config, ctrl, m)
+	go func() {
+		<-ctx.Done()
+		dbUsers.Wait()
+		dbCancel()
+	}()
+	return &Service{config: config, ctrl: ctrl, api: restApi}, nil
 }
 
-func Migrate(config configuration.Config, to configuration.DbImpl) (err error) {
+func Migrate(config configuration.Config, to configuration.DbImpl, options migration.Options) (err error) {
 	wg := &sync.WaitGroup{}
 	defer wg.Wait()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -37,5 +76,82 @@ func Migrate(config configuration.Config, to configuration.DbImpl) (err error) {
 		return err
 	}
 
-	return source.MigrateTo(target)
+	_, err = migration.Run(source, target, options)
+	return err
+}
+
+// Backup writes a backup archive of all devices to location; "-" writes to stdout.
+// Files are written to a temporary file first, so that an existing backup is only replaced by a complete archive.
+func Backup(config configuration.Config, location string) (result backup.Trailer, err error) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := persistence.New(ctx, wg, config)
+	if err != nil {
+		return result, err
+	}
+
+	if location == "-" {
+		return backup.Write(os.Stdout, db, string(config.DbImpl), migration.DefaultBatchSize)
+	}
+	file, err := os.CreateTemp(filepath.Dir(location), filepath.Base(location)+".*.tmp")
+	if err != nil {
+		return result, err
+	}
+	defer os.Remove(file.Name())
+	result, err = backup.Write(file, db, string(config.DbImpl), migration.DefaultBatchSize)
+	if err != nil {
+		file.Close()
+		return result, err
+	}
+	err = file.Sync()
+	if err != nil {
+		file.Close()
+		return result, err
+	}
+	err = file.Close()
+	if err != nil {
+		return result, err
+	}
+	return result, os.Rename(file.Name(), location)
+}
+
+// VerifyBackup checks the integrity of the backup archive at location without connecting to a database
+func VerifyBackup(location string) (header backup.Header, trailer backup.Trailer, err error) {
+	file, err := os.Open(location)
+	if err != nil {
+		return header, trailer, err
+	}
+	defer file.Close()
+	return backup.Verify(file)
+}
+
+// Restore verifies the backup archive at location and restores it into the configured database
+func Restore(config configuration.Config, location string, mode backup.Mode) (result backup.RestoreResult, err error) {
+	file, err := os.Open(location)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+	_, _, err = backup.Verify(file)
+	if err != nil {
+		return result, err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return result, err
+	}
+
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := persistence.New(ctx, wg, config)
+	if err != nil {
+		return result, err
+	}
+	return backup.Restore(file, db, mode, migration.DefaultBatchSize)
 }
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/controller"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Service is a started device-waiting-room, whose reloadable settings (see configuration.Changes) can be changed while running
+type Service struct {
+	mux    sync.Mutex
+	config configuration.Config
+	ctrl   *controller.Controller
+	api    *api.Api
+}
+
+// Config returns the effective config of the service
+func (this *Service) Config() configuration.Config {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return this.config
+}
+
+// Reload applies the reloadable settings of next to the logger, the controller and the api;
+// each of them switches to the new settings at once, so that no request sees a mix of old and new settings.
+// Changed settings, which need a restart, are not applied and returned as restartRequired.
+// An invalid next config is rejected as a whole.
+func (this *Service) Reload(next configuration.Config) (applied []string, restartRequired []string, err error) {
+	err = next.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	applied, restartRequired = configuration.Changes(this.config, next)
+	if len(applied) == 0 {
+		return applied, restartRequired, nil
+	}
+	config := configuration.Reload(this.config, next)
+	err = logging.UpdateLevel(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	this.ctrl.UpdateConfig(config)
+	this.api.UpdateConfig(config)
+	this.config = config
+	return applied, restartRequired, nil
+}
+
+// ReloadFile loads the config at location (including environment variables) and applies it with Reload; the outcome is logged
+func (this *Service) ReloadFile(location string, reason string) error {
+	next, err := configuration.Load(location)
+	if err != nil {
+		slog.Error("invalid config, keep the current config", "reason", reason, "error", err)
+		return err
+	}
+	applied, restartRequired, err := this.Reload(next)
+	if err != nil {
+		slog.Error("unable to reload config, keep the current config", "reason", reason, "error", err)
+		return err
+	}
+	if len(restartRequired) > 0 {
+		slog.Warn("changed settings need a restart and are not applied", "reason", reason, "settings", restartRequired)
+	}
+	if len(applied) > 0 {
+		slog.Info("config reloaded", "reason", reason, "applied", applied)
+	} else if len(restartRequired) == 0 {
+		slog.Info("config reloaded without changes", "reason", reason)
+	}
+	return nil
+}
+
+// WatchConfig reloads the config file at location on SIGHUP and, if config_watch_interval is set,
+// whenever the content of the file changes, until ctx is done
+func (this *Service) WatchConfig(ctx context.Context, wg *sync.WaitGroup, location string) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	var changeCheck <-chan time.Time //nil blocks forever, if the file is not watched
+	var ticker *time.Ticker
+	if interval := this.Config().ConfigWatchInterval.Duration(); interval > 0 {
+		ticker = time.NewTicker(interval)
+		changeCheck = ticker.C
+	}
+	last, err := os.ReadFile(location)
+	if err != nil {
+		slog.Warn("unable to read config file for change detection", "error", err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer signal.Stop(hangup)
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				this.ReloadFile(location, "SIGHUP")
+			case <-changeCheck:
+				content, err := os.ReadFile(location)
+				if err != nil {
+					//the file may be replaced right now (e.g. a kubernetes config map update); retry with the next tick
+					if !errors.Is(err, os.ErrNotExist) {
+						slog.Warn("unable to read config file for change detection", "error", err)
+					}
+					continue
+				}
+				if bytes.Equal(content, last) {
+					continue
+				}
+				last = content
+				this.ReloadFile(location, "file changed")
+			}
+		}
+	}()
+}
@@ -0,0 +1,218 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/rpc/pb"
+	"github.com/SENERGY-Platform/models/go/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo details of errors; their reason is the model.ErrorCode
+const ErrorDomain = "device-waiting-room"
+
+var errorCodes = map[model.ErrorCode]codes.Code{
+	model.ErrorCodeInvalidRequest:           codes.InvalidArgument,
+	model.ErrorCodeUnauthorized:             codes.Unauthenticated,
+	model.ErrorCodeAccessDenied:             codes.PermissionDenied,
+	model.ErrorCodeNotFound:                 codes.NotFound,
+	model.ErrorCodeConflict:                 codes.Aborted,
+	model.ErrorCodeUnsupportedMediaType:     codes.InvalidArgument,
+	model.ErrorCodeTooLarge:                 codes.ResourceExhausted,
+	model.ErrorCodeDeviceManagerRejected:    codes.FailedPrecondition,
+	model.ErrorCodeDeviceManagerUnavailable: codes.Unavailable,
+	model.ErrorCodeInternal:                 codes.Internal,
+}
+
+// toStatus converts errors of the controller to grpc status errors; nil stays nil
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	e := model.AsError(err)
+	code, ok := errorCodes[e.Code]
+	if !ok {
+		code = codes.Internal
+	}
+	info := &errdetails.ErrorInfo{Reason: string(e.Code), Domain: ErrorDomain, Metadata: map[string]string{}}
+	if e.LocalId != "" {
+		info.Metadata["local_id"] = e.LocalId
+	}
+	if e.UpstreamStatus != 0 {
+		info.Metadata["upstream_status"] = strconv.Itoa(e.UpstreamStatus)
+	}
+	result, detailsErr := status.New(code, e.Error()).WithDetails(info)
+	if detailsErr != nil {
+		slog.Error("unable to add grpc error details", "error", detailsErr)
+		return status.Error(code, e.Error())
+	}
+	return result.Err()
+}
+
+func toProtoDevice(device model.Device) *pb.Device {
+	result := &pb.Device{
+		Id:           device.Id,
+		LocalId:      device.LocalId,
+		Name:         device.Name,
+		Attributes:   toProtoAttributes(device.Attributes),
+		DeviceTypeId: device.DeviceTypeId,
+		UserId:       device.UserId,
+		Hidden:       device.Hidden,
+		CreatedAt:    toProtoTime(device.CreatedAt),
+		UpdatedAt:    toProtoTime(device.LastUpdate),
+		Overrides:    device.Overrides,
+	}
+	if device.Reported != nil {
+		result.Reported = &pb.Reported{
+			Name:         device.Reported.Name,
+			DeviceTypeId: device.Reported.DeviceTypeId,
+			Attributes:   toProtoAttributes(device.Reported.Attributes),
+		}
+	}
+	return result
+}
+
+// fromProtoDevice converts the input of SetDevice; fields set by the controller, like user_id or created_at, are ignored
+func fromProtoDevice(device *pb.Device) model.Device {
+	return model.Device{
+		Device: models.Device{
+			Id:           device.GetId(),
+			LocalId:      device.GetLocalId(),
+			Name:         device.GetName(),
+			Attributes:   fromProtoAttributes(device.GetAttributes()),
+			DeviceTypeId: device.GetDeviceTypeId(),
+		},
+		Hidden: device.GetHidden(),
+	}
+}
+
+func toProtoAttributes(attributes []models.Attribute) []*pb.Attribute {
+	result := make([]*pb.Attribute, 0, len(attributes))
+	for _, attr := range attributes {
+		result = append(result, &pb.Attribute{Key: attr.Key, Value: attr.Value, Origin: attr.Origin})
+	}
+	return result
+}
+
+func fromProtoAttributes(attributes []*pb.Attribute) []models.Attribute {
+	result := make([]models.Attribute, 0, len(attributes))
+	for _, attr := range attributes {
+		result = append(result, models.Attribute{Key: attr.GetKey(), Value: attr.GetValue(), Origin: attr.GetOrigin()})
+	}
+	return result
+}
+
+func toProtoTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromProtoTime(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+func toProtoDeviceList(list model.DeviceList) *pb.DeviceList {
+	result := &pb.DeviceList{
+		Total:  list.Total,
+		Limit:  int32(list.Limit),
+		Offset: int32(list.Offset),
+		Sort:   list.Sort,
+		Search: list.Search,
+		Result: make([]*pb.Device, 0, len(list.Result)),
+	}
+	for _, device := range list.Result {
+		result.Result = append(result.Result, toProtoDevice(device))
+	}
+	if list.Facets != nil {
+		result.Facets = &pb.DeviceFacets{
+			DeviceTypeId: toProtoFacetCounts(list.Facets.DeviceTypeId),
+			Hidden:       toProtoFacetCounts(list.Facets.Hidden),
+		}
+		if list.Facets.Attributes != nil {
+			result.Facets.Attributes = map[string]*pb.FacetCounts{}
+			for key, counts := range list.Facets.Attributes {
+				result.Facets.Attributes[key] = &pb.FacetCounts{Counts: toProtoFacetCounts(counts)}
+			}
+		}
+	}
+	return result
+}
+
+func toProtoFacetCounts(counts []model.FacetCount) []*pb.FacetCount {
+	result := make([]*pb.FacetCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, &pb.FacetCount{Value: count.Value, Count: count.Count})
+	}
+	return result
+}
+
+// toListOptions applies the defaults of the REST api (limit 100, sort by local_id) and validates search and facets
+func toListOptions(request *pb.ListDevicesRequest) (o options.List, err error) {
+	o = options.List{
+		Limit:         int(request.GetLimit()),
+		Offset:        int(request.GetOffset()),
+		Sort:          request.GetSort(),
+		ShowHidden:    request.GetShowHidden(),
+		Search:        request.GetSearch(),
+		DeviceTypeIds: request.GetDeviceTypeIds(),
+		CreatedAfter:  fromProtoTime(request.GetCreatedAfter()),
+		CreatedBefore: fromProtoTime(request.GetCreatedBefore()),
+		UpdatedAfter:  fromProtoTime(request.GetUpdatedAfter()),
+		UpdatedBefore: fromProtoTime(request.GetUpdatedBefore()),
+	}
+	if o.Limit == 0 {
+		o.Limit = 100
+	}
+	if o.Sort == "" {
+		o.Sort = "local_id"
+	}
+	_, err = o.SearchQuery()
+	if err != nil {
+		return o, err
+	}
+	for _, filter := range request.GetAttributeFilters() {
+		if filter.GetKey() == "" {
+			return o, errors.New("expect non empty attribute filter key")
+		}
+		if filter.GetExists() {
+			o.AttributeFilter = append(o.AttributeFilter, options.AttributeFilter{Key: filter.GetKey(), Operation: options.AttributeExists})
+		} else {
+			o.AttributeFilter = append(o.AttributeFilter, options.AttributeFilter{Key: filter.GetKey(), Value: filter.GetValue(), Operation: options.AttributeEquals})
+		}
+	}
+	for _, facet := range request.GetFacets() {
+		err = o.Facets.Add(facet)
+		if err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
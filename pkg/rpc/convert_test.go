@@ -0,0 +1,113 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/rpc/pb"
+	"github.com/SENERGY-Platform/models/go/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToStatus(t *testing.T) {
+	if toStatus(nil) != nil {
+		t.Error("expect nil")
+	}
+	err := toStatus(model.NewError(model.ErrorCodeNotFound, errors.New("device not found")).WithLocalId("lid"))
+	s := status.Convert(err)
+	if s.Code() != codes.NotFound || s.Message() != "device not found" {
+		t.Error(s.Code(), s.Message())
+	}
+	if len(s.Details()) != 1 {
+		t.Fatal(s.Details())
+	}
+	info, ok := s.Details()[0].(*errdetails.ErrorInfo)
+	if !ok || info.Reason != string(model.ErrorCodeNotFound) || info.Domain != ErrorDomain || info.Metadata["local_id"] != "lid" {
+		t.Errorf("%#v", s.Details()[0])
+	}
+	if code := status.Code(toStatus(errors.New("unknown"))); code != codes.Internal {
+		t.Error(code)
+	}
+}
+
+func TestDeviceConversion(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	device := model.Device{
+		Device: models.Device{
+			LocalId:      "lid",
+			Name:         "foo",
+			DeviceTypeId: "dt",
+			Attributes:   []models.Attribute{{Key: "a", Value: "1", Origin: "gw"}},
+		},
+		UserId:     "user",
+		Hidden:     true,
+		CreatedAt:  now,
+		LastUpdate: now,
+		Reported:   &model.Reported{Name: "bar", Attributes: []models.Attribute{}},
+		Overrides:  []string{model.OverrideName},
+	}
+	result := toProtoDevice(device)
+	if result.GetUserId() != "user" || !result.GetCreatedAt().AsTime().Equal(now) || result.GetReported().GetName() != "bar" || !reflect.DeepEqual(result.GetOverrides(), device.Overrides) {
+		t.Errorf("%#v", result)
+	}
+	expected := model.Device{Device: device.Device, Hidden: true}
+	if actual := fromProtoDevice(result); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("\n%#v\n%#v", actual, expected)
+	}
+	if toProtoDevice(model.Device{}).GetCreatedAt() != nil {
+		t.Error("expect nil timestamp for zero time")
+	}
+}
+
+func TestToListOptions(t *testing.T) {
+	o, err := toListOptions(&pb.ListDevicesRequest{
+		AttributeFilters: []*pb.AttributeFilter{{Key: "a", Value: "1"}, {Key: "b", Exists: true}},
+		Facets:           []string{"hidden", "attr.a"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := options.List{
+		Limit: 100,
+		Sort:  "local_id",
+		AttributeFilter: []options.AttributeFilter{
+			{Key: "a", Value: "1", Operation: options.AttributeEquals},
+			{Key: "b", Operation: options.AttributeExists},
+		},
+		Facets: options.Facets{Hidden: true, AttributeKeys: []string{"a"}},
+	}
+	if !reflect.DeepEqual(o, expected) {
+		t.Errorf("\n%#v\n%#v", o, expected)
+	}
+	for _, request := range []*pb.ListDevicesRequest{
+		{Facets: []string{"unknown"}},
+		{AttributeFilters: []*pb.AttributeFilter{{Value: "1"}}},
+		{Search: "name:"},
+	} {
+		_, err = toListOptions(request)
+		if err == nil {
+			t.Errorf("expect error for %v", request)
+		}
+	}
+}
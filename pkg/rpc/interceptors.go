@@ -0,0 +1,148 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// AuthorizationMetadata is the metadata key of the bearer token, equivalent to the Authorization header of the REST api
+const AuthorizationMetadata = "authorization"
+
+var requestIdMetadata = strings.ToLower(logging.RequestIdHeader)
+
+type tokenKey struct{}
+
+// authenticate validates the token of the authorization metadata with the configured jwt_pub_rsa_key, rejects expired tokens
+// and adds the token to the context. Unlike the REST api, the grpc api is not expected behind a gateway checking the token.
+func authenticate(ctx context.Context, control Controller) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(AuthorizationMetadata)
+	if len(values) == 0 || values[0] == "" {
+		return ctx, toStatus(model.NewError(model.ErrorCodeUnauthorized, errors.New("missing authorization metadata")))
+	}
+	token, err := auth.ParseAndValidateToken(values[0], control.Config().JwtPubRsaKey)
+	if err != nil {
+		return ctx, toStatus(model.NewError(model.ErrorCodeUnauthorized, err))
+	}
+	if token.IsExpired() {
+		return ctx, toStatus(model.NewError(model.ErrorCodeUnauthorized, errors.New("expired auth token")))
+	}
+	return context.WithValue(ctx, tokenKey{}, token), nil
+}
+
+// getToken returns the token added by the auth interceptors
+func getToken(ctx context.Context) auth.Token {
+	token, _ := ctx.Value(tokenKey{}).(auth.Token)
+	return token
+}
+
+func authUnary(control Controller) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, control)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStream(control Controller) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), control)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// contextStream replaces the context of a stream, to pass values from interceptors to the handler
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (this *contextStream) Context() context.Context {
+	return this.ctx
+}
+
+// withRequestId takes the request id from the x-request-id metadata or generates one, like the REST api
+func withRequestId(ctx context.Context) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestId := ""
+	if values := md.Get(requestIdMetadata); len(values) > 0 {
+		requestId = values[0]
+	}
+	if !logging.ValidRequestId(requestId) {
+		requestId = logging.NewRequestId()
+	}
+	return logging.WithRequestId(ctx, requestId), requestId
+}
+
+func logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, requestId := withRequestId(ctx)
+	err := grpc.SetHeader(ctx, metadata.Pairs(requestIdMetadata, requestId))
+	if err != nil {
+		slog.WarnContext(ctx, "unable to set grpc header", "error", err)
+	}
+	start := time.Now()
+	result, err := handler(ctx, req)
+	logRequest(ctx, info.FullMethod, err, start)
+	return result, err
+}
+
+func logStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, requestId := withRequestId(stream.Context())
+	err := stream.SetHeader(metadata.Pairs(requestIdMetadata, requestId))
+	if err != nil {
+		slog.WarnContext(ctx, "unable to set grpc header", "error", err)
+	}
+	start := time.Now()
+	err = handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	logRequest(ctx, info.FullMethod, err, start)
+	return err
+}
+
+func logRequest(ctx context.Context, method string, err error, start time.Time) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown || code == codes.DataLoss {
+		level = slog.LevelError
+	}
+	remoteAddr := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	slog.Log(ctx, level, "grpc request",
+		"method", method,
+		"code", code.String(),
+		"duration_ms", float64(time.Since(start).Microseconds())/1000,
+		"remote_addr", remoteAddr)
+}
@@ -1,5 +1,5 @@
 /*
- * Copyright 2023 InfAI (CC SES)
+ * Copyright 2026 InfAI (CC SES)
  *
  * Licensed under the Apache License, Version 2.0 (the "License");
  * you may not use this file except in compliance with the License.
@@ -14,10 +14,7 @@
  * limitations under the License.
  */
 
-package options
+// Package pb contains the generated code of the grpc api described in waitingroom.proto
+package pb
 
-import "github.com/SENERGY-Platform/device-waiting-room/pkg/model"
-
-type MigrationTarget interface {
-	SetDevice(device model.Device) (error, int)
-}
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative waitingroom.proto
@@ -0,0 +1,426 @@
+// Copyright 2026 InfAI (CC SES)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.1
+// source: waitingroom.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DeviceWaitingRoom_ListDevices_FullMethodName   = "/devicewaitingroom.v1.DeviceWaitingRoom/ListDevices"
+	DeviceWaitingRoom_ReadDevice_FullMethodName    = "/devicewaitingroom.v1.DeviceWaitingRoom/ReadDevice"
+	DeviceWaitingRoom_SetDevice_FullMethodName     = "/devicewaitingroom.v1.DeviceWaitingRoom/SetDevice"
+	DeviceWaitingRoom_UseDevices_FullMethodName    = "/devicewaitingroom.v1.DeviceWaitingRoom/UseDevices"
+	DeviceWaitingRoom_DeleteDevices_FullMethodName = "/devicewaitingroom.v1.DeviceWaitingRoom/DeleteDevices"
+	DeviceWaitingRoom_HideDevices_FullMethodName   = "/devicewaitingroom.v1.DeviceWaitingRoom/HideDevices"
+	DeviceWaitingRoom_ShowDevices_FullMethodName   = "/devicewaitingroom.v1.DeviceWaitingRoom/ShowDevices"
+	DeviceWaitingRoom_Watch_FullMethodName         = "/devicewaitingroom.v1.DeviceWaitingRoom/Watch"
+)
+
+// DeviceWaitingRoomClient is the client API for DeviceWaitingRoom service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// DeviceWaitingRoom mirrors the REST api; every call expects the metadata "authorization" with a bearer token,
+// which is validated with the configured jwt_pub_rsa_key and must not be expired.
+// Errors use the grpc status codes matching the http status of the REST api, with a google.rpc.ErrorInfo detail,
+// which contains the error code of the problem details as reason and the local_id in the metadata, if known.
+type DeviceWaitingRoomClient interface {
+	ListDevices(ctx context.Context, in *ListDevicesRequest, opts ...grpc.CallOption) (*DeviceList, error)
+	ReadDevice(ctx context.Context, in *ReadDeviceRequest, opts ...grpc.CallOption) (*Device, error)
+	// creates or updates a device; overrides of the user are kept unless overwrite is set
+	SetDevice(ctx context.Context, in *SetDeviceRequest, opts ...grpc.CallOption) (*Device, error)
+	// moves the devices to the device-manager and removes them from the waiting room
+	UseDevices(ctx context.Context, in *DeviceIds, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// moves the devices to the trash
+	DeleteDevices(ctx context.Context, in *DeviceIds, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	HideDevices(ctx context.Context, in *DeviceIds, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ShowDevices(ctx context.Context, in *DeviceIds, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// streams the events of the devices of the user, like the /events websocket;
+	// the stream ends with UNAUTHENTICATED when the token expires
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type deviceWaitingRoomClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeviceWaitingRoomClient(cc grpc.ClientConnInterface) DeviceWaitingRoomClient {
+	return &deviceWaitingRoomClient{cc}
+}
+
+func (c *deviceWaitingRoomClient) ListDevices(ctx context.Context, in *ListDevicesRequest, opts ...grpc.CallOption) (*DeviceList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeviceList)
+	err := c.cc.Invoke(ctx, DeviceWaitingRoom_ListDevices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceWaitingRoomClient) ReadDevice(ctx context.Context, in *ReadDeviceRequest, opts ...grpc.CallOption) (*Device, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Device)
+	err := c.cc.Invoke(ctx, DeviceWaitingRoom_ReadDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceWaitingRoomClient) SetDevice(ctx context.Context, in *SetDeviceRequest, opts ...grpc.CallOption) (*Device, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Device)
+	err := c.cc.Invoke(ctx, DeviceWaitingRoom_SetDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceWaitingRoomClient) UseDevices(ctx context.Context, in *DeviceIds, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, DeviceWaitingRoom_UseDevices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceWaitingRoomClient) DeleteDevices(ctx context.Context, in *DeviceIds, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, DeviceWaitingRoom_DeleteDevices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceWaitingRoomClient) HideDevices(ctx context.Context, in *DeviceIds, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, DeviceWaitingRoom_HideDevices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceWaitingRoomClient) ShowDevices(ctx context.Context, in *DeviceIds, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, DeviceWaitingRoom_ShowDevices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceWaitingRoomClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DeviceWaitingRoom_ServiceDesc.Streams[0], DeviceWaitingRoom_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DeviceWaitingRoom_WatchClient = grpc.ServerStreamingClient[Event]
+
+// DeviceWaitingRoomServer is the server API for DeviceWaitingRoom service.
+// All implementations must embed UnimplementedDeviceWaitingRoomServer
+// for forward compatibility.
+//
+// DeviceWaitingRoom mirrors the REST api; every call expects the metadata "authorization" with a bearer token,
+// which is validated with the configured jwt_pub_rsa_key and must not be expired.
+// Errors use the grpc status codes matching the http status of the REST api, with a google.rpc.ErrorInfo detail,
+// which contains the error code of the problem details as reason and the local_id in the metadata, if known.
+type DeviceWaitingRoomServer interface {
+	ListDevices(context.Context, *ListDevicesRequest) (*DeviceList, error)
+	ReadDevice(context.Context, *ReadDeviceRequest) (*Device, error)
+	// creates or updates a device; overrides of the user are kept unless overwrite is set
+	SetDevice(context.Context, *SetDeviceRequest) (*Device, error)
+	// moves the devices to the device-manager and removes them from the waiting room
+	UseDevices(context.Context, *DeviceIds) (*emptypb.Empty, error)
+	// moves the devices to the trash
+	DeleteDevices(context.Context, *DeviceIds) (*emptypb.Empty, error)
+	HideDevices(context.Context, *DeviceIds) (*emptypb.Empty, error)
+	ShowDevices(context.Context, *DeviceIds) (*emptypb.Empty, error)
+	// streams the events of the devices of the user, like the /events websocket;
+	// the stream ends with UNAUTHENTICATED when the token expires
+	Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedDeviceWaitingRoomServer()
+}
+
+// UnimplementedDeviceWaitingRoomServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDeviceWaitingRoomServer struct{}
+
+func (UnimplementedDeviceWaitingRoomServer) ListDevices(context.Context, *ListDevicesRequest) (*DeviceList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDevices not implemented")
+}
+func (UnimplementedDeviceWaitingRoomServer) ReadDevice(context.Context, *ReadDeviceRequest) (*Device, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadDevice not implemented")
+}
+func (UnimplementedDeviceWaitingRoomServer) SetDevice(context.Context, *SetDeviceRequest) (*Device, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDevice not implemented")
+}
+func (UnimplementedDeviceWaitingRoomServer) UseDevices(context.Context, *DeviceIds) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UseDevices not implemented")
+}
+func (UnimplementedDeviceWaitingRoomServer) DeleteDevices(context.Context, *DeviceIds) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDevices not implemented")
+}
+func (UnimplementedDeviceWaitingRoomServer) HideDevices(context.Context, *DeviceIds) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HideDevices not implemented")
+}
+func (UnimplementedDeviceWaitingRoomServer) ShowDevices(context.Context, *DeviceIds) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShowDevices not implemented")
+}
+func (UnimplementedDeviceWaitingRoomServer) Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedDeviceWaitingRoomServer) mustEmbedUnimplementedDeviceWaitingRoomServer() {}
+func (UnimplementedDeviceWaitingRoomServer) testEmbeddedByValue()                           {}
+
+// UnsafeDeviceWaitingRoomServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeviceWaitingRoomServer will
+// result in compilation errors.
+type UnsafeDeviceWaitingRoomServer interface {
+	mustEmbedUnimplementedDeviceWaitingRoomServer()
+}
+
+func RegisterDeviceWaitingRoomServer(s grpc.ServiceRegistrar, srv DeviceWaitingRoomServer) {
+	// If the following call pancis, it indicates UnimplementedDeviceWaitingRoomServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DeviceWaitingRoom_ServiceDesc, srv)
+}
+
+func _DeviceWaitingRoom_ListDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDevicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceWaitingRoomServer).ListDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeviceWaitingRoom_ListDevices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceWaitingRoomServer).ListDevices(ctx, req.(*ListDevicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceWaitingRoom_ReadDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadDeviceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceWaitingRoomServer).ReadDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeviceWaitingRoom_ReadDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceWaitingRoomServer).ReadDevice(ctx, req.(*ReadDeviceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceWaitingRoom_SetDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDeviceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceWaitingRoomServer).SetDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeviceWaitingRoom_SetDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceWaitingRoomServer).SetDevice(ctx, req.(*SetDeviceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceWaitingRoom_UseDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeviceIds)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceWaitingRoomServer).UseDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeviceWaitingRoom_UseDevices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceWaitingRoomServer).UseDevices(ctx, req.(*DeviceIds))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceWaitingRoom_DeleteDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeviceIds)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceWaitingRoomServer).DeleteDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeviceWaitingRoom_DeleteDevices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceWaitingRoomServer).DeleteDevices(ctx, req.(*DeviceIds))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceWaitingRoom_HideDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeviceIds)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceWaitingRoomServer).HideDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeviceWaitingRoom_HideDevices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceWaitingRoomServer).HideDevices(ctx, req.(*DeviceIds))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceWaitingRoom_ShowDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeviceIds)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceWaitingRoomServer).ShowDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DeviceWaitingRoom_ShowDevices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceWaitingRoomServer).ShowDevices(ctx, req.(*DeviceIds))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeviceWaitingRoom_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DeviceWaitingRoomServer).Watch(m, &grpc.GenericServerStream[WatchRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DeviceWaitingRoom_WatchServer = grpc.ServerStreamingServer[Event]
+
+// DeviceWaitingRoom_ServiceDesc is the grpc.ServiceDesc for DeviceWaitingRoom service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DeviceWaitingRoom_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "devicewaitingroom.v1.DeviceWaitingRoom",
+	HandlerType: (*DeviceWaitingRoomServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDevices",
+			Handler:    _DeviceWaitingRoom_ListDevices_Handler,
+		},
+		{
+			MethodName: "ReadDevice",
+			Handler:    _DeviceWaitingRoom_ReadDevice_Handler,
+		},
+		{
+			MethodName: "SetDevice",
+			Handler:    _DeviceWaitingRoom_SetDevice_Handler,
+		},
+		{
+			MethodName: "UseDevices",
+			Handler:    _DeviceWaitingRoom_UseDevices_Handler,
+		},
+		{
+			MethodName: "DeleteDevices",
+			Handler:    _DeviceWaitingRoom_DeleteDevices_Handler,
+		},
+		{
+			MethodName: "HideDevices",
+			Handler:    _DeviceWaitingRoom_HideDevices_Handler,
+		},
+		{
+			MethodName: "ShowDevices",
+			Handler:    _DeviceWaitingRoom_ShowDevices_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _DeviceWaitingRoom_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "waitingroom.proto",
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/options"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/rpc/pb"
+	"google.golang.org/grpc"
+	"log/slog"
+	"net"
+	"sync"
+)
+
+// Controller contains the operations of the api.Controller exposed by the grpc api, the event subscriptions used by Watch
+// and the current config, whose jwt_pub_rsa_key validates tokens
+type Controller interface {
+	Config() configuration.Config
+	ListDevices(ctx context.Context, token auth.Token, options options.List) (result model.DeviceList, err error)
+	ReadDevice(ctx context.Context, token auth.Token, localId string) (result model.Device, err error)
+	SetDevice(ctx context.Context, token auth.Token, device model.Device, overwrite bool) (result model.Device, err error)
+	UseMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error)
+	DeleteMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error)
+	HideMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error)
+	ShowMultipleDevices(ctx context.Context, token auth.Token, ids []string) (err error)
+	Subscribe(transport string, subId string, userId string, f func(eventType string, id string))
+	Unsubscribe(subId string)
+}
+
+// Start serves the grpc api on config.GrpcPort until ctx is done; an empty port disables the grpc api
+func Start(ctx context.Context, wg *sync.WaitGroup, config configuration.Config, control Controller) error {
+	if config.GrpcPort == "" {
+		slog.Info("grpc api disabled")
+		return nil
+	}
+	slog.Info("start grpc api")
+	listener, err := net.Listen("tcp", ":"+config.GrpcPort)
+	if err != nil {
+		return err
+	}
+	server := NewServer(ctx, control)
+	go func() {
+		slog.Info("grpc listening", "addr", listener.Addr().String())
+		err := server.Serve(listener)
+		if err != nil {
+			slog.Error("grpc server error", "error", err)
+		} else {
+			slog.Info("closing grpc server")
+		}
+	}()
+	//wg is done after the running calls are finished, not when Serve returns
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+	return nil
+}
+
+// NewServer creates a grpc server with the DeviceWaitingRoom service; running Watch streams end when ctx is done
+func NewServer(ctx context.Context, control Controller) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(logUnary, authUnary(control)),
+		grpc.ChainStreamInterceptor(logStream, authStream(control)),
+	)
+	pb.RegisterDeviceWaitingRoomServer(server, &Server{ctx: ctx, control: control})
+	return server
+}
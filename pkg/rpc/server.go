@@ -0,0 +1,130 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/rpc/pb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"log/slog"
+	"time"
+)
+
+// Server implements pb.DeviceWaitingRoomServer by calling the Controller with the token of the request
+type Server struct {
+	pb.UnimplementedDeviceWaitingRoomServer
+	ctx     context.Context
+	control Controller
+}
+
+func (this *Server) ListDevices(ctx context.Context, request *pb.ListDevicesRequest) (*pb.DeviceList, error) {
+	o, err := toListOptions(request)
+	if err != nil {
+		return nil, toStatus(model.NewError(model.ErrorCodeInvalidRequest, err))
+	}
+	result, err := this.control.ListDevices(ctx, getToken(ctx), o)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoDeviceList(result), nil
+}
+
+func (this *Server) ReadDevice(ctx context.Context, request *pb.ReadDeviceRequest) (*pb.Device, error) {
+	result, err := this.control.ReadDevice(ctx, getToken(ctx), request.GetLocalId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoDevice(result), nil
+}
+
+func (this *Server) SetDevice(ctx context.Context, request *pb.SetDeviceRequest) (*pb.Device, error) {
+	if request.GetDevice().GetLocalId() == "" {
+		return nil, toStatus(model.NewError(model.ErrorCodeInvalidRequest, errors.New("empty local_id in device")))
+	}
+	result, err := this.control.SetDevice(ctx, getToken(ctx), fromProtoDevice(request.GetDevice()), request.GetOverwrite())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoDevice(result), nil
+}
+
+func (this *Server) UseDevices(ctx context.Context, request *pb.DeviceIds) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, toStatus(this.control.UseMultipleDevices(ctx, getToken(ctx), request.GetLocalIds()))
+}
+
+func (this *Server) DeleteDevices(ctx context.Context, request *pb.DeviceIds) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, toStatus(this.control.DeleteMultipleDevices(ctx, getToken(ctx), request.GetLocalIds()))
+}
+
+func (this *Server) HideDevices(ctx context.Context, request *pb.DeviceIds) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, toStatus(this.control.HideMultipleDevices(ctx, getToken(ctx), request.GetLocalIds()))
+}
+
+func (this *Server) ShowDevices(ctx context.Context, request *pb.DeviceIds) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, toStatus(this.control.ShowMultipleDevices(ctx, getToken(ctx), request.GetLocalIds()))
+}
+
+// Watch subscribes to the events of the user until the client cancels the stream, the token expires or the server stops.
+func (this *Server) Watch(request *pb.WatchRequest, stream grpc.ServerStreamingServer[pb.Event]) error {
+	ctx := stream.Context()
+	token := getToken(ctx)
+	ctx = logging.With(ctx, logging.UserIdKey, token.GetUserId())
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan *pb.Event)
+	subId := uuid.NewString()
+	this.control.Subscribe(model.TransportGrpc, subId, token.GetUserId(), func(eventType string, id string) {
+		select {
+		case events <- &pb.Event{Type: eventType, LocalId: id}:
+		case <-ctx.Done():
+		}
+	})
+	defer this.control.Unsubscribe(subId)
+	slog.DebugContext(ctx, "grpc watch subscribed")
+
+	expiration := time.NewTimer(expiresIn(token))
+	defer expiration.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-this.ctx.Done():
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-expiration.C:
+			return toStatus(model.NewError(model.ErrorCodeUnauthorized, errors.New("expired auth token")))
+		case event := <-events:
+			err := stream.Send(event)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// expiresIn returns the time until the token expires, relative to auth.TimeNow
+func expiresIn(token auth.Token) time.Duration {
+	return time.Unix(token.Expiration, 0).Sub(auth.TimeNow())
+}
@@ -23,6 +23,9 @@ func TestDevices(t *testing.T) {
 	t.Run("postgres", func(t *testing.T) {
 		testDevices(t, "postgres")
 	})
+	t.Run("sqlite", func(t *testing.T) {
+		testDevices(t, "sqlite")
+	})
 }
 
 func testDevices(t *testing.T, dbImpl string) {
@@ -35,7 +38,7 @@ func testDevices(t *testing.T, dbImpl string) {
 	if err != nil {
 		t.Fatal("ERROR: unable to load config", err)
 	}
-	config.DeleteAfterUseWaitDuration = "1s"
+	config.DeleteAfterUseWaitDuration = configuration.Duration(time.Second)
 
 	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
 	if err != nil {
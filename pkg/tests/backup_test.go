@@ -0,0 +1,204 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/backup"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackup(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	mongoconfig, err := deployTestPersistenceContainer(configuration.Mongo, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	postgresconfig, err := deployTestPersistenceContainer(configuration.Postgres, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	location := filepath.Join(t.TempDir(), "backup.ndjson.gz")
+
+	mongoctx, mongocancel := context.WithCancel(ctx)
+	defer mongocancel()
+	mongowg := &sync.WaitGroup{}
+	err = pkg.Start(mongoctx, mongowg, mongoconfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("create device 1", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId:    "foo",
+			Name:       "bar",
+			Attributes: []models.Attribute{{Key: "gateway", Value: "gw-1"}},
+		},
+	}))
+	t.Run("create device 2", sendDevice(config, "user2", model.Device{
+		Device: models.Device{
+			LocalId: "bar",
+			Name:    "batz",
+		},
+	}))
+
+	mongocancel()
+	mongowg.Wait()
+
+	t.Run("backup mongo", func(t *testing.T) {
+		trailer, err := pkg.Backup(mongoconfig, location)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if trailer.Count != 2 || trailer.History == nil || trailer.History.Count != 2 {
+			t.Error(trailer)
+		}
+	})
+
+	t.Run("verify backup", func(t *testing.T) {
+		header, trailer, err := pkg.VerifyBackup(location)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if header.Source != string(configuration.Mongo) || trailer.Count != 2 || trailer.History == nil || trailer.History.Count != 2 {
+			t.Error(header, trailer)
+		}
+	})
+
+	postgresctx, postgrescancel := context.WithCancel(ctx)
+	defer postgrescancel()
+	postgreswg := &sync.WaitGroup{}
+	err = pkg.Start(postgresctx, postgreswg, postgresconfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("create postgres device", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId: "removed",
+			Name:    "removed",
+		},
+	}))
+
+	postgrescancel()
+	postgreswg.Wait()
+
+	t.Run("restore replace into postgres", func(t *testing.T) {
+		result, err := pkg.Restore(postgresconfig, location, backup.ModeReplace)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if result.Restored != 2 || result.Removed != 1 || result.RestoredVersions != 2 || result.RemovedVersions != 1 {
+			t.Error(result)
+		}
+	})
+
+	t.Run("restore merge into postgres", func(t *testing.T) {
+		result, err := pkg.Restore(postgresconfig, location, backup.ModeMerge)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if result.Restored != 2 || result.Removed != 0 || result.RestoredVersions != 2 || result.RemovedVersions != 0 {
+			t.Error(result)
+		}
+	})
+
+	err = pkg.Start(ctx, wg, postgresconfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("search restored user1", searchDevices(config, "user1", "", model.DeviceList{
+		Total:  1,
+		Limit:  10,
+		Offset: 0,
+		Sort:   "local_id",
+		Search: "",
+		Result: []model.Device{
+			{
+				Device: models.Device{
+					LocalId:    "foo",
+					Name:       "bar",
+					Attributes: []models.Attribute{{Key: "gateway", Value: "gw-1"}},
+				},
+				UserId: "user1",
+				Hidden: false,
+			},
+		},
+	}))
+
+	t.Run("search restored user2", searchDevices(config, "user2", "", model.DeviceList{
+		Total:  1,
+		Limit:  10,
+		Offset: 0,
+		Sort:   "local_id",
+		Search: "",
+		Result: []model.Device{
+			{
+				Device: models.Device{
+					LocalId: "bar",
+					Name:    "batz",
+				},
+				UserId: "user2",
+				Hidden: false,
+			},
+		},
+	}))
+}
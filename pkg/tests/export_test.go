@@ -0,0 +1,216 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/api"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testExport(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testExport(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testExport(t, "sqlite")
+	})
+}
+
+func testExport(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	devices := []model.Device{}
+	for i := 0; i < 250; i++ {
+		devices = append(devices, model.Device{
+			Device: models.Device{
+				LocalId:      "d" + strconv.Itoa(1000+i),
+				Name:         "device, " + strconv.Itoa(i),
+				DeviceTypeId: "dt" + strconv.Itoa(i%2),
+				Attributes: []models.Attribute{
+					{Key: "gateway", Value: "gw-" + strconv.Itoa(i%3)},
+				},
+			},
+			UserId: "user1",
+		})
+	}
+	for _, device := range devices {
+		t.Run("create device "+device.LocalId, sendDevice(config, "user1", device))
+	}
+
+	dt0 := []model.Device{}
+	for _, device := range devices {
+		if device.DeviceTypeId == "dt0" {
+			dt0 = append(dt0, device)
+		}
+	}
+
+	t.Run("export ndjson", exportNdjson(config, "user1", url.Values{}, "", devices))
+	t.Run("export ndjson by accept header", exportNdjson(config, "user1", url.Values{}, "application/x-ndjson", devices))
+	t.Run("export ndjson filtered", exportNdjson(config, "user1", url.Values{"device_type_id": {"dt0"}, "limit": {"5"}}, "", dt0))
+	t.Run("export ndjson other user", exportNdjson(config, "user2", url.Values{}, "", []model.Device{}))
+	t.Run("export csv", exportCsv(config, "user1", url.Values{"format": {"csv"}}, "", devices))
+	t.Run("export csv by accept header", exportCsv(config, "user1", url.Values{"device_type_id": {"dt0"}}, "text/csv", dt0))
+	t.Run("export csv other user", exportCsv(config, "user2", url.Values{"format": {"csv"}}, "", []model.Device{}))
+}
+
+func export(config configuration.Config, userId string, query url.Values, accept string) (body []byte, err error) {
+	token, err := createToken(userId)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", "http://localhost:"+config.ApiPort+"/export/devices?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(strconv.Itoa(resp.StatusCode) + " " + string(body))
+	}
+	return body, nil
+}
+
+func exportNdjson(config configuration.Config, userId string, query url.Values, accept string, expected []model.Device) func(t *testing.T) {
+	return func(t *testing.T) {
+		body, err := export(config, userId, query, accept)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		actual := []model.Device{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		for decoder.More() {
+			device := model.Device{}
+			err = decoder.Decode(&device)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			actual = append(actual, normalizeDevice(device))
+		}
+		expectedList := []model.Device{}
+		for _, device := range expected {
+			expectedList = append(expectedList, normalizeDevice(device))
+		}
+		if !reflect.DeepEqual(actual, expectedList) {
+			t.Errorf("\n%#v\n%#v\n", actual, expectedList)
+		}
+	}
+}
+
+func exportCsv(config configuration.Config, userId string, query url.Values, accept string, expected []model.Device) func(t *testing.T) {
+	return func(t *testing.T) {
+		body, err := export(config, userId, query, accept)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(records) == 0 || !reflect.DeepEqual(records[0], api.CsvColumns) {
+			t.Error("missing csv header", records)
+			return
+		}
+		records = records[1:]
+		if len(records) != len(expected) {
+			t.Error(len(records), len(expected))
+			return
+		}
+		for i, record := range records {
+			if record[0] != expected[i].LocalId || record[2] != expected[i].Name || record[3] != expected[i].DeviceTypeId {
+				t.Error(record, expected[i])
+				return
+			}
+			attributes := []models.Attribute{}
+			err = json.Unmarshal([]byte(record[4]), &attributes)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !reflect.DeepEqual(attributes, expected[i].Attributes) {
+				t.Error(attributes, expected[i].Attributes)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFacets(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testFacets(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testFacets(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testFacets(t, "sqlite")
+	})
+}
+
+func testFacets(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("create device 1", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId:      "d1",
+			Name:         "d1",
+			DeviceTypeId: "dt1",
+			Attributes:   []models.Attribute{{Key: "gateway", Value: "gw-1"}},
+		},
+	}))
+	t.Run("create device 2", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId:      "d2",
+			Name:         "d2",
+			DeviceTypeId: "dt2",
+			Attributes:   []models.Attribute{{Key: "gateway", Value: "gw-1"}},
+		},
+	}))
+	t.Run("create device 3", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId:      "d3",
+			Name:         "d3",
+			DeviceTypeId: "dt1",
+			Attributes:   []models.Attribute{{Key: "gateway", Value: "gw-2"}},
+		},
+	}))
+	t.Run("create device 4", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId:      "d4",
+			Name:         "d4",
+			DeviceTypeId: "dt1",
+		},
+	}))
+	t.Run("create device of other user", sendDevice(config, "user2", model.Device{
+		Device: models.Device{
+			LocalId:      "d5",
+			Name:         "d5",
+			DeviceTypeId: "dt2",
+		},
+	}))
+	t.Run("hide device 4", hideDevice(config, "user1", "d4"))
+
+	t.Run("all facets", listFacets(config, "user1", url.Values{"facets": {"device_type_id,hidden,attr.gateway"}}, &model.DeviceFacets{
+		DeviceTypeId: []model.FacetCount{{Value: "dt1", Count: 2}, {Value: "dt2", Count: 1}},
+		Hidden:       []model.FacetCount{{Value: "false", Count: 3}, {Value: "true", Count: 1}},
+		Attributes: map[string][]model.FacetCount{
+			"gateway": {{Value: "gw-1", Count: 2}, {Value: "gw-2", Count: 1}},
+		},
+	}))
+
+	t.Run("facets with hidden devices", listFacets(config, "user1", url.Values{"facets": {"device_type_id"}, "show_hidden": {"true"}}, &model.DeviceFacets{
+		DeviceTypeId: []model.FacetCount{{Value: "dt1", Count: 3}, {Value: "dt2", Count: 1}},
+	}))
+
+	t.Run("facets with filter", listFacets(config, "user1", url.Values{"facets": {"device_type_id,hidden", "attr.gateway"}, "attr": {"gateway=gw-1"}}, &model.DeviceFacets{
+		DeviceTypeId: []model.FacetCount{{Value: "dt1", Count: 1}, {Value: "dt2", Count: 1}},
+		Hidden:       []model.FacetCount{{Value: "false", Count: 2}},
+		Attributes: map[string][]model.FacetCount{
+			"gateway": {{Value: "gw-1", Count: 2}},
+		},
+	}))
+
+	t.Run("unknown attribute facet", listFacets(config, "user1", url.Values{"facets": {"attr.unknown"}}, &model.DeviceFacets{
+		Attributes: map[string][]model.FacetCount{
+			"unknown": {},
+		},
+	}))
+
+	t.Run("without facets", listFacets(config, "user1", url.Values{}, nil))
+}
+
+func listFacets(config configuration.Config, userId string, query url.Values, expected *model.DeviceFacets) func(t *testing.T) {
+	return func(t *testing.T) {
+		token, err := createToken(userId)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req, err := http.NewRequest("GET", "http://localhost:"+config.ApiPort+"/devices?"+query.Encode(), nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Header.Set("Authorization", token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			t.Error(resp.StatusCode, string(b))
+			return
+		}
+		actual := model.DeviceList{}
+		err = json.NewDecoder(resp.Body).Decode(&actual)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(actual.Facets, expected) {
+			t.Errorf("\n%#v\n%#v\n", actual.Facets, expected)
+			return
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testFilter(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testFilter(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testFilter(t, "sqlite")
+	})
+}
+
+func testFilter(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	device1 := model.Device{
+		Device: models.Device{
+			LocalId:      "d1",
+			Name:         "d1",
+			DeviceTypeId: "dt1",
+			Attributes: []models.Attribute{
+				{Key: "gateway", Value: "gw-1"},
+				{Key: "network", Value: "n1"},
+			},
+		},
+		UserId: "user1",
+	}
+	device2 := model.Device{
+		Device: models.Device{
+			LocalId:      "d2",
+			Name:         "d2",
+			DeviceTypeId: "dt2",
+			Attributes: []models.Attribute{
+				{Key: "gateway", Value: "gw-2"},
+			},
+		},
+		UserId: "user1",
+	}
+	device3 := model.Device{
+		Device: models.Device{
+			LocalId:      "d3",
+			Name:         "d3",
+			DeviceTypeId: "dt1",
+		},
+		UserId: "user1",
+	}
+
+	t.Run("create device 1", sendDevice(config, "user1", device1))
+	t.Run("create device 2", sendDevice(config, "user1", device2))
+	t.Run("create device 3", sendDevice(config, "user1", device3))
+
+	t.Run("filter attr gateway=gw-1", listDevicesWithQuery(config, "user1", url.Values{"attr": {"gateway=gw-1"}}, []model.Device{device1}))
+	t.Run("filter attr gateway=gw-3", listDevicesWithQuery(config, "user1", url.Values{"attr": {"gateway=gw-3"}}, []model.Device{}))
+	t.Run("filter attr gateway=n1", listDevicesWithQuery(config, "user1", url.Values{"attr": {"gateway=n1"}}, []model.Device{}))
+	t.Run("filter attr_exists gateway", listDevicesWithQuery(config, "user1", url.Values{"attr_exists": {"gateway"}}, []model.Device{device1, device2}))
+	t.Run("filter attr_exists gateway and network", listDevicesWithQuery(config, "user1", url.Values{"attr_exists": {"gateway", "network"}}, []model.Device{device1}))
+	t.Run("filter device_type_id dt1", listDevicesWithQuery(config, "user1", url.Values{"device_type_id": {"dt1"}}, []model.Device{device1, device3}))
+	t.Run("filter device_type_id dt1,dt2", listDevicesWithQuery(config, "user1", url.Values{"device_type_id": {"dt1,dt2"}}, []model.Device{device1, device2, device3}))
+	t.Run("filter device_type_id and attr", listDevicesWithQuery(config, "user1", url.Values{"device_type_id": {"dt1"}, "attr_exists": {"gateway"}}, []model.Device{device1}))
+	t.Run("filter created_before future", listDevicesWithQuery(config, "user1", url.Values{"created_before": {time.Now().Add(time.Hour).Format(time.RFC3339)}}, []model.Device{device1, device2, device3}))
+	t.Run("filter created_after future", listDevicesWithQuery(config, "user1", url.Values{"created_after": {time.Now().Add(time.Hour).Format(time.RFC3339)}}, []model.Device{}))
+	t.Run("filter updated_after past", listDevicesWithQuery(config, "user1", url.Values{"updated_after": {time.Now().Add(-time.Hour).Format(time.RFC3339)}}, []model.Device{device1, device2, device3}))
+	t.Run("filter other user", listDevicesWithQuery(config, "user2", url.Values{"attr_exists": {"gateway"}}, []model.Device{}))
+}
+
+func listDevicesWithQuery(config configuration.Config, userId string, query url.Values, expected []model.Device) func(t *testing.T) {
+	return func(t *testing.T) {
+		token, err := createToken(userId)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		query.Set("limit", "10")
+		req, err := http.NewRequest("GET", "http://localhost:"+config.ApiPort+"/devices?"+query.Encode(), nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Header.Set("Authorization", token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			t.Error(resp.StatusCode, string(b))
+			return
+		}
+		actual := model.DeviceList{}
+		err = json.NewDecoder(resp.Body).Decode(&actual)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		actual = normalizeDeviceList(actual)
+		expectedList := normalizeDeviceList(model.DeviceList{Result: expected})
+		if actual.Total != int64(len(expected)) {
+			t.Error(actual.Total, len(expected))
+		}
+		if !reflect.DeepEqual(actual.Result, expectedList.Result) {
+			t.Errorf("\n%#v\n%#v\n", actual.Result, expectedList.Result)
+			return
+		}
+	}
+}
@@ -0,0 +1,334 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/rpc"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/rpc/pb"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/golang-jwt/jwt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGrpc(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testGrpc(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testGrpc(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testGrpc(t, "sqlite")
+	})
+}
+
+func testGrpc(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+	key, pubRsaKey, err := createTestKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.JwtPubRsaKey = pubRsaKey
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+	freePort, err = getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.GrpcPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	conn, err := grpc.NewClient("localhost:"+config.GrpcPort, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewDeviceWaitingRoomClient(conn)
+
+	token, err := createSignedToken(key, "user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userCtx := metadata.AppendToOutgoingContext(ctx, rpc.AuthorizationMetadata, token)
+
+	t.Run("missing token", func(t *testing.T) {
+		_, err := client.ListDevices(ctx, &pb.ListDevicesRequest{})
+		if code := status.Code(err); code != codes.Unauthenticated {
+			t.Error(code, err)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		otherKey, _, err := createTestKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		foreignToken, err := createSignedToken(otherKey, "user1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsignedToken, err := createToken("user1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expiredClaims := testClaims("user1")
+		expiredClaims.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+		expiredToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, expiredClaims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens := map[string]string{"foreign key": foreignToken, "unsigned": unsignedToken, "expired": "Bearer " + expiredToken}
+		for name, token := range tokens {
+			t.Run(name, func(t *testing.T) {
+				invalidCtx := metadata.AppendToOutgoingContext(ctx, rpc.AuthorizationMetadata, token)
+				_, err := client.ListDevices(invalidCtx, &pb.ListDevicesRequest{})
+				if code := status.Code(err); code != codes.Unauthenticated {
+					t.Error(code, err)
+				}
+				_, err = client.DeleteDevices(invalidCtx, &pb.DeviceIds{LocalIds: []string{"lid1"}})
+				if code := status.Code(err); code != codes.Unauthenticated {
+					t.Error(code, err)
+				}
+				watch, err := client.Watch(invalidCtx, &pb.WatchRequest{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = watch.Recv()
+				if code := status.Code(err); code != codes.Unauthenticated {
+					t.Error(code, err)
+				}
+			})
+		}
+	})
+
+	watchCtx, stopWatch := context.WithCancel(userCtx)
+	defer stopWatch()
+	watch, err := client.Watch(watchCtx, &pb.WatchRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan *pb.Event, 10)
+	go func() {
+		defer close(events)
+		for {
+			event, err := watch.Recv()
+			if err != nil {
+				return
+			}
+			events <- event
+		}
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	t.Run("set device", func(t *testing.T) {
+		result, err := client.SetDevice(userCtx, &pb.SetDeviceRequest{Device: &pb.Device{
+			LocalId:    "lid1",
+			Name:       "foo",
+			Attributes: []*pb.Attribute{{Key: "a", Value: "1"}},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.GetLocalId() != "lid1" || result.GetUserId() != "user1" || result.GetName() != "foo" || result.GetUpdatedAt() == nil {
+			t.Error(result)
+		}
+	})
+
+	t.Run("set device without local_id", func(t *testing.T) {
+		_, err := client.SetDevice(userCtx, &pb.SetDeviceRequest{Device: &pb.Device{Name: "foo"}})
+		if code := status.Code(err); code != codes.InvalidArgument {
+			t.Error(code, err)
+		}
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		select {
+		case event := <-events:
+			if event.GetType() != model.WsUpdateSetType || event.GetLocalId() != "lid1" {
+				t.Error(event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("missing event")
+		}
+	})
+
+	t.Run("read device", func(t *testing.T) {
+		result, err := client.ReadDevice(userCtx, &pb.ReadDeviceRequest{LocalId: "lid1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.GetName() != "foo" || len(result.GetAttributes()) != 1 || result.GetAttributes()[0].GetValue() != "1" {
+			t.Error(result)
+		}
+	})
+
+	t.Run("hide devices", func(t *testing.T) {
+		_, err := client.HideDevices(userCtx, &pb.DeviceIds{LocalIds: []string{"lid1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := client.ListDevices(userCtx, &pb.ListDevicesRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.GetTotal() != 0 || list.GetLimit() != 100 {
+			t.Error(list)
+		}
+		list, err = client.ListDevices(userCtx, &pb.ListDevicesRequest{ShowHidden: true, Facets: []string{"hidden"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.GetTotal() != 1 || len(list.GetResult()) != 1 || !list.GetResult()[0].GetHidden() || len(list.GetFacets().GetHidden()) == 0 {
+			t.Error(list)
+		}
+	})
+
+	t.Run("show devices", func(t *testing.T) {
+		_, err := client.ShowDevices(userCtx, &pb.DeviceIds{LocalIds: []string{"lid1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := client.ListDevices(userCtx, &pb.ListDevicesRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.GetTotal() != 1 {
+			t.Error(list)
+		}
+	})
+
+	t.Run("invalid list request", func(t *testing.T) {
+		_, err := client.ListDevices(userCtx, &pb.ListDevicesRequest{Facets: []string{"unknown"}})
+		if code := status.Code(err); code != codes.InvalidArgument {
+			t.Error(code, err)
+		}
+	})
+
+	t.Run("delete devices", func(t *testing.T) {
+		_, err := client.DeleteDevices(userCtx, &pb.DeviceIds{LocalIds: []string{"lid1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.ReadDevice(userCtx, &pb.ReadDeviceRequest{LocalId: "lid1"})
+		s := status.Convert(err)
+		if s.Code() != codes.NotFound {
+			t.Fatal(s.Code(), err)
+		}
+		if len(s.Details()) != 1 {
+			t.Fatal(s.Details())
+		}
+		info, ok := s.Details()[0].(*errdetails.ErrorInfo)
+		if !ok || info.GetReason() != string(model.ErrorCodeNotFound) || info.GetMetadata()["local_id"] != "lid1" {
+			t.Error(s.Details())
+		}
+	})
+
+	t.Run("use devices", func(t *testing.T) {
+		_, err := client.SetDevice(userCtx, &pb.SetDeviceRequest{Device: &pb.Device{LocalId: "lid2", Name: "bar"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.UseDevices(userCtx, &pb.DeviceIds{LocalIds: []string{"lid2"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.ReadDevice(userCtx, &pb.ReadDeviceRequest{LocalId: "lid2"})
+		if code := status.Code(err); code != codes.NotFound {
+			t.Error(code, err)
+		}
+	})
+
+	t.Run("foreign device", func(t *testing.T) {
+		_, err := client.SetDevice(userCtx, &pb.SetDeviceRequest{Device: &pb.Device{LocalId: "lid3"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		otherToken, err := createSignedToken(key, "user2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		otherCtx := metadata.AppendToOutgoingContext(ctx, rpc.AuthorizationMetadata, otherToken)
+		_, err = client.HideDevices(otherCtx, &pb.DeviceIds{LocalIds: []string{"lid3"}})
+		if code := status.Code(err); code != codes.PermissionDenied {
+			t.Error(code, err)
+		}
+	})
+
+	t.Run("watch metrics", expectMetrics(config, []string{
+		`device_waiting_room_grpc_watch_streams 1`,
+		`device_waiting_room_ws_subscriptions 0`,
+	}))
+
+	t.Run("stop watch", func(t *testing.T) {
+		stopWatch()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					return
+				}
+			case <-timeout:
+				t.Error("watch stream not closed")
+				return
+			}
+		}
+	})
+}
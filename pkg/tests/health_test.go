@@ -0,0 +1,190 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testPubRsaKey = `MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEArwI+YDxMBAAKP5I2odn0GHTbfYzbVx0pfIY3kE8wKBSJ7DLuaauUR9BvbD0fr5Nu61LRus4hHK4muv7Ej2PIY907LsjvW9HPlsIpF3U0jO0jSMxrqKhKFDl48ejeFbytL4UJWGhYLVvGPk3igHIjgnQ3oA6ZzZyPgXHZiuRu9yGY/murS1MH1ZP+PM5fxE1pj9/OC1gcK8Ar1ZQXBG0V8hhEqYXHVqQa/FpcQDQsO8Z+QEoO014i4Q5/zfQwS/LbyrRduVYFyVbvdYT/trjoF4kpeIo+mkrjYVs/CAX8OGQ5Y+4U9tUZr7CtRhEfI671SmdachvDe30A5EP1NOnQhwIDAQAB`
+
+func TestHealth(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testHealth(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testHealth(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testHealth(t, "sqlite")
+	})
+}
+
+func testHealth(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+	config.JwtPubRsaKey = testPubRsaKey
+	config.HealthCheckDeviceManager = true
+	config.ShutdownDelay = configuration.Duration(2 * time.Second)
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	apictx, apicancel := context.WithCancel(ctx)
+	defer apicancel()
+	err = pkg.Start(apictx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("live", checkHealth(config, "/health/live", http.StatusOK, model.Health{Status: model.HealthOk}))
+
+	t.Run("ready", checkHealth(config, "/health/ready", http.StatusOK, model.Health{
+		Status: model.HealthOk,
+		Checks: map[string]model.HealthCheck{
+			"database":       {Status: model.HealthOk},
+			"jwt_key":        {Status: model.HealthOk},
+			"device_manager": {Status: model.HealthOk},
+		},
+	}))
+
+	apicancel()
+	time.Sleep(500 * time.Millisecond)
+
+	t.Run("ready during shutdown", func(t *testing.T) {
+		resp, err := http.Get("http://localhost:" + config.ApiPort + "/health/ready")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		actual := model.Health{}
+		err = json.NewDecoder(resp.Body).Decode(&actual)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if resp.StatusCode != http.StatusServiceUnavailable || actual.Status != model.HealthFailed || actual.Checks["shutdown"].Status != model.HealthFailed {
+			t.Error(resp.StatusCode, actual)
+		}
+	})
+
+	t.Run("live during shutdown", checkHealth(config, "/health/live", http.StatusOK, model.Health{Status: model.HealthOk}))
+
+	t.Run("request during shutdown", func(t *testing.T) {
+		jsonRequest(t, config, "testOwner", http.MethodGet, "/devices", nil, http.StatusOK, nil)
+	})
+}
+
+func TestHealthEmptyJwtKey(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+	config.DeviceManagerUrl = "http://localhost:1" //unreachable, but not checked without health_check_device_manager
+	config.JwtPubRsaKey = ""
+	config.HealthCheckDeviceManager = false
+
+	config, err = deployTestPersistenceContainer(configuration.Sqlite, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("ready", checkHealth(config, "/health/ready", http.StatusOK, model.Health{
+		Status: model.HealthOk,
+		Checks: map[string]model.HealthCheck{
+			"database": {Status: model.HealthOk},
+		},
+	}))
+}
+
+func checkHealth(config configuration.Config, path string, expectedStatusCode int, expected model.Health) func(t *testing.T) {
+	return func(t *testing.T) {
+		resp, err := http.Get("http://localhost:" + config.ApiPort + path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != expectedStatusCode {
+			t.Error(resp.StatusCode)
+		}
+		actual := model.Health{}
+		err = json.NewDecoder(resp.Body).Decode(&actual)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("\n%#v\n%#v\n", actual, expected)
+		}
+	}
+}
@@ -20,6 +20,9 @@ func TestHiddenDevices(t *testing.T) {
 	t.Run("postgres", func(t *testing.T) {
 		testHiddenDevices(t, "postgres")
 	})
+	t.Run("sqlite", func(t *testing.T) {
+		testHiddenDevices(t, "sqlite")
+	})
 }
 
 func testHiddenDevices(t *testing.T, dbImpl string) {
@@ -179,6 +182,9 @@ func TestHideDevices(t *testing.T) {
 	t.Run("postgres", func(t *testing.T) {
 		testHideDevices(t, "postgres")
 	})
+	t.Run("sqlite", func(t *testing.T) {
+		testHideDevices(t, "sqlite")
+	})
 }
 
 func testHideDevices(t *testing.T, dbImpl string) {
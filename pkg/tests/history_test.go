@@ -0,0 +1,196 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testHistory(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testHistory(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testHistory(t, "sqlite")
+	})
+}
+
+func testHistory(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+	config.HistoryLimit = 3
+	config.DeleteAfterUseWaitDuration = 0
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	device := model.Device{
+		Device: models.Device{
+			LocalId:      "lid1",
+			Name:         "foo",
+			DeviceTypeId: "dt1",
+			Attributes:   []models.Attribute{{Key: "firmware", Value: "1.0", Origin: "gw"}},
+		},
+		UserId: "user1",
+	}
+	t.Run("send device", sendDevice(config, "user1", device))
+
+	t.Run("initial version", func(t *testing.T) {
+		history := model.DeviceHistory{}
+		jsonRequest(t, config, "user1", http.MethodGet, "/devices/lid1/history", nil, http.StatusOK, &history)
+		if len(history.Versions) != 1 || history.Versions[0].Version != 1 || history.Versions[0].Change != model.ChangeSet {
+			t.Fatalf("%#v", history)
+		}
+		expectedDiff := []model.FieldChange{
+			{Field: "name", Old: "", New: "foo"},
+			{Field: "device_type_id", Old: "", New: "dt1"},
+			{Field: "attr.firmware", Old: nil, New: map[string]any{"value": "1.0", "origin": "gw"}},
+		}
+		if !reflect.DeepEqual(history.Versions[0].Diff, expectedDiff) {
+			t.Errorf("%#v", history.Versions[0].Diff)
+		}
+	})
+
+	t.Run("patch device", patchDevice(config, "user1", "lid1", model.MergePatchContentType, `{"name": "bar"}`, http.StatusOK))
+	t.Run("hide device", hideDevice(config, "user1", "lid1"))
+	t.Run("show device", showDevice(config, "user1", "lid1"))
+	device.Attributes = []models.Attribute{{Key: "firmware", Value: "1.1", Origin: "gw"}}
+	t.Run("send device again", sendDevice(config, "user1", device))
+
+	t.Run("bounded versions with diffs", func(t *testing.T) {
+		history := model.DeviceHistory{}
+		jsonRequest(t, config, "user1", http.MethodGet, "/devices/lid1/history", nil, http.StatusOK, &history)
+		changes := []string{}
+		for _, version := range history.Versions {
+			changes = append(changes, strconv.FormatInt(version.Version, 10)+":"+version.Change)
+		}
+		if !reflect.DeepEqual(changes, []string{"5:set", "4:show", "3:hide"}) {
+			t.Fatal(changes)
+		}
+		expectedDiff := []model.FieldChange{{Field: "attr.firmware", Old: map[string]any{"value": "1.0", "origin": "gw"}, New: map[string]any{"value": "1.1", "origin": "gw"}}}
+		if !reflect.DeepEqual(history.Versions[0].Diff, expectedDiff) {
+			t.Errorf("%#v", history.Versions[0].Diff)
+		}
+		if !reflect.DeepEqual(history.Versions[1].Diff, []model.FieldChange{{Field: "hidden", Old: true, New: false}}) {
+			t.Errorf("%#v", history.Versions[1].Diff)
+		}
+		if history.Versions[2].Diff != nil {
+			t.Errorf("expect unknown diff of oldest kept version, got %#v", history.Versions[2].Diff)
+		}
+		if history.Versions[0].Device.Name != "bar" || history.Versions[2].Device.Hidden != true {
+			t.Errorf("%#v", history.Versions)
+		}
+	})
+
+	t.Run("restore version", func(t *testing.T) {
+		restored := model.Device{}
+		jsonRequest(t, config, "user1", http.MethodPost, "/devices/lid1/history/3/restore", nil, http.StatusOK, &restored)
+		if !restored.Hidden || restored.Attributes[0].Value != "1.0" {
+			t.Errorf("%#v", restored)
+		}
+		actual := readDeviceResult(t, config, "user1", "lid1")
+		if !actual.Hidden || actual.Attributes[0].Value != "1.0" || actual.Name != "bar" {
+			t.Errorf("%#v", actual)
+		}
+		history := model.DeviceHistory{}
+		jsonRequest(t, config, "user1", http.MethodGet, "/devices/lid1/history", nil, http.StatusOK, &history)
+		if history.Versions[0].Version != 6 || history.Versions[0].Change != model.ChangeRestore {
+			t.Errorf("%#v", history.Versions[0])
+		}
+	})
+
+	t.Run("use device", useDevice(config, "user1", "lid1"))
+	t.Run("restore used device", func(t *testing.T) {
+		history := model.DeviceHistory{}
+		jsonRequest(t, config, "user1", http.MethodGet, "/devices/lid1/history", nil, http.StatusOK, &history)
+		if history.Versions[0].Version != 7 || history.Versions[0].Change != model.ChangeUse {
+			t.Fatalf("%#v", history.Versions[0])
+		}
+		jsonRequest(t, config, "user1", http.MethodPost, "/devices/lid1/history/7/restore", nil, http.StatusOK, nil)
+		actual := readDeviceResult(t, config, "user1", "lid1")
+		if actual.LocalId != "lid1" || actual.Name != "bar" {
+			t.Errorf("%#v", actual)
+		}
+	})
+
+	t.Run("delete device", deleteDevice(config, "user1", "lid1"))
+	t.Run("restore trashed device", func(t *testing.T) {
+		jsonRequest(t, config, "user1", http.MethodPost, "/devices/lid1/history/7/restore", nil, http.StatusConflict, nil)
+		list := model.DeviceList{}
+		jsonRequest(t, config, "user1", http.MethodGet, "/deleted/devices", nil, http.StatusOK, &list)
+		if list.Total != 1 || list.Result[0].LocalId != "lid1" {
+			t.Fatalf("%#v", list)
+		}
+		jsonRequest(t, config, "user1", http.MethodPost, "/restored/devices/lid1", nil, http.StatusOK, nil)
+		jsonRequest(t, config, "user1", http.MethodPost, "/devices/lid1/history/7/restore", nil, http.StatusOK, nil)
+		jsonRequest(t, config, "user1", http.MethodGet, "/deleted/devices", nil, http.StatusOK, &list)
+		if list.Total != 0 {
+			t.Errorf("%#v", list)
+		}
+	})
+
+	t.Run("foreign history", func(t *testing.T) {
+		jsonRequest(t, config, "user2", http.MethodGet, "/devices/lid1/history", nil, http.StatusNotFound, nil)
+		jsonRequest(t, config, "user2", http.MethodPost, "/devices/lid1/history/7/restore", nil, http.StatusNotFound, nil)
+	})
+	t.Run("unknown version", func(t *testing.T) {
+		jsonRequest(t, config, "user1", http.MethodPost, "/devices/lid1/history/1/restore", nil, http.StatusNotFound, nil)
+		jsonRequest(t, config, "user1", http.MethodPost, "/devices/lid1/history/latest/restore", nil, http.StatusBadRequest, nil)
+	})
+}
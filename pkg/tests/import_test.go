@@ -0,0 +1,250 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestImport(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testImport(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testImport(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testImport(t, "sqlite")
+	})
+}
+
+func testImport(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	existing := model.Device{
+		Device: models.Device{
+			LocalId:      "d1",
+			Name:         "old name",
+			DeviceTypeId: "dt1",
+		},
+		UserId: "user1",
+	}
+	foreign := model.Device{
+		Device: models.Device{
+			LocalId:      "d4",
+			Name:         "foreign",
+			DeviceTypeId: "dt1",
+		},
+		UserId: "user2",
+	}
+	t.Run("create existing device", sendDevice(config, "user1", existing))
+	t.Run("create foreign device", sendDevice(config, "user2", foreign))
+
+	csvBody := "Serial;Label;Type;Gateway;Comment\n" +
+		"d1;Device 1;dt1;gw-1;updated\n" +
+		"d2;Device 2;dt2;;created\n" +
+		";Device 3;dt1;gw-1;missing local_id\n" +
+		"d4;Device 4;dt1;gw-1;foreign device\n" +
+		"d2;Device 2;dt2;gw-2;duplicate\n"
+	csvQuery := url.Values{
+		"mapping":   {"Serial=local_id", "Label=name", "Type=device_type_id", "Gateway=attr.gateway"},
+		"delimiter": {";"},
+	}
+	expectedRows := []model.ImportRowResult{
+		{Row: 1, LocalId: "d1", Status: model.ImportStatusUpdated},
+		{Row: 2, LocalId: "d2", Status: model.ImportStatusCreated},
+		{Row: 3, Status: model.ImportStatusFailed, Error: "missing local_id"},
+		{Row: 4, LocalId: "d4", Status: model.ImportStatusFailed, Error: "device d4 not found"},
+		{Row: 5, LocalId: "d2", Status: model.ImportStatusFailed, Error: "duplicate local_id, already used in row 2"},
+	}
+
+	dryRunQuery := url.Values{"dry_run": {"true"}}
+	for key, value := range csvQuery {
+		dryRunQuery[key] = value
+	}
+	t.Run("dry run", importDevices(config, "user1", dryRunQuery, "text/csv", csvBody, model.ImportResult{
+		DryRun:  true,
+		Total:   5,
+		Created: 1,
+		Updated: 1,
+		Failed:  3,
+		Rows:    expectedRows,
+	}))
+	t.Run("check dry run", listDevicesWithQuery(config, "user1", url.Values{}, []model.Device{existing}))
+
+	t.Run("import csv", importDevices(config, "user1", csvQuery, "text/csv", csvBody, model.ImportResult{
+		Total:   5,
+		Created: 1,
+		Updated: 1,
+		Failed:  3,
+		Rows:    expectedRows,
+	}))
+	device1 := model.Device{
+		Device: models.Device{
+			LocalId:      "d1",
+			Name:         "Device 1",
+			DeviceTypeId: "dt1",
+			Attributes:   []models.Attribute{{Key: "gateway", Value: "gw-1"}},
+		},
+		UserId: "user1",
+	}
+	device2 := model.Device{
+		Device: models.Device{
+			LocalId:      "d2",
+			Name:         "Device 2",
+			DeviceTypeId: "dt2",
+		},
+		UserId: "user1",
+	}
+	t.Run("check csv import", listDevicesWithQuery(config, "user1", url.Values{}, []model.Device{device1, device2}))
+	t.Run("check foreign device", readDevice(config, "user2", foreign.LocalId, foreign))
+
+	ndjsonBody := `{"local_id": "d2", "name": "Device 2b", "device_type_id": "dt2", "attributes": [{"key": "a", "value": "1"}], "hidden": true}` + "\n" +
+		`{"local_id": "d3", "name": "Device 3", "device_type_id": "dt3", "attr.gateway": "gw-3"}` + "\n" +
+		"\n" +
+		`{"local_id": "d5", "name": ` + "\n" +
+		`{"local_id": "d6", "attributes": "not a list"}` + "\n"
+	t.Run("import ndjson", importDevices(config, "user1", url.Values{}, "application/x-ndjson", ndjsonBody, model.ImportResult{
+		Total:   4,
+		Created: 1,
+		Updated: 1,
+		Failed:  2,
+		Rows: []model.ImportRowResult{
+			{Row: 1, LocalId: "d2", Status: model.ImportStatusUpdated},
+			{Row: 2, LocalId: "d3", Status: model.ImportStatusCreated},
+			{Row: 3, Status: model.ImportStatusFailed, Error: "unexpected end of JSON input"},
+			{Row: 4, LocalId: "d6", Status: model.ImportStatusFailed, Error: "invalid attributes in column attributes: invalid character 'o' in literal null (expecting 'u')"},
+		},
+	}))
+	device2.Name = "Device 2b"
+	device2.Attributes = []models.Attribute{{Key: "a", Value: "1"}}
+	device3 := model.Device{
+		Device: models.Device{
+			LocalId:      "d3",
+			Name:         "Device 3",
+			DeviceTypeId: "dt3",
+			Attributes:   []models.Attribute{{Key: "gateway", Value: "gw-3"}},
+		},
+		UserId: "user1",
+	}
+	t.Run("check ndjson import", listDevicesWithQuery(config, "user1", url.Values{}, []model.Device{device1, device2, device3}))
+
+	t.Run("unknown mapping field", importDevicesWithStatus(config, "user1", url.Values{"mapping": {"Serial=serial"}}, "text/csv", csvBody, http.StatusBadRequest))
+	t.Run("missing mapped column", importDevicesWithStatus(config, "user1", url.Values{"mapping": {"Foo=local_id"}, "delimiter": {";"}}, "text/csv", csvBody, http.StatusBadRequest))
+	t.Run("unknown content type", importDevicesWithStatus(config, "user1", url.Values{}, "application/xml", "<devices/>", http.StatusUnsupportedMediaType))
+}
+
+func importDevicesRequest(config configuration.Config, userId string, query url.Values, contentType string, body string) (resp *http.Response, err error) {
+	token, err := createToken(userId)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", "http://localhost:"+config.ApiPort+"/import/devices?"+query.Encode(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Content-Type", contentType)
+	return http.DefaultClient.Do(req)
+}
+
+func importDevices(config configuration.Config, userId string, query url.Values, contentType string, body string, expected model.ImportResult) func(t *testing.T) {
+	return func(t *testing.T) {
+		resp, err := importDevicesRequest(config, userId, query, contentType, body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			t.Error(resp.StatusCode, string(b))
+			return
+		}
+		actual := model.ImportResult{}
+		err = json.NewDecoder(resp.Body).Decode(&actual)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("\n%#v\n%#v\n", actual, expected)
+		}
+	}
+}
+
+func importDevicesWithStatus(config configuration.Config, userId string, query url.Values, contentType string, body string, expectedStatusCode int) func(t *testing.T) {
+	return func(t *testing.T) {
+		resp, err := importDevicesRequest(config, userId, query, contentType, body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != expectedStatusCode {
+			b, _ := io.ReadAll(resp.Body)
+			t.Error(resp.StatusCode, string(b))
+		}
+	}
+}
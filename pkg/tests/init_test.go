@@ -3,6 +3,10 @@ package tests
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
@@ -15,6 +19,8 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -44,6 +50,9 @@ func TestInit(t *testing.T) {
 	t.Run("postgres", func(t *testing.T) {
 		testInit(t, "postgres")
 	})
+	t.Run("sqlite", func(t *testing.T) {
+		testInit(t, "sqlite")
+	})
 }
 
 func deployTestPersistenceContainer(dbImpl string, config configuration.Config, ctx context.Context, wg *sync.WaitGroup) (configuration.Config, error) {
@@ -61,6 +70,18 @@ func deployTestPersistenceContainer(dbImpl string, config configuration.Config,
 			return config, err
 		}
 		config.PostgresConnStr = connstr
+	case configuration.Sqlite:
+		dir, err := os.MkdirTemp("", "device-waiting-room-test-")
+		if err != nil {
+			return config, err
+		}
+		config.SqlitePath = filepath.Join(dir, "devices.db")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-ctx.Done()
+			os.RemoveAll(dir)
+		}()
 	default:
 	}
 	return config, nil
@@ -487,17 +508,54 @@ func showDevice(config configuration.Config, userId string, deviceId string) fun
 	}
 }
 
-func createToken(userId string) (token string, err error) {
-	claims := KeycloakClaims{
-		RealmAccess{Roles: []string{}},
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Duration(10 * time.Minute)).Unix(),
-			Issuer:    "test",
-			Subject:   userId,
-		},
+// jsonRequest sends body as json, if not nil, expects the status code and decodes the response into result, if not nil
+func jsonRequest(t *testing.T, config configuration.Config, userId string, method string, path string, body any, expectedStatusCode int, result any) {
+	t.Helper()
+	token, err := createToken(userId)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var reqBody io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		reqBody = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequest(method, "http://localhost:"+config.ApiPort+path, reqBody)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	req.Header.Set("Authorization", token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return
 	}
+	defer resp.Body.Close()
+	if resp.StatusCode != expectedStatusCode {
+		b, _ := io.ReadAll(resp.Body)
+		t.Error(resp.StatusCode, string(b))
+		return
+	}
+	if result != nil {
+		err = json.NewDecoder(resp.Body).Decode(result)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
 
-	jwtoken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+// createToken creates an unsigned token, which is accepted by the REST api (the signature is checked by the gateway)
+func createToken(userId string) (token string, err error) {
+	jwtoken := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims(userId))
 	unsignedTokenString, err := jwtoken.SigningString()
 	if err != nil {
 		return token, err
@@ -507,6 +565,39 @@ func createToken(userId string) (token string, err error) {
 	return token, nil
 }
 
+// createSignedToken creates a token signed by key, as required by the websocket and the grpc api
+func createSignedToken(key *rsa.PrivateKey, userId string) (token string, err error) {
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims(userId)).SignedString(key)
+	if err != nil {
+		return token, err
+	}
+	return "Bearer " + tokenString, nil
+}
+
+// createTestKey generates a key to sign tokens and returns it with its public key in the format of jwt_pub_rsa_key
+func createTestKey() (key *rsa.PrivateKey, pubRsaKey string, err error) {
+	key, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return key, pubRsaKey, err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return key, pubRsaKey, err
+	}
+	return key, base64.StdEncoding.EncodeToString(pub), nil
+}
+
+func testClaims(userId string) KeycloakClaims {
+	return KeycloakClaims{
+		RealmAccess{Roles: []string{}},
+		jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(time.Duration(10 * time.Minute)).Unix(),
+			Issuer:    "test",
+			Subject:   userId,
+		},
+	}
+}
+
 type KeycloakClaims struct {
 	RealmAccess RealmAccess `json:"realm_access"`
 	jwt.StandardClaims
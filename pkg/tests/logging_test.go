@@ -0,0 +1,220 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestId(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testRequestId(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testRequestId(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testRequestId(t, "sqlite")
+	})
+}
+
+func testRequestId(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+	config.LogLevel = "debug"
+	config.DeleteAfterUseWaitDuration = 0
+
+	logs := &syncBuffer{}
+	logger, err := logging.New(logs, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defaultLogger := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(defaultLogger)
+
+	deviceManagerRequestIds := make(chan string, 10)
+	deviceManager := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		deviceManagerRequestIds <- request.Header.Get(logging.RequestIdHeader)
+	}))
+	defer deviceManager.Close()
+	config.DeviceManagerUrl = deviceManager.URL
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("create device", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId: "test_1",
+			Name:    "foo",
+		},
+	}))
+
+	t.Run("generated request id", func(t *testing.T) {
+		resp, err := requestWithId(config, "user1", http.MethodGet, "/devices/test_1", "")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if resp.StatusCode != http.StatusOK || !logging.ValidRequestId(resp.Header.Get(logging.RequestIdHeader)) {
+			t.Error(resp.StatusCode, resp.Header.Get(logging.RequestIdHeader))
+		}
+	})
+
+	t.Run("invalid request id is replaced", func(t *testing.T) {
+		resp, err := requestWithId(config, "user1", http.MethodGet, "/devices/test_1", "invalid id")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if actual := resp.Header.Get(logging.RequestIdHeader); actual == "invalid id" || !logging.ValidRequestId(actual) {
+			t.Error(actual)
+		}
+	})
+
+	t.Run("use device", func(t *testing.T) {
+		resp, err := requestWithId(config, "user1", http.MethodPost, "/used/devices/test_1", "test-request-1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if resp.StatusCode != http.StatusOK || resp.Header.Get(logging.RequestIdHeader) != "test-request-1" {
+			t.Error(resp.StatusCode, resp.Header.Get(logging.RequestIdHeader))
+		}
+	})
+
+	t.Run("device-manager received request id", func(t *testing.T) {
+		select {
+		case actual := <-deviceManagerRequestIds:
+			if actual != "test-request-1" {
+				t.Error(actual)
+			}
+		case <-time.After(time.Second):
+			t.Error("missing device-manager request")
+		}
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		expected := []map[string]interface{}{
+			{"msg": "persistence call", "method": "ReadDevice", "request_id": "test-request-1", "user_id": "user1", "local_id": "test_1"},
+			{"msg": "device created in device-manager", "request_id": "test-request-1", "user_id": "user1", "local_id": "test_1"},
+			{"msg": "persistence call", "method": "RemoveDevice", "request_id": "test-request-1", "user_id": "user1", "local_id": "test_1"},
+			{"msg": "request", "level": "INFO", "request_id": "test-request-1", "path": "/used/devices/test_1", "status": float64(http.StatusOK)},
+		}
+		for _, e := range expected {
+			if !logs.contains(e) {
+				t.Error("missing log record", e)
+			}
+		}
+	})
+}
+
+func requestWithId(config configuration.Config, userId string, method string, path string, requestId string) (resp *http.Response, err error) {
+	token, err := createToken(userId)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, "http://localhost:"+config.ApiPort+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	if requestId != "" {
+		req.Header.Set(logging.RequestIdHeader, requestId)
+	}
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+type syncBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (this *syncBuffer) Write(p []byte) (int, error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return this.buf.Write(p)
+}
+
+// contains checks if a json log record exists, which has all fields of expected
+func (this *syncBuffer) contains(expected map[string]interface{}) bool {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	scanner := bufio.NewScanner(bytes.NewReader(this.buf.Bytes()))
+	for scanner.Scan() {
+		record := map[string]interface{}{}
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+		matches := true
+		for key, value := range expected {
+			if record[key] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testMetrics(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testMetrics(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testMetrics(t, "sqlite")
+	})
+}
+
+func testMetrics(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeleteAfterUseWaitDuration = 0
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		if strings.Contains(string(body), "rejected") {
+			return []byte("rejected"), http.StatusBadRequest
+		}
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	for _, localId := range []string{"d1", "d2", "d3", "rejected"} {
+		t.Run("create "+localId, sendDevice(config, "user1", model.Device{
+			Device: models.Device{
+				LocalId: localId,
+				Name:    localId,
+			},
+		}))
+	}
+	t.Run("hide d2", hideDevice(config, "user1", "d2"))
+	t.Run("use d3", useDevice(config, "user1", "d3"))
+	t.Run("use rejected", func(t *testing.T) {
+		token, err := createToken("user1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req, err := http.NewRequest("POST", "http://localhost:"+config.ApiPort+"/used/devices/rejected", nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Header.Set("Authorization", token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Error(resp.StatusCode)
+		}
+	})
+	t.Run("list", listDevices(config, "user1", model.DeviceList{
+		Total:  2,
+		Limit:  10,
+		Offset: 0,
+		Sort:   "local_id",
+		Result: []model.Device{
+			{Device: models.Device{LocalId: "d1", Name: "d1"}, UserId: "user1"},
+			{Device: models.Device{LocalId: "rejected", Name: "rejected"}, UserId: "user1"},
+		},
+	}))
+
+	t.Run("metrics", expectMetrics(config, []string{
+		`device_waiting_room_http_requests_total{method="GET",route="/devices",status="200"} 1`,
+		`device_waiting_room_http_requests_total{method="PUT",route="/devices/:id",status="200"} 4`,
+		`device_waiting_room_http_requests_total{method="POST",route="/used/devices/:local_id",status="400"} 1`,
+		`device_waiting_room_http_request_duration_seconds_count{method="GET",route="/devices",status="200"} 1`,
+		`device_waiting_room_persistence_duration_seconds_count{backend="` + dbImpl + `",method="ListDevices"} 1`,
+		`device_waiting_room_device_manager_requests_total{outcome="success"} 1`,
+		`device_waiting_room_device_manager_requests_total{outcome="rejected"} 1`,
+		`device_waiting_room_devices{state="visible"} 2`,
+		`device_waiting_room_devices{state="hidden"} 1`,
+		`device_waiting_room_ws_connections 0`,
+		`device_waiting_room_grpc_watch_streams 0`,
+	}))
+}
+
+func expectMetrics(config configuration.Config, expected []string) func(t *testing.T) {
+	return func(t *testing.T) {
+		resp, err := http.Get("http://localhost:" + config.ApiPort + "/metrics")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Error(resp.StatusCode, string(body))
+			return
+		}
+		lines := strings.Split(string(body), "\n")
+		for _, line := range expected {
+			found := false
+			for _, actual := range lines {
+				if actual == line {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Error("missing metric:", line)
+			}
+		}
+	}
+}
@@ -21,8 +21,10 @@ import (
 	"github.com/SENERGY-Platform/device-waiting-room/pkg"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence/migration"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
 	"github.com/SENERGY-Platform/models/go/models"
+	"net/http"
 	"strconv"
 	"sync"
 	"testing"
@@ -102,7 +104,7 @@ func TestMigration(t *testing.T) {
 	mongowg.Wait()
 
 	t.Run("migrate", func(t *testing.T) {
-		err = pkg.Migrate(mongoconfig, configuration.Postgres)
+		err = pkg.Migrate(mongoconfig, configuration.Postgres, migration.Options{})
 		if err != nil {
 			t.Error(err)
 			return
@@ -116,6 +118,14 @@ func TestMigration(t *testing.T) {
 	}
 	time.Sleep(time.Second)
 
+	t.Run("history of user1 foo", func(t *testing.T) {
+		history := model.DeviceHistory{}
+		jsonRequest(t, config, "user1", http.MethodGet, "/devices/foo/history", nil, http.StatusOK, &history)
+		if len(history.Versions) != 1 || history.Versions[0].Version != 1 || history.Versions[0].Device.Name != "bar" {
+			t.Errorf("%#v", history)
+		}
+	})
+
 	t.Run("search user1 foo", searchDevices(config, "user1", "foo", model.DeviceList{
 		Total:  1,
 		Limit:  10,
@@ -215,3 +225,141 @@ func TestMigration(t *testing.T) {
 	}))
 
 }
+
+func TestSqliteMigration(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	sqliteconfig, err := deployTestPersistenceContainer(configuration.Sqlite, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	postgresconfig, err := deployTestPersistenceContainer(configuration.Postgres, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sqliteconfig.PostgresConnStr = postgresconfig.PostgresConnStr
+	postgresconfig.SqlitePath = sqliteconfig.SqlitePath
+
+	sqlitectx, sqlitecancel := context.WithCancel(ctx)
+	defer sqlitecancel()
+	sqlitewg := &sync.WaitGroup{}
+	err = pkg.Start(sqlitectx, sqlitewg, sqliteconfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("create device 1", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId:    "foo",
+			Name:       "bar",
+			Attributes: []models.Attribute{{Key: "gateway", Value: "gw-1"}},
+		},
+	}))
+	t.Run("create device 2", sendDevice(config, "user2", model.Device{
+		Device: models.Device{
+			LocalId: "batz",
+			Name:    "42",
+		},
+	}))
+	t.Run("create device 3", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId: "trashed",
+			Name:    "trashed",
+		},
+	}))
+	t.Run("delete device 3", deleteDevice(config, "user1", "trashed"))
+
+	sqlitecancel()
+	sqlitewg.Wait()
+
+	t.Run("migrate sqlite to postgres", func(t *testing.T) {
+		err = pkg.Migrate(sqliteconfig, configuration.Postgres, migration.Options{DeleteSource: true})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	t.Run("migrate postgres to sqlite", func(t *testing.T) {
+		err = pkg.Migrate(postgresconfig, configuration.Sqlite, migration.Options{})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	})
+
+	err = pkg.Start(ctx, wg, sqliteconfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("search user1 gw", searchDevices(config, "user1", "gw", model.DeviceList{
+		Total:  1,
+		Limit:  10,
+		Offset: 0,
+		Sort:   "local_id",
+		Search: "gw",
+		Result: []model.Device{
+			{
+				Device: models.Device{
+					LocalId:    "foo",
+					Name:       "bar",
+					Attributes: []models.Attribute{{Key: "gateway", Value: "gw-1"}},
+				},
+				UserId: "user1",
+				Hidden: false,
+			},
+		},
+	}))
+
+	t.Run("search user2 42", searchDevices(config, "user2", "42", model.DeviceList{
+		Total:  1,
+		Limit:  10,
+		Offset: 0,
+		Sort:   "local_id",
+		Search: "42",
+		Result: []model.Device{
+			{
+				Device: models.Device{
+					LocalId: "batz",
+					Name:    "42",
+				},
+				UserId: "user2",
+				Hidden: false,
+			},
+		},
+	}))
+
+	t.Run("trash of user1", func(t *testing.T) {
+		list := model.DeviceList{}
+		jsonRequest(t, config, "user1", http.MethodGet, "/deleted/devices", nil, http.StatusOK, &list)
+		if list.Total != 1 || len(list.Result) != 1 || list.Result[0].LocalId != "trashed" || list.Result[0].DeletedAt == nil {
+			t.Errorf("%#v", list)
+		}
+	})
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOpenApi(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testOpenApi(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testOpenApi(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testOpenApi(t, "sqlite")
+	})
+}
+
+func testOpenApi(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("get openapi.json", func(t *testing.T) {
+		resp, err := http.Get("http://localhost:" + config.ApiPort + "/openapi.json")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		doc := map[string]interface{}{}
+		err = json.NewDecoder(resp.Body).Decode(&doc)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if resp.StatusCode != http.StatusOK || doc["openapi"] == nil || doc["paths"] == nil {
+			t.Error(resp.StatusCode, doc)
+		}
+	})
+
+	t.Run("get openapi.yaml", func(t *testing.T) {
+		resp, err := http.Get("http://localhost:" + config.ApiPort + "/openapi.yaml")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(string(b), "openapi: 3") {
+			t.Error(resp.StatusCode, string(b))
+		}
+	})
+
+	t.Run("send valid device", sendDevice(config, "user1", model.Device{Device: models.Device{LocalId: "lid1", Name: "foo", DeviceTypeId: "dt1"}}))
+
+	t.Run("invalid device body", validationRequest(config, "PUT", "/devices/lid1", "", `{"local_id": "lid1", "name": 42}`, http.StatusBadRequest, "name"))
+	t.Run("invalid device body with content type", validationRequest(config, "PUT", "/devices/lid1", "application/json; charset=utf-8", `{"local_id": "lid1", "hidden": "yes"}`, http.StatusBadRequest, "hidden"))
+	t.Run("invalid id list", validationRequest(config, "PUT", "/hidden/devices", "", `[1, 2]`, http.StatusBadRequest, ""))
+	t.Run("invalid limit", validationRequest(config, "GET", "/devices?limit=foo", "", "", http.StatusBadRequest, "limit"))
+	t.Run("negative offset", validationRequest(config, "GET", "/devices?offset=-1", "", "", http.StatusBadRequest, "offset"))
+	t.Run("invalid created_after", validationRequest(config, "GET", "/devices?created_after=yesterday", "", "", http.StatusBadRequest, "created_after"))
+	t.Run("invalid export format", validationRequest(config, "GET", "/export/devices?format=xml", "", "", http.StatusBadRequest, "format"))
+	t.Run("valid list", validationRequest(config, "GET", "/devices?limit=10&show_hidden=true", "", "", http.StatusOK, ""))
+	t.Run("unknown route", validationRequest(config, "GET", "/unknown", "", "", http.StatusNotFound, ""))
+
+	t.Run("check device unchanged", readDevice(config, "user1", "lid1", model.Device{Device: models.Device{LocalId: "lid1", Name: "foo", DeviceTypeId: "dt1"}, UserId: "user1"}))
+}
+
+func validationRequest(config configuration.Config, method string, path string, contentType string, body string, expectedStatusCode int, expectedMessage string) func(t *testing.T) {
+	return func(t *testing.T) {
+		token, err := createToken("user1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req, err := http.NewRequest(method, "http://localhost:"+config.ApiPort+path, strings.NewReader(body))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Header.Set("Authorization", token)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != expectedStatusCode {
+			t.Error(resp.StatusCode, string(b))
+			return
+		}
+		if !strings.Contains(string(b), expectedMessage) {
+			t.Error(string(b))
+		}
+	}
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPatch(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testPatch(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testPatch(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testPatch(t, "sqlite")
+	})
+}
+
+func testPatch(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	device := model.Device{
+		Device: models.Device{
+			LocalId:      "lid1",
+			Name:         "foo",
+			DeviceTypeId: "dt1",
+			Attributes: []models.Attribute{
+				{Key: "gateway", Value: "gw-1", Origin: "gw"},
+				{Key: "network", Value: "n1"},
+			},
+		},
+		UserId: "user1",
+	}
+	t.Run("send device", sendDevice(config, "user1", device))
+
+	//patched fields are overrides of the values reported by the gateway
+	device.Reported = &model.Reported{Name: device.Name, DeviceTypeId: device.DeviceTypeId, Attributes: device.Attributes}
+	device.Name = "bar"
+	device.Attributes = []models.Attribute{{Key: "gateway", Value: "gw-2", Origin: "gw"}, {Key: "room", Value: "kitchen"}}
+	device.Overrides = []string{"attr.gateway", "attr.network", "attr.room", "name"}
+	t.Run("merge patch", patchDevice(config, "user1", "lid1", model.MergePatchContentType,
+		`{"name": "bar", "attributes": {"gateway": {"value": "gw-2"}, "network": null, "room": {"value": "kitchen"}}}`, http.StatusOK))
+	t.Run("check merge patch", readDevice(config, "user1", "lid1", device))
+
+	device.DeviceTypeId = "dt2"
+	device.Attributes = []models.Attribute{{Key: "gateway", Value: "gw-3", Origin: "gw"}}
+	device.Overrides = []string{"attr.gateway", "attr.network", "device_type_id", "name"}
+	t.Run("json patch", patchDevice(config, "user1", "lid1", model.JsonPatchContentType,
+		`[{"op": "test", "path": "/name", "value": "bar"}, {"op": "replace", "path": "/device_type_id", "value": "dt2"}, {"op": "replace", "path": "/attributes/gateway/value", "value": "gw-3"}, {"op": "remove", "path": "/attributes/room"}]`, http.StatusOK))
+	t.Run("check json patch", readDevice(config, "user1", "lid1", device))
+
+	t.Run("failed test operation", patchDevice(config, "user1", "lid1", model.JsonPatchContentType, `[{"op": "test", "path": "/name", "value": "foo"}, {"op": "replace", "path": "/name", "value": "baz"}]`, http.StatusConflict))
+	t.Run("change local_id", patchDevice(config, "user1", "lid1", model.MergePatchContentType, `{"local_id": "lid2"}`, http.StatusBadRequest))
+	t.Run("invalid attribute", patchDevice(config, "user1", "lid1", model.MergePatchContentType, `{"attributes": {"gateway": 42}}`, http.StatusBadRequest))
+	t.Run("unsupported content type", patchDevice(config, "user1", "lid1", "text/plain", `name=baz`, http.StatusUnsupportedMediaType))
+	t.Run("foreign device", patchDevice(config, "user2", "lid1", model.MergePatchContentType, `{"name": "baz"}`, http.StatusForbidden))
+	t.Run("unknown device", patchDevice(config, "user1", "unknown", model.MergePatchContentType, `{"name": "baz"}`, http.StatusNotFound))
+	t.Run("check unchanged", readDevice(config, "user1", "lid1", device))
+
+	t.Run("concurrent patches", func(t *testing.T) {
+		mux := sync.Mutex{}
+		applied := []string{}
+		patchWg := sync.WaitGroup{}
+		for i := 0; i < 8; i++ {
+			key := "attr" + strconv.Itoa(i)
+			patchWg.Add(1)
+			go func() {
+				defer patchWg.Done()
+				resp, err := patchDeviceRequest(config, "user1", "lid1", model.MergePatchContentType, `{"attributes": {"`+key+`": {"value": "v"}}}`)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				defer resp.Body.Close()
+				switch resp.StatusCode {
+				case http.StatusOK:
+					mux.Lock()
+					applied = append(applied, key)
+					mux.Unlock()
+				case http.StatusConflict:
+				default:
+					b, _ := io.ReadAll(resp.Body)
+					t.Error(resp.StatusCode, string(b))
+				}
+			}()
+		}
+		patchWg.Wait()
+		if len(applied) == 0 {
+			t.Error("no patch applied")
+		}
+		actual := readDeviceResult(t, config, "user1", "lid1")
+		for _, key := range applied {
+			found := false
+			for _, attr := range actual.Attributes {
+				found = found || attr.Key == key
+			}
+			if !found {
+				t.Error("lost update of", key, actual.Attributes)
+			}
+		}
+	})
+}
+
+func patchDeviceRequest(config configuration.Config, userId string, localId string, contentType string, patch string) (resp *http.Response, err error) {
+	token, err := createToken(userId)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPatch, "http://localhost:"+config.ApiPort+"/devices/"+localId, strings.NewReader(patch))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Content-Type", contentType)
+	return http.DefaultClient.Do(req)
+}
+
+func patchDevice(config configuration.Config, userId string, localId string, contentType string, patch string, expectedStatusCode int) func(t *testing.T) {
+	return func(t *testing.T) {
+		resp, err := patchDeviceRequest(config, userId, localId, contentType, patch)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != expectedStatusCode {
+			b, _ := io.ReadAll(resp.Body)
+			t.Error(resp.StatusCode, string(b))
+		}
+	}
+}
+
+func readDeviceResult(t *testing.T, config configuration.Config, userId string, localId string) (result model.Device) {
+	token, err := createToken(userId)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:"+config.ApiPort+"/devices/"+localId, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	req.Header.Set("Authorization", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		t.Error(err)
+	}
+	return result
+}
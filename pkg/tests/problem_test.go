@@ -0,0 +1,186 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/logging"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProblemDetails(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testProblemDetails(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testProblemDetails(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testProblemDetails(t, "sqlite")
+	})
+}
+
+func testProblemDetails(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		device := models.Device{}
+		_ = json.Unmarshal(body, &device)
+		switch device.LocalId {
+		case "rejected":
+			return []byte("invalid device type"), http.StatusBadRequest
+		case "failing":
+			return []byte("internal error"), http.StatusInternalServerError
+		}
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("send device", sendDevice(config, "user1", model.Device{Device: models.Device{LocalId: "lid1", Name: "foo"}}))
+	t.Run("send rejected device", sendDevice(config, "user1", model.Device{Device: models.Device{LocalId: "rejected", Name: "foo"}}))
+	t.Run("send failing device", sendDevice(config, "user1", model.Device{Device: models.Device{LocalId: "failing", Name: "foo"}}))
+
+	t.Run("unknown device", expectProblem(config, "user1", http.MethodGet, "/devices/unknown", model.Problem{
+		Status:  http.StatusNotFound,
+		Code:    model.ErrorCodeNotFound,
+		LocalId: "unknown",
+	}))
+	t.Run("foreign device", expectProblem(config, "user2", http.MethodGet, "/devices/lid1", model.Problem{
+		Status:  http.StatusForbidden,
+		Code:    model.ErrorCodeAccessDenied,
+		LocalId: "lid1",
+	}))
+	t.Run("delete foreign device", expectProblem(config, "user2", http.MethodDelete, "/devices/lid1", model.Problem{
+		Status:  http.StatusForbidden,
+		Code:    model.ErrorCodeAccessDenied,
+		LocalId: "lid1",
+	}))
+	t.Run("missing token", expectProblem(config, "", http.MethodGet, "/devices/lid1", model.Problem{
+		Status: http.StatusUnauthorized,
+		Code:   model.ErrorCodeUnauthorized,
+	}))
+	t.Run("invalid search", expectProblem(config, "user1", http.MethodGet, "/devices?search="+url.QueryEscape("(foo"), model.Problem{
+		Status: http.StatusBadRequest,
+		Code:   model.ErrorCodeInvalidRequest,
+	}))
+	t.Run("invalid body", expectProblem(config, "user1", http.MethodPut, "/hidden/devices", model.Problem{
+		Status: http.StatusBadRequest,
+		Code:   model.ErrorCodeInvalidRequest,
+	}))
+	t.Run("rejected by device-manager", expectProblem(config, "user1", http.MethodPost, "/used/devices/rejected", model.Problem{
+		Status:         http.StatusBadRequest,
+		Code:           model.ErrorCodeDeviceManagerRejected,
+		LocalId:        "rejected",
+		UpstreamStatus: http.StatusBadRequest,
+		Detail:         "invalid device type",
+	}))
+	t.Run("device-manager error", expectProblem(config, "user1", http.MethodPost, "/used/devices/failing", model.Problem{
+		Status:         http.StatusBadGateway,
+		Code:           model.ErrorCodeDeviceManagerRejected,
+		LocalId:        "failing",
+		UpstreamStatus: http.StatusInternalServerError,
+	}))
+	t.Run("read rejected device", readDevice(config, "user1", "rejected", model.Device{Device: models.Device{LocalId: "rejected", Name: "foo"}, UserId: "user1"}))
+}
+
+// expectProblem checks status, code, local_id and upstream_status of the response, and detail if expected.Detail is set
+func expectProblem(config configuration.Config, userId string, method string, path string, expected model.Problem) func(t *testing.T) {
+	return func(t *testing.T) {
+		req, err := http.NewRequest(method, "http://localhost:"+config.ApiPort+path, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if userId != "" {
+			token, err := createToken(userId)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if contentType := resp.Header.Get("Content-Type"); contentType != model.ProblemContentType {
+			t.Error(contentType)
+		}
+		actual := model.Problem{}
+		err = json.NewDecoder(resp.Body).Decode(&actual)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if resp.StatusCode != expected.Status || actual.Status != expected.Status {
+			t.Error(resp.StatusCode, actual)
+		}
+		if actual.Code != expected.Code || actual.Type != model.ProblemTypePrefix+string(expected.Code) || actual.Title == "" {
+			t.Error(actual)
+		}
+		if actual.LocalId != expected.LocalId || actual.UpstreamStatus != expected.UpstreamStatus {
+			t.Error(actual)
+		}
+		if expected.Detail != "" && actual.Detail != expected.Detail {
+			t.Error(actual.Detail)
+		}
+		if actual.Instance != strings.Split(path, "?")[0] || actual.RequestId == "" || actual.RequestId != resp.Header.Get(logging.RequestIdHeader) {
+			t.Error(actual)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProvenance(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testProvenance(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testProvenance(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testProvenance(t, "sqlite")
+	})
+}
+
+func testProvenance(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	reported := model.Device{
+		Device: models.Device{
+			LocalId:      "lid1",
+			Name:         "gw-name",
+			DeviceTypeId: "dt1",
+			Attributes: []models.Attribute{
+				{Key: "gateway", Value: "gw-1", Origin: "gw"},
+				{Key: "firmware", Value: "1.0", Origin: "gw"},
+			},
+		},
+		UserId: "user1",
+	}
+	t.Run("send device", sendDevice(config, "user1", reported))
+	t.Run("read device without overrides", readDevice(config, "user1", "lid1", reported))
+
+	t.Run("patch device", patchDevice(config, "user1", "lid1", model.MergePatchContentType,
+		`{"name": "kitchen lamp", "attributes": {"room": {"value": "kitchen"}}}`, http.StatusOK))
+
+	reported.Name = "gw-name-2"
+	reported.Attributes = []models.Attribute{
+		{Key: "gateway", Value: "gw-1", Origin: "gw"},
+		{Key: "firmware", Value: "1.1", Origin: "gw"},
+	}
+	t.Run("upsert by gateway", setDeviceWithQuery(config, "user1", reported, "", http.StatusOK))
+
+	effective := reported
+	effective.Name = "kitchen lamp"
+	effective.Attributes = append(effective.Attributes, models.Attribute{Key: "room", Value: "kitchen"})
+	effective.Reported = &model.Reported{Name: reported.Name, DeviceTypeId: reported.DeviceTypeId, Attributes: reported.Attributes}
+	effective.Overrides = []string{"attr.room", "name"}
+	t.Run("read device keeps overrides", readDevice(config, "user1", "lid1", effective))
+
+	t.Run("invalid overwrite", setDeviceWithQuery(config, "user1", reported, "?overwrite=maybe", http.StatusBadRequest))
+	t.Run("upsert with overwrite", setDeviceWithQuery(config, "user1", reported, "?overwrite=true", http.StatusOK))
+	t.Run("read device without overrides after overwrite", readDevice(config, "user1", "lid1", reported))
+}
+
+func setDeviceWithQuery(config configuration.Config, userId string, device model.Device, query string, expectedStatusCode int) func(t *testing.T) {
+	return func(t *testing.T) {
+		token, err := createToken(userId)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b := new(bytes.Buffer)
+		err = json.NewEncoder(b).Encode(device)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req, err := http.NewRequest(http.MethodPut, "http://localhost:"+config.ApiPort+"/devices/"+device.LocalId+query, b)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Header.Set("Authorization", token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != expectedStatusCode {
+			b, _ := io.ReadAll(resp.Body)
+			t.Error(resp.StatusCode, string(b))
+		}
+	}
+}
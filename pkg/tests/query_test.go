@@ -0,0 +1,180 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testQuery(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testQuery(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testQuery(t, "sqlite")
+	})
+}
+
+func testQuery(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	device1 := model.Device{
+		Device: models.Device{
+			LocalId:      "thermostat-1",
+			Name:         "Thermostat Kitchen",
+			DeviceTypeId: "urn:infai:ses:device-type:thermostat",
+			Attributes: []models.Attribute{
+				{Key: "gateway", Value: "gw-12"},
+			},
+		},
+		UserId: "user1",
+	}
+	device2 := model.Device{
+		Device: models.Device{
+			LocalId:      "thermostat-2",
+			Name:         "Thermostat Living Room",
+			DeviceTypeId: "urn:infai:ses:device-type:thermostat",
+			Attributes: []models.Attribute{
+				{Key: "gateway", Value: "gw-13"},
+			},
+		},
+		UserId: "user1",
+	}
+	device3 := model.Device{
+		Device: models.Device{
+			LocalId:      "lamp-1",
+			Name:         "Lamp 100%",
+			DeviceTypeId: "urn:infai:ses:device-type:lamp",
+		},
+		UserId: "user1",
+	}
+
+	t.Run("create device 1", sendDevice(config, "user1", device1))
+	t.Run("create device 2", sendDevice(config, "user1", device2))
+	t.Run("create device 3", sendDevice(config, "user1", device3))
+	t.Run("hide device 2", hideDevice(config, "user1", device2.LocalId))
+	device2.Hidden = true
+
+	search := func(query string, expected ...model.Device) func(t *testing.T) {
+		if expected == nil {
+			expected = []model.Device{}
+		}
+		return listDevicesWithQuery(config, "user1", url.Values{"search": {query}, "sort": {"local_id"}}, expected)
+	}
+
+	t.Run("text", search("therm", device1))
+	t.Run("quoted text", search(`"thermostat kitchen"`, device1))
+	t.Run("name wildcard", search("name:therm*", device1))
+	t.Run("name case insensitive", search("name:THERMOSTAT*", device1))
+	t.Run("name without wildcard", search("name:therm"))
+	t.Run("name like characters", search("name:lamp_100%"))
+	t.Run("name percent", search("name:*100%", device3))
+	t.Run("type", search("type:urn:infai:ses:device-type:thermostat", device1))
+	t.Run("local_id", search("local_id:*-1", device3, device1))
+	t.Run("attribute", search("attr.gateway=gw-12", device1))
+	t.Run("attribute wildcard", search("attr.gateway=GW-*", device1))
+	t.Run("attribute exists", search("attr.gateway", device1))
+	t.Run("attribute exists incl hidden", search("attr.gateway (hidden OR -hidden)", device1, device2))
+	t.Run("hidden", search("hidden", device2))
+	t.Run("not hidden", search("-hidden", device3, device1))
+	t.Run("negation", search("-lamp", device1))
+	t.Run("or", search("lamp OR kitchen", device3, device1))
+	t.Run("grouping", search("(lamp OR kitchen) -name:lamp*", device1))
+	t.Run("combined", search("name:therm* type:urn:infai:ses:device-type:thermostat attr.gateway=gw-1* -hidden", device1))
+	t.Run("urn as text", search("urn:infai:ses:device-type:lamp", device3))
+
+	t.Run("invalid", invalidSearch(config, "user1", "(therm", "position 0"))
+	t.Run("invalid attribute", invalidSearch(config, "user1", "attr.gateway=", "position 13"))
+}
+
+func invalidSearch(config configuration.Config, userId string, query string, expectedMessage string) func(t *testing.T) {
+	return func(t *testing.T) {
+		token, err := createToken(userId)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req, err := http.NewRequest("GET", "http://localhost:"+config.ApiPort+"/devices?"+url.Values{"search": {query}}.Encode(), nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Header.Set("Authorization", token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Error(resp.StatusCode, string(b))
+			return
+		}
+		if !strings.Contains(string(b), expectedMessage) {
+			t.Error(string(b))
+		}
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+	config.DeviceManagerUrl = "http://localhost:1"
+	config.JwtPubRsaKey = ""
+	config.WsPingPeriod = configuration.Duration(time.Hour)
+	config.CorsAllowedOrigins = []string{"https://allowed.example.com"}
+	config.ConfigWatchInterval = configuration.Duration(100 * time.Millisecond)
+
+	config, err = deployTestPersistenceContainer(configuration.Sqlite, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	location := filepath.Join(t.TempDir(), "config.json")
+	writeConfig := func(t *testing.T, config configuration.Config) {
+		b, err := json.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(location, b, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(t, config)
+
+	service, err := pkg.StartService(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	service.WatchConfig(ctx, wg, location)
+	time.Sleep(time.Second)
+
+	pings := atomic.Int64{}
+	conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+config.ApiPort+"/events", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	conn.SetPingHandler(func(string) error {
+		pings.Add(1)
+		return nil
+	})
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	t.Run("initial", func(t *testing.T) {
+		if origin := corsOrigin(t, config, "https://other.example.com"); origin != "" {
+			t.Error(origin)
+		}
+		checkHealth(config, "/health/ready", http.StatusServiceUnavailable, model.Health{
+			Status: model.HealthFailed,
+			Checks: map[string]model.HealthCheck{
+				"database": {Status: model.HealthOk},
+				"jwt_key":  {Status: model.HealthFailed, Error: "invalid jwt_pub_rsa_key: missing public key"},
+			},
+		})(t)
+		if pings.Load() != 0 {
+			t.Error(pings.Load())
+		}
+	})
+
+	changed := config
+	changed.JwtPubRsaKey = testPubRsaKey
+	changed.WsPingPeriod = configuration.Duration(100 * time.Millisecond)
+	changed.CorsAllowedOrigins = []string{"https://allowed.example.com", "https://other.example.com"}
+	changed.Debug = true
+	changed.TrashRetention = configuration.Duration(time.Minute)
+
+	t.Run("file change", func(t *testing.T) {
+		writeConfig(t, changed)
+		time.Sleep(time.Second)
+		if origin := corsOrigin(t, config, "https://other.example.com"); origin != "https://other.example.com" {
+			t.Error(origin)
+		}
+		checkHealth(config, "/health/ready", http.StatusOK, model.Health{
+			Status: model.HealthOk,
+			Checks: map[string]model.HealthCheck{
+				"database": {Status: model.HealthOk},
+				"jwt_key":  {Status: model.HealthOk},
+			},
+		})(t)
+		//the open connection switches from the hourly ping to the new period without waiting for the next ping
+		if pings.Load() < 3 {
+			t.Error(pings.Load())
+		}
+		effective := service.Config()
+		if !effective.Debug || effective.TrashRetention != config.TrashRetention {
+			t.Error(effective.Debug, effective.TrashRetention)
+		}
+	})
+
+	t.Run("restart required", func(t *testing.T) {
+		next := changed
+		next.ApiPort = "1"
+		next.HistoryLimit = 5
+		applied, restartRequired, err := service.Reload(next)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(applied, []string{"history_limit"}) {
+			t.Error(applied)
+		}
+		if !reflect.DeepEqual(restartRequired, []string{"api_port", "trash_retention"}) {
+			t.Error(restartRequired)
+		}
+		if service.Config().ApiPort != config.ApiPort || service.Config().HistoryLimit != 5 {
+			t.Error(service.Config().ApiPort, service.Config().HistoryLimit)
+		}
+	})
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		next := changed
+		next.WsPingPeriod = 0
+		next.HistoryLimit = 7
+		_, _, err := service.Reload(next)
+		if err == nil {
+			t.Error("expected error")
+		}
+		if service.Config().WsPingPeriod != changed.WsPingPeriod || service.Config().HistoryLimit != 5 {
+			t.Error(service.Config().WsPingPeriod, service.Config().HistoryLimit)
+		}
+	})
+
+	t.Run("SIGHUP", func(t *testing.T) {
+		//the file content is unchanged, so the reload is only triggered by the signal
+		err := syscall.Kill(os.Getpid(), syscall.SIGHUP)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		time.Sleep(500 * time.Millisecond)
+		if service.Config().HistoryLimit != changed.HistoryLimit {
+			t.Error(service.Config().HistoryLimit)
+		}
+	})
+}
+
+func corsOrigin(t *testing.T, config configuration.Config, origin string) string {
+	req, err := http.NewRequest(http.MethodOptions, "http://localhost:"+config.ApiPort+"/devices", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Origin", origin)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Access-Control-Allow-Origin")
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/persistence"
+	"sync"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testSchema(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testSchema(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testSchema(t, "sqlite")
+	})
+}
+
+func testSchema(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("pending before start", func(t *testing.T) {
+		status, err := persistence.SchemaStatus(config)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(status) == 0 {
+			t.Error("expected known migrations")
+			return
+		}
+		for _, migration := range status {
+			if migration.Applied() {
+				t.Error("unexpected applied migration", migration)
+			}
+		}
+	})
+
+	t.Run("concurrent start", func(t *testing.T) {
+		instances := &sync.WaitGroup{}
+		mux := sync.Mutex{}
+		errs := []error{}
+		for i := 0; i < 3; i++ {
+			instances.Add(1)
+			go func() {
+				defer instances.Done()
+				_, err := persistence.New(ctx, wg, config)
+				mux.Lock()
+				defer mux.Unlock()
+				errs = append(errs, err)
+			}()
+		}
+		instances.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("applied after start", func(t *testing.T) {
+		status, err := persistence.SchemaStatus(config)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		for i, migration := range status {
+			if !migration.Applied() {
+				t.Error("expected applied migration", migration)
+			}
+			if migration.Version != i+1 {
+				t.Error("unexpected version order", status)
+			}
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
 	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
 	"github.com/SENERGY-Platform/models/go/models"
+	"net/url"
 	"strconv"
 	"sync"
 	"testing"
@@ -20,6 +21,9 @@ func TestSearch(t *testing.T) {
 	t.Run("postgres", func(t *testing.T) {
 		testSearch(t, "postgres")
 	})
+	t.Run("sqlite", func(t *testing.T) {
+		testSearch(t, "sqlite")
+	})
 }
 
 func testSearch(t *testing.T, dbImpl string) {
@@ -156,6 +160,9 @@ func TestSearch2(t *testing.T) {
 	t.Run("postgres", func(t *testing.T) {
 		testSearch2(t, "postgres")
 	})
+	t.Run("sqlite", func(t *testing.T) {
+		testSearch2(t, "sqlite")
+	})
 }
 
 func testSearch2(t *testing.T, dbImpl string) {
@@ -318,6 +325,9 @@ func TestSearch3(t *testing.T) {
 	t.Run("postgres", func(t *testing.T) {
 		testSearch3(t, "postgres")
 	})
+	t.Run("sqlite", func(t *testing.T) {
+		testSearch3(t, "sqlite")
+	})
 }
 
 func testSearch3(t *testing.T, dbImpl string) {
@@ -446,69 +456,57 @@ func testSearch3(t *testing.T, dbImpl string) {
 		},
 	}))
 
-	if dbImpl == "mongo" {
-		t.Run("search 252", func(t *testing.T) {
-			t.Skip("not implemented for mongo")
-		})
-	} else {
-		t.Run("search 252", searchDevices(config, "user1", "252", model.DeviceList{
-			Total:  2,
-			Limit:  10,
-			Offset: 0,
-			Sort:   "local_id",
-			Search: "252",
-			Result: []model.Device{
-				{
-					Device: models.Device{
-						LocalId: "2",
-						Name:    "HYD WATER 2520611",
-					},
-					UserId: "user1",
-					Hidden: false,
+	t.Run("search 252", searchDevices(config, "user1", "252", model.DeviceList{
+		Total:  2,
+		Limit:  10,
+		Offset: 0,
+		Sort:   "local_id",
+		Search: "252",
+		Result: []model.Device{
+			{
+				Device: models.Device{
+					LocalId: "2",
+					Name:    "HYD WATER 2520611",
 				},
-				{
-					Device: models.Device{
-						LocalId: "3",
-						Name:    "TECH AIR 2520622",
-					},
-					UserId: "user1",
-					Hidden: false,
+				UserId: "user1",
+				Hidden: false,
+			},
+			{
+				Device: models.Device{
+					LocalId: "3",
+					Name:    "TECH AIR 2520622",
 				},
+				UserId: "user1",
+				Hidden: false,
 			},
-		}))
-	}
+		},
+	}))
 
-	if dbImpl == "mongo" {
-		t.Run("search 520", func(t *testing.T) {
-			t.Skip("not implemented for mongo")
-		})
-	} else {
-		t.Run("search 520", searchDevices(config, "user1", "520", model.DeviceList{
-			Total:  2,
-			Limit:  10,
-			Offset: 0,
-			Sort:   "local_id",
-			Search: "520",
-			Result: []model.Device{
-				{
-					Device: models.Device{
-						LocalId: "2",
-						Name:    "HYD WATER 2520611",
-					},
-					UserId: "user1",
-					Hidden: false,
+	t.Run("search 520", searchDevices(config, "user1", "520", model.DeviceList{
+		Total:  2,
+		Limit:  10,
+		Offset: 0,
+		Sort:   "local_id",
+		Search: "520",
+		Result: []model.Device{
+			{
+				Device: models.Device{
+					LocalId: "2",
+					Name:    "HYD WATER 2520611",
 				},
-				{
-					Device: models.Device{
-						LocalId: "3",
-						Name:    "TECH AIR 2520622",
-					},
-					UserId: "user1",
-					Hidden: false,
+				UserId: "user1",
+				Hidden: false,
+			},
+			{
+				Device: models.Device{
+					LocalId: "3",
+					Name:    "TECH AIR 2520622",
 				},
+				UserId: "user1",
+				Hidden: false,
 			},
-		}))
-	}
+		},
+	}))
 
 	t.Run("search 79606", searchDevices(config, "user1", "79606", model.DeviceList{
 		Total:  1,
@@ -529,3 +527,71 @@ func testSearch3(t *testing.T, dbImpl string) {
 	}))
 
 }
+
+func TestSearchRelevance(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testSearchRelevance(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testSearchRelevance(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testSearchRelevance(t, "sqlite")
+	})
+}
+
+func testSearchRelevance(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	thermostat := model.Device{Device: models.Device{LocalId: "d1", Name: "Living Room Thermostat"}, UserId: "user1"}
+	therm := model.Device{Device: models.Device{LocalId: "d2", Name: "therm"}, UserId: "user1"}
+	isotherm := model.Device{Device: models.Device{LocalId: "d3", Name: "Isotherm Sensor"}, UserId: "user1"}
+	deviceType := model.Device{Device: models.Device{LocalId: "d4", Name: "Lamp", DeviceTypeId: "urn:thermo:type"}, UserId: "user1"}
+	attribute := model.Device{Device: models.Device{LocalId: "d5", Name: "Plug", Attributes: []models.Attribute{{Key: "gateway", Value: "thermal-gw"}}}, UserId: "user1"}
+	long := model.Device{Device: models.Device{LocalId: "d6", Name: "abcdefghijklmnopqrstuvwxyz"}, UserId: "user1"}
+
+	for _, device := range []model.Device{thermostat, therm, isotherm, deviceType, attribute, long} {
+		t.Run("create "+device.LocalId, sendDevice(config, "user1", device))
+	}
+
+	t.Run("search therm", listDevicesWithQuery(config, "user1", url.Values{"search": {"therm"}}, []model.Device{thermostat, therm, isotherm, deviceType, attribute}))
+	t.Run("search THERM by relevance", listDevicesWithQuery(config, "user1", url.Values{"search": {"THERM"}, "sort": {"relevance"}}, []model.Device{therm, thermostat, deviceType, attribute, isotherm}))
+	t.Run("search multiple terms", listDevicesWithQuery(config, "user1", url.Values{"search": {"room therm"}}, []model.Device{thermostat}))
+	t.Run("search attribute value", listDevicesWithQuery(config, "user1", url.Values{"search": {"gw"}}, []model.Device{attribute}))
+	t.Run("search device type", listDevicesWithQuery(config, "user1", url.Values{"search": {"urn"}}, []model.Device{deviceType}))
+	t.Run("search long term", listDevicesWithQuery(config, "user1", url.Values{"search": {"defghijklmnopqr"}}, []model.Device{long}))
+	t.Run("search long term without match", listDevicesWithQuery(config, "user1", url.Values{"search": {"defghijklmnopqz"}}, []model.Device{}))
+	t.Run("relevance without search", listDevicesWithQuery(config, "user1", url.Values{"sort": {"relevance"}}, []model.Device{thermostat, therm, isotherm, deviceType, attribute, long}))
+}
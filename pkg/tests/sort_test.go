@@ -20,6 +20,9 @@ func TestSortByCreatedAt(t *testing.T) {
 	t.Run("postgres", func(t *testing.T) {
 		testSortByCreatedAt(t, "postgres")
 	})
+	t.Run("sqlite", func(t *testing.T) {
+		testSortByCreatedAt(t, "sqlite")
+	})
 }
 
 func testSortByCreatedAt(t *testing.T, dbImpl string) {
@@ -173,6 +176,9 @@ func TestSortByUpdatedAt(t *testing.T) {
 	t.Run("postgres", func(t *testing.T) {
 		testSortByUpdatedAt(t, "postgres")
 	})
+	t.Run("sqlite", func(t *testing.T) {
+		testSortByUpdatedAt(t, "sqlite")
+	})
 }
 
 func testSortByUpdatedAt(t *testing.T, dbImpl string) {
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTracing(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testTracing(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testTracing(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testTracing(t, "sqlite")
+	})
+}
+
+func testTracing(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+	config.DeleteAfterUseWaitDuration = 0
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defaultProvider, defaultPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(defaultProvider)
+	defer otel.SetTextMapPropagator(defaultPropagator)
+
+	deviceManagerTraceParents := make(chan string, 10)
+	deviceManager := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		deviceManagerTraceParents <- request.Header.Get("traceparent")
+	}))
+	defer deviceManager.Close()
+	config.DeviceManagerUrl = deviceManager.URL
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("create device", sendDevice(config, "user1", model.Device{
+		Device: models.Device{
+			LocalId: "test_1",
+			Name:    "foo",
+		},
+	}))
+
+	traceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+	exporter.Reset()
+
+	t.Run("use device", func(t *testing.T) {
+		token, err := createToken("user1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req, err := http.NewRequest(http.MethodPost, "http://localhost:"+config.ApiPort+"/used/devices/test_1", nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Header.Set("Authorization", token)
+		req.Header.Set("traceparent", "00-"+traceId+"-00f067aa0ba902b7-01")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Error(resp.StatusCode)
+		}
+	})
+
+	t.Run("device-manager received trace context", func(t *testing.T) {
+		select {
+		case actual := <-deviceManagerTraceParents:
+			if len(actual) != 55 || actual[3:35] != traceId {
+				t.Error(actual)
+			}
+		case <-time.After(time.Second):
+			t.Error("missing device-manager request")
+		}
+	})
+
+	t.Run("spans", func(t *testing.T) {
+		time.Sleep(100 * time.Millisecond) //the server span ends after the response has been sent
+		spans := map[string]tracetest.SpanStub{}
+		for _, span := range exporter.GetSpans() {
+			spans[span.Name] = span
+		}
+		expected := []struct {
+			name   string
+			parent string
+			kind   trace.SpanKind
+		}{
+			{name: "POST /used/devices/:local_id", kind: trace.SpanKindServer},
+			{name: "Controller.UseDevice", parent: "POST /used/devices/:local_id", kind: trace.SpanKindInternal},
+			{name: "Persistence.ReadDevice", parent: "Controller.UseDevice", kind: trace.SpanKindClient},
+			{name: "device-manager POST /devices", parent: "Controller.UseDevice", kind: trace.SpanKindClient},
+			{name: "Persistence.RemoveDevice", parent: "Controller.UseDevice", kind: trace.SpanKindClient},
+		}
+		for _, e := range expected {
+			span, ok := spans[e.name]
+			if !ok {
+				t.Error("missing span", e.name, spans)
+				continue
+			}
+			if span.SpanContext.TraceID().String() != traceId || span.SpanKind != e.kind {
+				t.Error(e.name, span.SpanContext.TraceID(), span.SpanKind)
+			}
+			if e.parent != "" && span.Parent.SpanID() != spans[e.parent].SpanContext.SpanID() {
+				t.Error("unexpected parent of", e.name)
+			}
+		}
+	})
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/auth"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/model"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/tests/mocks"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTrash(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testTrash(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testTrash(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testTrash(t, "sqlite")
+	})
+}
+
+func testTrash(t *testing.T, dbImpl string) {
+	timeNow := auth.TimeNow
+	defer func() {
+		auth.TimeNow = timeNow
+	}()
+	auth.TimeNow = func() time.Time {
+		return time.Time{}
+	}
+
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+	config.JwtPubRsaKey = wsTestJwtPubRsaKey
+
+	config.DeviceManagerUrl = mocks.DeviceManager(ctx, wg, func(path string, body []byte, err error) (resp []byte, code int) {
+		return nil, 200
+	})
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	events := subscribeEvents(t, config)
+
+	userId := wsTestUserId
+	device1 := model.Device{Device: models.Device{LocalId: "lid1", Name: "foo", Attributes: []models.Attribute{}}, UserId: userId}
+	device2 := model.Device{Device: models.Device{LocalId: "lid2", Name: "bar", Attributes: []models.Attribute{}}, UserId: userId}
+	t.Run("send device 1", sendDevice(config, userId, device1))
+	t.Run("send device 2", sendDevice(config, userId, device2))
+	t.Run("delete device 1", deleteDevice(config, userId, "lid1"))
+	t.Run("delete device 2", func(t *testing.T) {
+		jsonRequest(t, config, userId, http.MethodDelete, "/devices", []string{"lid2"}, http.StatusOK, nil)
+	})
+	t.Run("deleted device is not listed", headDevice(config, userId, "lid1", http.StatusNotFound))
+
+	t.Run("list trash", func(t *testing.T) {
+		list := model.DeviceList{}
+		jsonRequest(t, config, userId, http.MethodGet, "/deleted/devices", nil, http.StatusOK, &list)
+		if list.Total != 2 || len(list.Result) != 2 || list.Result[0].LocalId != "lid2" || list.Result[1].LocalId != "lid1" || list.Result[0].DeletedAt == nil {
+			t.Errorf("%#v", list)
+		}
+		jsonRequest(t, config, userId, http.MethodGet, "/deleted/devices?limit=1&offset=1", nil, http.StatusOK, &list)
+		if list.Total != 2 || len(list.Result) != 1 || list.Result[0].LocalId != "lid1" {
+			t.Errorf("%#v", list)
+		}
+		jsonRequest(t, config, "user2", http.MethodGet, "/deleted/devices", nil, http.StatusOK, &list)
+		if list.Total != 0 || len(list.Result) != 0 {
+			t.Errorf("%#v", list)
+		}
+	})
+
+	t.Run("restore foreign device", func(t *testing.T) {
+		jsonRequest(t, config, "user2", http.MethodPost, "/restored/devices/lid1", nil, http.StatusForbidden, nil)
+	})
+	t.Run("restore unknown device", func(t *testing.T) {
+		jsonRequest(t, config, userId, http.MethodPost, "/restored/devices/unknown", nil, http.StatusNotFound, nil)
+	})
+	t.Run("restore device 1", func(t *testing.T) {
+		jsonRequest(t, config, userId, http.MethodPost, "/restored/devices", []string{"lid1"}, http.StatusOK, nil)
+	})
+	t.Run("read restored device", readDevice(config, userId, "lid1", device1))
+
+	replacement := model.Device{Device: models.Device{LocalId: "lid2", Name: "replacement", Attributes: []models.Attribute{}}, UserId: userId}
+	t.Run("send replacement of device 2", sendDevice(config, userId, replacement))
+	t.Run("restore replaced device", func(t *testing.T) {
+		jsonRequest(t, config, userId, http.MethodPost, "/restored/devices/lid2", nil, http.StatusConflict, nil)
+	})
+	t.Run("replacement is not overwritten", readDevice(config, userId, "lid2", replacement))
+	t.Run("replaced device stays in the trash", func(t *testing.T) {
+		list := model.DeviceList{}
+		jsonRequest(t, config, userId, http.MethodGet, "/deleted/devices", nil, http.StatusOK, &list)
+		if list.Total != 1 || len(list.Result) != 1 || list.Result[0].LocalId != "lid2" || list.Result[0].Name != "bar" {
+			t.Errorf("%#v", list)
+		}
+	})
+
+	t.Run("restore event", func(t *testing.T) {
+		time.Sleep(500 * time.Millisecond)
+		for _, msg := range events() {
+			if msg.Type == model.WsUpdateRestoreType && msg.Payload == "lid1" {
+				return
+			}
+		}
+		t.Error("missing update_restore event", events())
+	})
+}
+
+func TestTrashPurge(t *testing.T) {
+	t.Run("mongo", func(t *testing.T) {
+		testTrashPurge(t, "mongo")
+	})
+	t.Run("postgres", func(t *testing.T) {
+		testTrashPurge(t, "postgres")
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		testTrashPurge(t, "sqlite")
+	})
+}
+
+func testTrashPurge(t *testing.T, dbImpl string) {
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("./../../config.json")
+	if err != nil {
+		t.Fatal("ERROR: unable to load config", err)
+	}
+	config.TrashRetention = configuration.Duration(time.Second)
+	config.TrashPurgeInterval = configuration.Duration(100 * time.Millisecond)
+
+	config, err = deployTestPersistenceContainer(dbImpl, config, ctx, wg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	freePort, err := getFreePort()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ApiPort = strconv.Itoa(freePort)
+
+	err = pkg.Start(ctx, wg, config)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	time.Sleep(time.Second)
+
+	t.Run("send device", sendDevice(config, "user1", model.Device{Device: models.Device{LocalId: "lid1"}}))
+	t.Run("delete device", deleteDevice(config, "user1", "lid1"))
+	t.Run("purge after retention", func(t *testing.T) {
+		time.Sleep(2 * time.Second)
+		list := model.DeviceList{}
+		jsonRequest(t, config, "user1", http.MethodGet, "/deleted/devices", nil, http.StatusOK, &list)
+		if list.Total != 0 {
+			t.Errorf("%#v", list)
+		}
+		jsonRequest(t, config, "user1", http.MethodPost, "/restored/devices/lid1", nil, http.StatusNotFound, nil)
+	})
+}
+
+// subscribeEvents authenticates with wsTestToken at the /events websocket and returns a function listing the received messages
+func subscribeEvents(t *testing.T, config configuration.Config) func() []model.EventMessage {
+	c, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+config.ApiPort+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+	})
+	messages := []model.EventMessage{}
+	mux := sync.Mutex{}
+	go func() {
+		for {
+			msg := model.EventMessage{}
+			err := c.ReadJSON(&msg)
+			if err != nil {
+				return
+			}
+			mux.Lock()
+			messages = append(messages, msg)
+			mux.Unlock()
+		}
+	}()
+	err = c.WriteJSON(model.EventMessage{Type: model.WsAuthType, Payload: wsTestToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func() []model.EventMessage {
+		mux.Lock()
+		defer mux.Unlock()
+		return append([]model.EventMessage{}, messages...)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tracing configures the OpenTelemetry tracer provider of the service and provides helpers to create spans.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"os"
+)
+
+const ServiceName = "device-waiting-room"
+const instrumentationName = "github.com/SENERGY-Platform/device-waiting-room"
+
+const ExporterNone = "none"
+const ExporterStdout = "stdout"
+const ExporterFile = "file"
+const ExporterOtlp = "otlp"
+
+// Setup sets the global tracer provider and the w3c trace context propagator.
+// The exporter is selected by config.TracingExporter:
+//
+//	"" or none  no spans are exported, incoming trace context is still propagated
+//	stdout      spans are written as json to stdout
+//	file        spans are written as json to config.TracingFile
+//	otlp        spans are sent with otlp/http to config.TracingOtlpEndpoint (or OTEL_EXPORTER_OTLP_ENDPOINT if empty)
+//
+// The returned shutdown function flushes pending spans and has to be called before the program stops.
+func Setup(ctx context.Context, config configuration.Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	exporter, closeOutput, err := newExporter(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		return errors.Join(provider.Shutdown(ctx), closeOutput())
+	}, nil
+}
+
+func newExporter(ctx context.Context, config configuration.Config) (exporter sdktrace.SpanExporter, closeOutput func() error, err error) {
+	closeOutput = func() error { return nil }
+	switch config.TracingExporter {
+	case "", ExporterNone:
+		return nil, closeOutput, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, closeOutput, err
+	case ExporterFile:
+		if config.TracingFile == "" {
+			return nil, closeOutput, errors.New("missing tracing_file for tracing_exporter file")
+		}
+		file, err := os.OpenFile(config.TracingFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, closeOutput, err
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, closeOutput, err
+		}
+		return exporter, file.Close, nil
+	case ExporterOtlp:
+		options := []otlptracehttp.Option{}
+		if config.TracingOtlpEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(config.TracingOtlpEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+		return exporter, closeOutput, err
+	default:
+		return nil, closeOutput, fmt.Errorf("unknown tracing_exporter %v, expected none, stdout, file or otlp", config.TracingExporter)
+	}
+}
+
+// Start creates a span as child of the span in ctx, using the global tracer provider
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, options...)
+}
+
+// End records err in span and ends it. Only server errors (errCode >= 500) mark the span as failed,
+// client errors like a missing device are expected results.
+// Pointers are used, so that End can be deferred with the named results of a function.
+func End(span trace.Span, err *error, errCode *int) {
+	if *err != nil {
+		span.RecordError(*err)
+		if *errCode >= http.StatusInternalServerError || *errCode == 0 {
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+	}
+	if *errCode != 0 {
+		span.SetAttributes(attribute.Int("status_code", *errCode))
+	}
+	span.End()
+}
+
+// Inject adds the trace context of ctx to the headers of an outgoing request
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract returns ctx with the trace context of the headers of an incoming request
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
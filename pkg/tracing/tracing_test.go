@@ -0,0 +1,62 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"github.com/SENERGY-Platform/device-waiting-room/pkg/configuration"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileExporter(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), configuration.Config{TracingExporter: ExporterFile, TracingFile: location})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, span := Start(context.Background(), "test-span")
+	span.End()
+	err = shutdown(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	content, err := os.ReadFile(location)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(string(content), `"Name":"test-span"`) || !strings.Contains(string(content), ServiceName) {
+		t.Error(string(content))
+	}
+}
+
+func TestInvalidExporter(t *testing.T) {
+	for _, config := range []configuration.Config{
+		{TracingExporter: "unknown"},
+		{TracingExporter: ExporterFile},
+	} {
+		_, err := Setup(context.Background(), config)
+		if err == nil {
+			t.Error("expected error", config.TracingExporter)
+		}
+	}
+}